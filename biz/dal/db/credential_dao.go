@@ -0,0 +1,49 @@
+package db
+
+import (
+	"time"
+
+	"github.com/yi-nology/git-manage-service/biz/model/po"
+)
+
+type CredentialDAO struct{}
+
+func NewCredentialDAO() *CredentialDAO {
+	return &CredentialDAO{}
+}
+
+func (d *CredentialDAO) Create(cred *po.Credential) error {
+	return DB.Create(cred).Error
+}
+
+func (d *CredentialDAO) Save(cred *po.Credential) error {
+	return DB.Save(cred).Error
+}
+
+func (d *CredentialDAO) FindAll() ([]po.Credential, error) {
+	var creds []po.Credential
+	err := DB.Order("name asc").Find(&creds).Error
+	return creds, err
+}
+
+func (d *CredentialDAO) FindByID(id uint) (*po.Credential, error) {
+	var cred po.Credential
+	err := DB.First(&cred, id).Error
+	return &cred, err
+}
+
+// NameTaken reports whether another credential than excludeID uses the name
+func (d *CredentialDAO) NameTaken(name string, excludeID uint) (bool, error) {
+	var count int64
+	err := DB.Model(&po.Credential{}).Where("name = ? AND id <> ?", name, excludeID).Count(&count).Error
+	return count > 0, err
+}
+
+func (d *CredentialDAO) Delete(cred *po.Credential) error {
+	return DB.Unscoped().Delete(cred).Error
+}
+
+// TouchLastUsed skips the hooks so the stored secret is left alone
+func (d *CredentialDAO) TouchLastUsed(id uint) error {
+	return DB.Model(&po.Credential{}).Where("id = ?", id).UpdateColumn("last_used_at", time.Now()).Error
+}
@@ -51,21 +51,42 @@ func Init() {
 		log.Fatal("failed to connect database: ", err)
 	}
 
-	// Check if tables exist to skip initialization if requested
-	migrator := DB.Migrator()
-	if migrator.HasTable(&po.Repo{}) &&
-		migrator.HasTable(&po.SyncTask{}) &&
-		migrator.HasTable(&po.SyncRun{}) &&
-		migrator.HasTable(&po.AuditLog{}) &&
-		migrator.HasTable(&po.SystemConfig{}) &&
-		migrator.HasTable(&po.CommitStat{}) {
+	models := []interface{}{
+		&po.Repo{}, &po.SyncTask{}, &po.SyncRun{}, &po.AuditLog{}, &po.SystemConfig{}, &po.CommitStat{},
+		&po.SyncRunStep{}, &po.SyncRunCommit{}, &po.TrustedKey{}, &po.KnownHost{}, &po.SSHKey{}, &po.Credential{},
+		&po.RepoHealth{},
+	}
+
+	// Check if tables (and all their columns) exist to skip initialization if requested
+	if schemaUpToDate(models) {
 		log.Println("Database tables exist, skipping schema migration.")
 		return
 	}
 
 	// Migrate the schema
-	err = DB.AutoMigrate(&po.Repo{}, &po.SyncTask{}, &po.SyncRun{}, &po.AuditLog{}, &po.SystemConfig{}, &po.CommitStat{})
+	err = DB.AutoMigrate(models...)
 	if err != nil {
 		log.Fatal("failed to migrate database: ", err)
 	}
 }
+
+// schemaUpToDate reports whether every model's table and columns already exist,
+// so that fields added in newer versions still trigger a migration.
+func schemaUpToDate(models []interface{}) bool {
+	migrator := DB.Migrator()
+	for _, m := range models {
+		if !migrator.HasTable(m) {
+			return false
+		}
+		stmt := &gorm.Statement{DB: DB}
+		if err := stmt.Parse(m); err != nil {
+			return false
+		}
+		for _, column := range stmt.Schema.DBNames {
+			if !migrator.HasColumn(m, column) {
+				return false
+			}
+		}
+	}
+	return true
+}
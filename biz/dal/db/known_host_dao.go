@@ -0,0 +1,53 @@
+package db
+
+import (
+	"time"
+
+	"github.com/yi-nology/git-manage-service/biz/model/po"
+)
+
+type KnownHostDAO struct{}
+
+func NewKnownHostDAO() *KnownHostDAO {
+	return &KnownHostDAO{}
+}
+
+func (d *KnownHostDAO) Create(host *po.KnownHost) error {
+	return DB.Create(host).Error
+}
+
+func (d *KnownHostDAO) Save(host *po.KnownHost) error {
+	return DB.Save(host).Error
+}
+
+func (d *KnownHostDAO) FindAll() ([]po.KnownHost, error) {
+	var hosts []po.KnownHost
+	err := DB.Order("host asc, id asc").Find(&hosts).Error
+	return hosts, err
+}
+
+func (d *KnownHostDAO) FindByHost(host string) ([]po.KnownHost, error) {
+	var hosts []po.KnownHost
+	err := DB.Where("host = ?", host).Order("id asc").Find(&hosts).Error
+	return hosts, err
+}
+
+func (d *KnownHostDAO) FindByStatus(status string) ([]po.KnownHost, error) {
+	var hosts []po.KnownHost
+	err := DB.Where("status = ?", status).Order("host asc, id asc").Find(&hosts).Error
+	return hosts, err
+}
+
+func (d *KnownHostDAO) FindByID(id uint) (*po.KnownHost, error) {
+	var host po.KnownHost
+	err := DB.First(&host, id).Error
+	return &host, err
+}
+
+func (d *KnownHostDAO) TouchLastSeen(id uint) error {
+	return DB.Model(&po.KnownHost{}).Where("id = ?", id).Update("last_seen_at", time.Now()).Error
+}
+
+func (d *KnownHostDAO) Delete(host *po.KnownHost) error {
+	return DB.Unscoped().Delete(host).Error
+}
@@ -20,6 +20,13 @@ func (d *RepoDAO) FindAll() ([]po.Repo, error) {
 	return repos, err
 }
 
+func (d *RepoDAO) FindByGroup(group string) ([]po.Repo, error) {
+	var repos []po.Repo
+	// Struct conditions let GORM quote the column, `group` is a reserved word
+	err := DB.Where(&po.Repo{Group: group}).Find(&repos).Error
+	return repos, err
+}
+
 func (d *RepoDAO) FindByKey(key string) (*po.Repo, error) {
 	var repo po.Repo
 	err := DB.Where("key = ?", key).First(&repo).Error
@@ -0,0 +1,38 @@
+package db
+
+import (
+	"github.com/yi-nology/git-manage-service/biz/model/po"
+)
+
+type RepoHealthDAO struct{}
+
+func NewRepoHealthDAO() *RepoHealthDAO {
+	return &RepoHealthDAO{}
+}
+
+func (d *RepoHealthDAO) Save(health *po.RepoHealth) error {
+	return DB.Save(health).Error
+}
+
+func (d *RepoHealthDAO) FindAll() ([]po.RepoHealth, error) {
+	var list []po.RepoHealth
+	err := DB.Order("repo_id asc").Find(&list).Error
+	return list, err
+}
+
+// FindByRepoID returns the health record of a repository, or an unsaved empty record
+// when it was never maintained
+func (d *RepoHealthDAO) FindByRepoID(repoID uint) (*po.RepoHealth, error) {
+	var list []po.RepoHealth
+	if err := DB.Where("repo_id = ?", repoID).Limit(1).Find(&list).Error; err != nil {
+		return nil, err
+	}
+	if len(list) == 0 {
+		return &po.RepoHealth{RepoID: repoID}, nil
+	}
+	return &list[0], nil
+}
+
+func (d *RepoHealthDAO) DeleteByRepoID(repoID uint) error {
+	return DB.Unscoped().Where("repo_id = ?", repoID).Delete(&po.RepoHealth{}).Error
+}
@@ -0,0 +1,37 @@
+package db
+
+import (
+	"github.com/yi-nology/git-manage-service/biz/model/po"
+)
+
+type SSHKeyDAO struct{}
+
+func NewSSHKeyDAO() *SSHKeyDAO {
+	return &SSHKeyDAO{}
+}
+
+func (d *SSHKeyDAO) Create(key *po.SSHKey) error {
+	return DB.Create(key).Error
+}
+
+func (d *SSHKeyDAO) FindAll() ([]po.SSHKey, error) {
+	var keys []po.SSHKey
+	err := DB.Order("id asc").Find(&keys).Error
+	return keys, err
+}
+
+func (d *SSHKeyDAO) FindByID(id uint) (*po.SSHKey, error) {
+	var key po.SSHKey
+	err := DB.First(&key, id).Error
+	return &key, err
+}
+
+func (d *SSHKeyDAO) Delete(key *po.SSHKey) error {
+	return DB.Unscoped().Delete(key).Error
+}
+
+func (d *SSHKeyDAO) Exists(name, fingerprint string) (bool, error) {
+	var count int64
+	err := DB.Model(&po.SSHKey{}).Where("name = ? OR fingerprint = ?", name, fingerprint).Count(&count).Error
+	return count > 0, err
+}
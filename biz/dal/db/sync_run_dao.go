@@ -2,6 +2,7 @@ package db
 
 import (
 	"github.com/yi-nology/git-manage-service/biz/model/po"
+	"gorm.io/gorm"
 )
 
 type SyncRunDAO struct{}
@@ -34,6 +35,57 @@ func (d *SyncRunDAO) FindByTaskKeys(taskKeys []string, limit int) ([]po.SyncRun,
 	return runs, err
 }
 
+// FindLastPushed returns the most recent successful run of the task that pushed something
+func (d *SyncRunDAO) FindLastPushed(taskKey string) (*po.SyncRun, error) {
+	var run po.SyncRun
+	err := DB.Where("task_key = ? AND status = ? AND pushed_hash <> ''", taskKey, "success").
+		Order("start_time desc").First(&run).Error
+	if err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+func (d *SyncRunDAO) FindByIDWithDetails(id uint) (*po.SyncRun, error) {
+	var run po.SyncRun
+	err := DB.Preload("Task").
+		Preload("Steps", func(tx *gorm.DB) *gorm.DB { return tx.Order("seq asc") }).
+		Preload("Commits", func(tx *gorm.DB) *gorm.DB { return tx.Order("commit_time desc") }).
+		First(&run, id).Error
+	return &run, err
+}
+
+// SaveDetails stores the structured steps and pushed commits of a run
+func (d *SyncRunDAO) SaveDetails(runID uint, steps []po.SyncRunStep, commits []po.SyncRunCommit) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		for i := range steps {
+			steps[i].RunID = runID
+		}
+		for i := range commits {
+			commits[i].RunID = runID
+		}
+		if len(steps) > 0 {
+			if err := tx.Create(&steps).Error; err != nil {
+				return err
+			}
+		}
+		if len(commits) > 0 {
+			if err := tx.CreateInBatches(&commits, 100).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
 func (d *SyncRunDAO) Delete(id uint) error {
-	return DB.Delete(&po.SyncRun{}, id).Error
+	return DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("run_id = ?", id).Delete(&po.SyncRunStep{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("run_id = ?", id).Delete(&po.SyncRunCommit{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&po.SyncRun{}, id).Error
+	})
 }
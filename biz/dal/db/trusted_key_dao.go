@@ -0,0 +1,31 @@
+package db
+
+import (
+	"github.com/yi-nology/git-manage-service/biz/model/po"
+)
+
+type TrustedKeyDAO struct{}
+
+func NewTrustedKeyDAO() *TrustedKeyDAO {
+	return &TrustedKeyDAO{}
+}
+
+func (d *TrustedKeyDAO) Create(key *po.TrustedKey) error {
+	return DB.Create(key).Error
+}
+
+func (d *TrustedKeyDAO) FindAll() ([]po.TrustedKey, error) {
+	var keys []po.TrustedKey
+	err := DB.Order("id asc").Find(&keys).Error
+	return keys, err
+}
+
+func (d *TrustedKeyDAO) FindByID(id uint) (*po.TrustedKey, error) {
+	var key po.TrustedKey
+	err := DB.First(&key, id).Error
+	return &key, err
+}
+
+func (d *TrustedKeyDAO) Delete(key *po.TrustedKey) error {
+	return DB.Unscoped().Delete(key).Error
+}
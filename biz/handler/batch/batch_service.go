@@ -0,0 +1,82 @@
+package batch
+
+import (
+	"context"
+	"errors"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/yi-nology/git-manage-service/biz/model/api"
+	"github.com/yi-nology/git-manage-service/biz/service/audit"
+	"github.com/yi-nology/git-manage-service/biz/service/batch"
+	"github.com/yi-nology/git-manage-service/pkg/response"
+)
+
+// Submit .
+// @router /api/v1/batch/submit [POST]
+func Submit(ctx context.Context, c *app.RequestContext) {
+	var req api.BatchJobReq
+	if err := c.BindAndValidate(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	job, err := batch.BatchSvc.Submit(
+		batch.Selection{RepoKeys: req.RepoKeys, Group: req.Group, All: req.All},
+		batch.Operation{
+			Type:        req.Operation,
+			Branch:      req.Branch,
+			Tag:         req.Tag,
+			Ref:         req.Ref,
+			Message:     req.Message,
+			Remote:      req.Remote,
+			Force:       req.Force,
+			AuthorName:  req.AuthorName,
+			AuthorEmail: req.AuthorEmail,
+		},
+		req.Concurrency,
+	)
+	switch {
+	case errors.Is(err, batch.ErrInvalidJob):
+		response.BadRequest(c, err.Error())
+		return
+	case errors.Is(err, batch.ErrRepoNotFound):
+		response.NotFound(c, err.Error())
+		return
+	case err != nil:
+		response.InternalServerError(c, err.Error())
+		return
+	}
+
+	repoKeys := make([]string, len(job.Results))
+	for i, r := range job.Results {
+		repoKeys[i] = r.RepoKey
+	}
+	audit.AuditSvc.Log(c, "BATCH_JOB", "batch:"+job.ID, map[string]interface{}{
+		"operation": job.Operation,
+		"repos":     repoKeys,
+	})
+
+	response.Accepted(c, "batch job started", job)
+}
+
+// GetJob .
+// @router /api/v1/batch/job [GET]
+func GetJob(ctx context.Context, c *app.RequestContext) {
+	id := c.Query("id")
+	if id == "" {
+		response.BadRequest(c, "id is required")
+		return
+	}
+	job, ok := batch.BatchSvc.GetJob(id)
+	if !ok {
+		response.NotFound(c, "job not found")
+		return
+	}
+	response.Success(c, job)
+}
+
+// ListJobs .
+// @router /api/v1/batch/jobs [GET]
+func ListJobs(ctx context.Context, c *app.RequestContext) {
+	response.Success(c, batch.BatchSvc.ListJobs())
+}
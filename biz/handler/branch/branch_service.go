@@ -4,6 +4,7 @@ package branch
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strconv"
 	"strings"
@@ -34,7 +35,7 @@ func List(ctx context.Context, c *app.RequestContext) {
 		return
 	}
 
-	gitSvc := git.NewGitService()
+	gitSvc := git.NewGitService().WithContext(ctx)
 	branches, err := gitSvc.ListBranchesWithInfo(repo.Path)
 	if err != nil {
 		response.InternalServerError(c, err.Error())
@@ -110,7 +111,7 @@ func Create(ctx context.Context, c *app.RequestContext) {
 		return
 	}
 
-	gitSvc := git.NewGitService()
+	gitSvc := git.NewGitService().WithContext(ctx)
 	if err := gitSvc.CreateBranch(repo.Path, req.Name, req.BaseRef); err != nil {
 		response.InternalServerError(c, err.Error())
 		return
@@ -142,7 +143,7 @@ func Delete(ctx context.Context, c *app.RequestContext) {
 		return
 	}
 
-	gitSvc := git.NewGitService()
+	gitSvc := git.NewGitService().WithContext(ctx)
 	if err := gitSvc.DeleteBranch(repo.Path, req.Name, req.Force); err != nil {
 		response.InternalServerError(c, err.Error())
 		return
@@ -175,7 +176,7 @@ func Update(ctx context.Context, c *app.RequestContext) {
 		return
 	}
 
-	gitSvc := git.NewGitService()
+	gitSvc := git.NewGitService().WithContext(ctx)
 	currentName := req.Name
 
 	// Rename
@@ -220,7 +221,7 @@ func Checkout(ctx context.Context, c *app.RequestContext) {
 		return
 	}
 
-	gitSvc := git.NewGitService()
+	gitSvc := git.NewGitService().WithContext(ctx)
 	if err := gitSvc.CheckoutBranch(repo.Path, req.Name); err != nil {
 		response.BadRequest(c, "Checkout failed: "+err.Error())
 		return
@@ -251,7 +252,7 @@ func Push(ctx context.Context, c *app.RequestContext) {
 		return
 	}
 
-	gitSvc := git.NewGitService()
+	gitSvc := git.NewGitService().WithContext(ctx)
 
 	var errors []string
 	for _, remote := range req.Remotes {
@@ -290,7 +291,7 @@ func Pull(ctx context.Context, c *app.RequestContext) {
 		return
 	}
 
-	gitSvc := git.NewGitService()
+	gitSvc := git.NewGitService().WithContext(ctx)
 	branches, _ := gitSvc.ListBranchesWithInfo(repo.Path)
 
 	var isCurrent bool
@@ -367,7 +368,7 @@ func Compare(ctx context.Context, c *app.RequestContext) {
 		return
 	}
 
-	gitSvc := git.NewGitService()
+	gitSvc := git.NewGitService().WithContext(ctx)
 
 	stat, err := gitSvc.GetDiffStat(repo.Path, base, target)
 	if err != nil {
@@ -401,7 +402,7 @@ func GetDiff(ctx context.Context, c *app.RequestContext) {
 		return
 	}
 
-	gitSvc := git.NewGitService()
+	gitSvc := git.NewGitService().WithContext(ctx)
 	content, err := gitSvc.GetRawDiff(repo.Path, base, target, file)
 	if err != nil {
 		response.InternalServerError(c, err.Error())
@@ -424,7 +425,7 @@ func MergeCheck(ctx context.Context, c *app.RequestContext) {
 		return
 	}
 
-	gitSvc := git.NewGitService()
+	gitSvc := git.NewGitService().WithContext(ctx)
 	result, err := gitSvc.MergeDryRun(repo.Path, base, target)
 	if err != nil {
 		response.InternalServerError(c, err.Error())
@@ -452,47 +453,231 @@ func Merge(ctx context.Context, c *app.RequestContext) {
 		return
 	}
 
-	gitSvc := git.NewGitService()
+	opts := git.MergeOptions{
+		Source:      req.Source,
+		Target:      req.Target,
+		Strategy:    req.Strategy,
+		Message:     req.Message,
+		AuthorName:  req.AuthorName,
+		AuthorEmail: req.AuthorEmail,
+	}
+	if err := opts.Validate(); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	gitSvc := git.NewGitService().WithContext(ctx)
+
+	// Fast-forwards cannot conflict and rebase conflicts depend on the individual
+	// commits, so the three-way preview only applies to the merging strategies.
+	if req.Strategy != git.MergeStrategyFFOnly && req.Strategy != git.MergeStrategyRebase {
+		check, err := gitSvc.MergeDryRun(repo.Path, req.Source, req.Target)
+		if err != nil {
+			response.InternalServerError(c, "Pre-merge check failed: "+err.Error())
+			return
+		}
+		if !check.Success {
+			mergeConflictResponse(c, repo.Key, req.MergeReq, check.ConflictDetails, "")
+			return
+		}
+	}
 
-	check, err := gitSvc.MergeDryRun(repo.Path, req.Source, req.Target)
+	outcome, err := gitSvc.MergeWithOptions(repo.Path, opts)
+	if errors.Is(err, git.ErrNotFastForward) {
+		response.Conflict(c, err.Error())
+		return
+	}
 	if err != nil {
-		response.InternalServerError(c, "Pre-merge check failed: "+err.Error())
+		response.InternalServerError(c, "Merge execution failed: "+err.Error())
+		return
+	}
+	if !outcome.Success {
+		mergeConflictResponse(c, repo.Key, req.MergeReq, outcome.Conflicts, outcome.FailedCommit)
 		return
 	}
-	if !check.Success {
-		mergeID := uuid.New().String()
-		reportURL := fmt.Sprintf("/merge_report.html?repo_key=%s&source=%s&target=%s&merge_id=%s", repo.Key, req.Source, req.Target, mergeID)
 
-		audit.AuditSvc.Log(c, "MERGE_CONFLICT", "repo:"+repo.Key, map[string]interface{}{
-			"source":    req.Source,
-			"target":    req.Target,
-			"conflicts": check.Conflicts,
-			"merge_id":  mergeID,
-		})
+	audit.AuditSvc.Log(c, "MERGE_SUCCESS", "repo:"+repo.Key, map[string]string{
+		"source":   req.Source,
+		"target":   req.Target,
+		"strategy": outcome.Strategy,
+		"head":     outcome.Head,
+	})
+
+	response.Success(c, map[string]interface{}{
+		"status":        "merged",
+		"strategy":      outcome.Strategy,
+		"head":          outcome.Head,
+		"previous_head": outcome.PreviousHead,
+		"up_to_date":    outcome.UpToDate,
+	})
+}
 
+// mergeConflictResponse records the conflict and answers with code 409 and the conflict list
+func mergeConflictResponse(c *app.RequestContext, repoKey string, req api.MergeReq, conflicts []git.MergeConflict, failedCommit string) {
+	mergeID := uuid.New().String()
+	reportURL := fmt.Sprintf("/merge_report.html?repo_key=%s&source=%s&target=%s&merge_id=%s", repoKey, req.Source, req.Target, mergeID)
+
+	paths := make([]string, 0, len(conflicts))
+	for _, cf := range conflicts {
+		paths = append(paths, cf.Path)
+	}
+
+	audit.AuditSvc.Log(c, "MERGE_CONFLICT", "repo:"+repoKey, map[string]interface{}{
+		"source":        req.Source,
+		"target":        req.Target,
+		"strategy":      req.Strategy,
+		"conflicts":     paths,
+		"details":       conflicts,
+		"failed_commit": failedCommit,
+		"merge_id":      mergeID,
+	})
+
+	c.JSON(200, response.Response{
+		Code: 409,
+		Msg:  "Merge conflict detected",
+		Data: map[string]interface{}{
+			"conflicts":        paths,
+			"conflict_details": conflicts,
+			"failed_commit":    failedCommit,
+			"report_url":       reportURL,
+			"merge_id":         mergeID,
+		},
+	})
+}
+
+// CherryPick .
+// @router /api/v1/branch/cherry-pick [POST]
+func CherryPick(ctx context.Context, c *app.RequestContext) {
+	var req api.CherryPickReq
+	if err := c.BindAndValidate(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+	if len(req.Commits) == 0 || len(req.Targets) == 0 {
+		response.BadRequest(c, "commits and targets are required")
+		return
+	}
+
+	repo, err := db.NewRepoDAO().FindByKey(req.RepoKey)
+	if err != nil {
+		response.NotFound(c, "repo not found")
+		return
+	}
+
+	results, err := git.NewGitService().WithContext(ctx).CherryPick(repo.Path, git.CherryPickOptions{
+		Commits:     req.Commits,
+		Targets:     req.Targets,
+		Push:        req.Push,
+		Remote:      req.Remote,
+		Auth:        repo.AuthForRemote,
+		AuthorName:  req.AuthorName,
+		AuthorEmail: req.AuthorEmail,
+	})
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	statuses := make(map[string]string, len(results))
+	for _, r := range results {
+		statuses[r.Branch] = r.Status
+	}
+	audit.AuditSvc.Log(c, "CHERRY_PICK", "repo:"+repo.Key, map[string]interface{}{
+		"commits": req.Commits,
+		"targets": statuses,
+		"push":    req.Push,
+	})
+
+	response.Success(c, results)
+}
+
+// Revert .
+// @router /api/v1/branch/revert [POST]
+func Revert(ctx context.Context, c *app.RequestContext) {
+	var req api.RevertReq
+	if err := c.BindAndValidate(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+	if req.Commit == "" || req.Branch == "" {
+		response.BadRequest(c, "commit and branch are required")
+		return
+	}
+
+	repo, err := db.NewRepoDAO().FindByKey(req.RepoKey)
+	if err != nil {
+		response.NotFound(c, "repo not found")
+		return
+	}
+
+	gitSvc := git.NewGitService().WithContext(ctx)
+	outcome, err := gitSvc.Revert(repo.Path, git.RevertOptions{
+		Commit:      req.Commit,
+		Branch:      req.Branch,
+		Mainline:    req.Mainline,
+		Message:     req.Message,
+		AuthorName:  req.AuthorName,
+		AuthorEmail: req.AuthorEmail,
+	})
+	if errors.Is(err, git.ErrInvalidRevert) {
+		response.BadRequest(c, err.Error())
+		return
+	}
+	if err != nil {
+		response.InternalServerError(c, "Revert failed: "+err.Error())
+		return
+	}
+	if !outcome.Success {
+		paths := make([]string, 0, len(outcome.Conflicts))
+		for _, cf := range outcome.Conflicts {
+			paths = append(paths, cf.Path)
+		}
+		audit.AuditSvc.Log(c, "REVERT_CONFLICT", "repo:"+repo.Key, map[string]interface{}{
+			"commit":    outcome.Commit,
+			"branch":    req.Branch,
+			"conflicts": paths,
+		})
 		c.JSON(200, response.Response{
 			Code: 409,
-			Msg:  "Merge conflict detected",
+			Msg:  "Revert conflict detected",
 			Data: map[string]interface{}{
-				"conflicts":  check.Conflicts,
-				"report_url": reportURL,
-				"merge_id":   mergeID,
+				"conflicts":        paths,
+				"conflict_details": outcome.Conflicts,
 			},
 		})
 		return
 	}
 
-	if err := gitSvc.Merge(repo.Path, req.Source, req.Target, req.Message); err != nil {
-		response.InternalServerError(c, "Merge execution failed: "+err.Error())
-		return
+	result := map[string]interface{}{
+		"status":        "reverted",
+		"commit":        outcome.Commit,
+		"head":          outcome.Head,
+		"previous_head": outcome.PreviousHead,
+		"up_to_date":    outcome.UpToDate,
+	}
+	if req.Push && !outcome.UpToDate {
+		remote := req.Remote
+		if remote == "" {
+			remote = "origin"
+		}
+		auth := repo.AuthForRemote(remote)
+		if err := gitSvc.PushBranchWithAuth(repo.Path, remote, req.Branch, auth.Type, auth.Key, auth.Secret); err != nil {
+			result["warning"] = "push_failed"
+			result["push_error"] = err.Error()
+		} else {
+			result["pushed"] = true
+		}
 	}
 
-	audit.AuditSvc.Log(c, "MERGE_SUCCESS", "repo:"+repo.Key, map[string]string{
-		"source": req.Source,
-		"target": req.Target,
+	audit.AuditSvc.Log(c, "REVERT", "repo:"+repo.Key, map[string]interface{}{
+		"commit":   outcome.Commit,
+		"branch":   req.Branch,
+		"mainline": req.Mainline,
+		"head":     outcome.Head,
+		"push":     req.Push,
 	})
 
-	response.Success(c, map[string]string{"status": "merged"})
+	response.Success(c, result)
 }
 
 // GetPatch .
@@ -508,7 +693,7 @@ func GetPatch(ctx context.Context, c *app.RequestContext) {
 		return
 	}
 
-	gitSvc := git.NewGitService()
+	gitSvc := git.NewGitService().WithContext(ctx)
 	patch, err := gitSvc.GetPatch(repo.Path, base, target)
 	if err != nil {
 		response.InternalServerError(c, err.Error())
@@ -519,3 +704,89 @@ func GetPatch(ctx context.Context, c *app.RequestContext) {
 	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s-%s-%s.patch", repo.Name, base, time.Now().Format("20060102")))
 	c.Write([]byte(patch))
 }
+
+// Analyze .
+// @router /api/v1/branch/analyze [GET]
+func Analyze(ctx context.Context, c *app.RequestContext) {
+	repoKey := c.Query("repo_key")
+	if repoKey == "" {
+		response.BadRequest(c, "repo_key is required")
+		return
+	}
+
+	repo, err := db.NewRepoDAO().FindByKey(repoKey)
+	if err != nil {
+		response.NotFound(c, "repo not found")
+		return
+	}
+
+	opts := git.BranchAnalysisOptions{Base: c.Query("base")}
+	if days := c.Query("stale_days"); days != "" {
+		if opts.StaleDays, err = strconv.Atoi(days); err != nil {
+			response.BadRequest(c, "invalid stale_days")
+			return
+		}
+	}
+
+	branches, err := git.NewGitService().WithContext(ctx).AnalyzeBranches(repo.Path, opts)
+	if errors.Is(err, git.ErrInvalidCleanup) || errors.Is(err, git.ErrRefNotFound) {
+		response.BadRequest(c, err.Error())
+		return
+	}
+	if err != nil {
+		response.InternalServerError(c, err.Error())
+		return
+	}
+
+	response.Success(c, branches)
+}
+
+// Cleanup .
+// @router /api/v1/branch/cleanup [POST]
+func Cleanup(ctx context.Context, c *app.RequestContext) {
+	var req api.BranchCleanupReq
+	if err := c.BindAndValidate(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	repo, err := db.NewRepoDAO().FindByKey(req.RepoKey)
+	if err != nil {
+		response.NotFound(c, "repo not found")
+		return
+	}
+
+	results, err := git.NewGitService().WithContext(ctx).CleanupBranches(repo.Path, git.BranchCleanupOptions{
+		Branches:     req.Branches,
+		Base:         req.Base,
+		Archive:      req.Archive,
+		Force:        req.Force,
+		TagPrefix:    req.TagPrefix,
+		DeleteRemote: req.DeleteRemote,
+		DryRun:       req.DryRun,
+		Auth:         repo.AuthForRemote,
+	})
+	if errors.Is(err, git.ErrInvalidCleanup) {
+		response.BadRequest(c, err.Error())
+		return
+	}
+	if err != nil {
+		response.InternalServerError(c, err.Error())
+		return
+	}
+
+	if !req.DryRun {
+		audit.AuditSvc.Log(c, "BRANCH_CLEANUP", "repo:"+repo.Key, map[string]interface{}{
+			"base":          req.Base,
+			"archive":       req.Archive,
+			"force":         req.Force,
+			"delete_remote": req.DeleteRemote,
+			"results":       results,
+		})
+	}
+
+	response.Success(c, map[string]interface{}{
+		"dry_run": req.DryRun,
+		"results": results,
+	})
+}
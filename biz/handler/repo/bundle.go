@@ -0,0 +1,179 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/yi-nology/git-manage-service/biz/dal/db"
+	"github.com/yi-nology/git-manage-service/biz/service/audit"
+	"github.com/yi-nology/git-manage-service/biz/service/git"
+	"github.com/yi-nology/git-manage-service/pkg/response"
+)
+
+// tempFile removes the file once the response body has been sent
+type tempFile struct {
+	*os.File
+}
+
+func (f tempFile) Close() error {
+	err := f.File.Close()
+	os.Remove(f.Name())
+	return err
+}
+
+func splitList(v string) []string {
+	var list []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// bundleDropPath allows server-side bundles only from the source bundle directories of
+// the sync tasks that import into the repository. Symlinks are resolved before the check.
+func bundleDropPath(repoKey, file string) (string, error) {
+	resolved, err := filepath.EvalSymlinks(filepath.Clean(file))
+	if err != nil {
+		return "", errors.New("path is not in a bundle drop directory of this repository")
+	}
+	tasks, err := db.NewSyncTaskDAO().FindByRepoKey(repoKey)
+	if err != nil {
+		return "", err
+	}
+	for _, task := range tasks {
+		if task.SourceRepoKey != repoKey || task.SourceBundleDir == "" {
+			continue
+		}
+		dir, err := filepath.EvalSymlinks(filepath.Clean(task.SourceBundleDir))
+		if err != nil {
+			continue
+		}
+		if rel, err := filepath.Rel(dir, resolved); err == nil && rel != "." && rel != ".." &&
+			!strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return resolved, nil
+		}
+	}
+	return "", errors.New("path is not in a bundle drop directory of this repository")
+}
+
+// ExportBundle .
+// @router /api/v1/repo/bundle/export [GET]
+func ExportBundle(ctx context.Context, c *app.RequestContext) {
+	repo, ok := findRepo(c)
+	if !ok {
+		return
+	}
+	gitSvc := git.NewGitService().WithContext(ctx)
+
+	heads, err := gitSvc.ResolveBundleRefs(repo.Path, splitList(c.Query("refs")))
+	if err != nil {
+		fileBrowserError(c, err)
+		return
+	}
+	since := splitList(c.Query("since"))
+
+	tmp, err := os.CreateTemp("", "export-*.bundle")
+	if err != nil {
+		response.InternalServerError(c, err.Error())
+		return
+	}
+	tmp.Close()
+	if err := gitSvc.CreateBundle(repo.Path, tmp.Name(), heads, since); err != nil {
+		os.Remove(tmp.Name())
+		if errors.Is(err, git.ErrEmptyBundle) || errors.Is(err, git.ErrRefNotFound) {
+			response.BadRequest(c, err.Error())
+			return
+		}
+		response.InternalServerError(c, "Create bundle failed: "+err.Error())
+		return
+	}
+	f, err := os.Open(tmp.Name())
+	if err != nil {
+		os.Remove(tmp.Name())
+		response.InternalServerError(c, err.Error())
+		return
+	}
+	info, _ := f.Stat()
+
+	audit.AuditSvc.Log(c, "BUNDLE_EXPORT", "repo:"+repo.Key, map[string]interface{}{
+		"heads": heads,
+		"since": since,
+	})
+
+	c.Header("Content-Type", "application/octet-stream")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q",
+		fmt.Sprintf("%s-%s.bundle", repo.Name, time.Now().Format("20060102-150405"))))
+	c.SetBodyStream(tempFile{f}, int(info.Size()))
+}
+
+// ImportBundle .
+// @router /api/v1/repo/bundle/import [POST]
+func ImportBundle(ctx context.Context, c *app.RequestContext) {
+	repoKey := string(c.FormValue("repo_key"))
+	repo, err := db.NewRepoDAO().FindByKey(repoKey)
+	if err != nil {
+		response.NotFound(c, "repo not found")
+		return
+	}
+	force := string(c.FormValue("force")) == "true"
+
+	// Either an uploaded file or a bundle in one of the repository's drop directories
+	file := string(c.FormValue("path"))
+	if fh, err := c.FormFile("bundle"); err == nil {
+		tmp, err := os.CreateTemp("", "import-*.bundle")
+		if err != nil {
+			response.InternalServerError(c, err.Error())
+			return
+		}
+		tmp.Close()
+		defer os.Remove(tmp.Name())
+		if err := c.SaveUploadedFile(fh, tmp.Name()); err != nil {
+			response.InternalServerError(c, err.Error())
+			return
+		}
+		file = tmp.Name()
+	} else if file == "" {
+		response.BadRequest(c, "bundle file or path is required")
+		return
+	} else if !filepath.IsAbs(file) {
+		response.BadRequest(c, "path must be absolute")
+		return
+	} else if file, err = bundleDropPath(repo.Key, file); err != nil {
+		response.Forbidden(c, err.Error())
+		return
+	}
+
+	gitSvc := git.NewGitService().WithContext(ctx)
+	heads, err := gitSvc.ListBundleHeads(file)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+	results, err := gitSvc.ImportBundle(repo.Path, file, force)
+	if errors.Is(err, git.ErrBundlePrerequisites) {
+		response.Conflict(c, err.Error())
+		return
+	}
+	if err != nil {
+		response.InternalServerError(c, "Import bundle failed: "+err.Error())
+		return
+	}
+
+	audit.AuditSvc.Log(c, "BUNDLE_IMPORT", "repo:"+repo.Key, map[string]interface{}{
+		"force":   force,
+		"results": results,
+	})
+
+	response.Success(c, map[string]interface{}{
+		"heads":   heads,
+		"results": results,
+	})
+}
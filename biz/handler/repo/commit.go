@@ -0,0 +1,59 @@
+package repo
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/yi-nology/git-manage-service/biz/dal/db"
+	"github.com/yi-nology/git-manage-service/biz/service/git"
+	"github.com/yi-nology/git-manage-service/biz/service/signature"
+	"github.com/yi-nology/git-manage-service/pkg/response"
+)
+
+// GetCommitDetail .
+// @router /api/v1/repo/commit [GET]
+func GetCommitDetail(ctx context.Context, c *app.RequestContext) {
+	repo, ok := findRepo(c)
+	if !ok {
+		return
+	}
+	hash := c.Query("hash")
+	if hash == "" {
+		response.BadRequest(c, "hash is required")
+		return
+	}
+	parent := 0
+	if p := c.Query("parent"); p != "" {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			response.BadRequest(c, "invalid parent")
+			return
+		}
+		parent = n
+	}
+	combined := c.Query("combined") == "true"
+
+	gitSvc := git.NewGitService().WithContext(ctx)
+	detail, err := gitSvc.GetCommitDetail(repo.Path, hash, parent, combined)
+	if err != nil {
+		fileBrowserError(c, err)
+		return
+	}
+
+	keys, err := db.NewTrustedKeyDAO().FindAll()
+	if err != nil {
+		response.InternalServerError(c, err.Error())
+		return
+	}
+	commit, err := gitSvc.GetCommit(repo.Path, detail.Hash)
+	if err != nil {
+		response.InternalServerError(c, err.Error())
+		return
+	}
+
+	response.Success(c, struct {
+		*git.CommitDetail
+		Signature signature.SignatureStatus `json:"signature"`
+	}{detail, signature.NewKeyring(keys).Status(commit)})
+}
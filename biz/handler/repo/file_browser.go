@@ -0,0 +1,206 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/yi-nology/git-manage-service/biz/dal/db"
+	"github.com/yi-nology/git-manage-service/biz/model/po"
+	"github.com/yi-nology/git-manage-service/biz/service/git"
+	"github.com/yi-nology/git-manage-service/pkg/response"
+)
+
+// findRepo loads the repo named by the repo_key query parameter, answering the request on failure
+func findRepo(c *app.RequestContext) (*po.Repo, bool) {
+	repoKey := c.Query("repo_key")
+	if repoKey == "" {
+		response.BadRequest(c, "repo_key is required")
+		return nil, false
+	}
+	repo, err := db.NewRepoDAO().FindByKey(repoKey)
+	if err != nil {
+		response.NotFound(c, "repo not found")
+		return nil, false
+	}
+	return repo, true
+}
+
+func fileBrowserError(c *app.RequestContext, err error) {
+	if errors.Is(err, git.ErrRefNotFound) || errors.Is(err, git.ErrPathNotFound) {
+		response.NotFound(c, err.Error())
+		return
+	}
+	if errors.Is(err, git.ErrNotAFile) || errors.Is(err, git.ErrInvalidPath) || errors.Is(err, git.ErrInvalidLineRange) ||
+		errors.Is(err, git.ErrInvalidParent) {
+		response.BadRequest(c, err.Error())
+		return
+	}
+	response.InternalServerError(c, err.Error())
+}
+
+// GetTree .
+// @router /api/v1/repo/tree [GET]
+func GetTree(ctx context.Context, c *app.RequestContext) {
+	repo, ok := findRepo(c)
+	if !ok {
+		return
+	}
+	withLastCommit := c.Query("last_commit") != "false"
+
+	entries, err := git.NewGitService().WithContext(ctx).ListTree(repo.Path, c.Query("ref"), c.Query("path"), withLastCommit)
+	if err != nil {
+		fileBrowserError(c, err)
+		return
+	}
+	response.Success(c, entries)
+}
+
+// GetBlob .
+// @router /api/v1/repo/blob [GET]
+func GetBlob(ctx context.Context, c *app.RequestContext) {
+	repo, ok := findRepo(c)
+	if !ok {
+		return
+	}
+	maxSize, _ := strconv.ParseInt(c.Query("max_size"), 10, 64)
+
+	blob, err := git.NewGitService().WithContext(ctx).GetBlob(repo.Path, c.Query("ref"), c.Query("path"), maxSize)
+	if err != nil {
+		fileBrowserError(c, err)
+		return
+	}
+	response.Success(c, blob)
+}
+
+// GetRawFile .
+// @router /api/v1/repo/raw [GET]
+func GetRawFile(ctx context.Context, c *app.RequestContext) {
+	repo, ok := findRepo(c)
+	if !ok {
+		return
+	}
+	file := c.Query("path")
+
+	reader, size, err := git.NewGitService().WithContext(ctx).OpenBlob(repo.Path, c.Query("ref"), file)
+	if err != nil {
+		fileBrowserError(c, err)
+		return
+	}
+
+	contentType := mime.TypeByExtension(path.Ext(file))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	c.Header("Content-Type", contentType)
+	// Always download: repository content must not be rendered in the service's origin
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", path.Base(file)))
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.SetBodyStream(reader, int(size))
+}
+
+// GetFileHistory .
+// @router /api/v1/repo/file-history [GET]
+func GetFileHistory(ctx context.Context, c *app.RequestContext) {
+	repo, ok := findRepo(c)
+	if !ok {
+		return
+	}
+
+	page, _ := strconv.Atoi(c.Query("page"))
+	if page < 1 {
+		page = 1
+	}
+	pageSize, _ := strconv.Atoi(c.Query("page_size"))
+	if pageSize < 1 || pageSize > 500 {
+		pageSize = 50
+	}
+
+	commits, err := git.NewGitService().WithContext(ctx).FileHistory(repo.Path, c.Query("ref"), c.Query("path"), (page-1)*pageSize, pageSize)
+	if err != nil {
+		fileBrowserError(c, err)
+		return
+	}
+	response.Success(c, map[string]interface{}{
+		"list":      commits,
+		"page":      page,
+		"page_size": pageSize,
+	})
+}
+
+// GetBlame .
+// @router /api/v1/repo/blame [GET]
+func GetBlame(ctx context.Context, c *app.RequestContext) {
+	repo, ok := findRepo(c)
+	if !ok {
+		return
+	}
+
+	opts := git.BlameOptions{UseIgnoreFile: c.Query("use_ignore_file") == "true"}
+	if revs := c.Query("ignore_revs"); revs != "" {
+		for _, rev := range strings.Split(revs, ",") {
+			if rev = strings.TrimSpace(rev); rev != "" {
+				opts.IgnoreRevs = append(opts.IgnoreRevs, rev)
+			}
+		}
+	}
+	var err error
+	if v := c.Query("start_line"); v != "" {
+		if opts.StartLine, err = strconv.Atoi(v); err != nil {
+			response.BadRequest(c, "invalid start_line")
+			return
+		}
+	}
+	if v := c.Query("end_line"); v != "" {
+		if opts.EndLine, err = strconv.Atoi(v); err != nil {
+			response.BadRequest(c, "invalid end_line")
+			return
+		}
+	}
+
+	result, err := git.NewGitService().WithContext(ctx).Blame(repo.Path, c.Query("ref"), c.Query("path"), opts)
+	if err != nil {
+		fileBrowserError(c, err)
+		return
+	}
+	response.Success(c, result)
+}
+
+// GetArchive .
+// @router /api/v1/repo/archive [GET]
+func GetArchive(ctx context.Context, c *app.RequestContext) {
+	repo, ok := findRepo(c)
+	if !ok {
+		return
+	}
+	ref := c.Query("ref")
+	format := c.DefaultQuery("format", git.ArchiveFormatTarGz)
+	if !git.IsValidArchiveFormat(format) {
+		response.BadRequest(c, "format must be tar.gz or zip")
+		return
+	}
+	name := git.ArchiveName(repo.Name, ref)
+	prefix := name
+	if c.QueryArgs().Has("prefix") {
+		prefix = c.Query("prefix")
+	}
+
+	stream, err := git.NewGitService().WithContext(ctx).Archive(ctx, repo.Path, ref, format, prefix, c.Query("path"))
+	if err != nil {
+		fileBrowserError(c, err)
+		return
+	}
+
+	contentType := "application/gzip"
+	if format == git.ArchiveFormatZip {
+		contentType = "application/zip"
+	}
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name+"."+format))
+	c.SetBodyStream(stream, -1)
+}
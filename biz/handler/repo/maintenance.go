@@ -0,0 +1,110 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/google/uuid"
+	"github.com/yi-nology/git-manage-service/biz/dal/db"
+	"github.com/yi-nology/git-manage-service/biz/model/api"
+	"github.com/yi-nology/git-manage-service/biz/model/po"
+	"github.com/yi-nology/git-manage-service/biz/service/audit"
+	"github.com/yi-nology/git-manage-service/biz/service/git"
+	"github.com/yi-nology/git-manage-service/biz/service/maintenance"
+	"github.com/yi-nology/git-manage-service/pkg/response"
+)
+
+// ListHealth .
+// @router /api/v1/repo/health [GET]
+func ListHealth(ctx context.Context, c *app.RequestContext) {
+	repos, err := db.NewRepoDAO().FindAll()
+	if err != nil {
+		response.InternalServerError(c, err.Error())
+		return
+	}
+	records, err := db.NewRepoHealthDAO().FindAll()
+	if err != nil {
+		response.InternalServerError(c, err.Error())
+		return
+	}
+	byRepo := make(map[uint]po.RepoHealth, len(records))
+	for _, h := range records {
+		byRepo[h.RepoID] = h
+	}
+
+	dtos := make([]api.RepoHealthDTO, 0, len(repos))
+	for _, r := range repos {
+		dtos = append(dtos, api.NewRepoHealthDTO(r, byRepo[r.ID], maintenance.MaintenanceSvc.Running(r.ID)))
+	}
+	response.Success(c, dtos)
+}
+
+// GetMaintenance .
+// @router /api/v1/repo/maintenance [GET]
+func GetMaintenance(ctx context.Context, c *app.RequestContext) {
+	repo, ok := findRepo(c)
+	if !ok {
+		return
+	}
+
+	health, err := db.NewRepoHealthDAO().FindByRepoID(repo.ID)
+	if err != nil {
+		response.InternalServerError(c, err.Error())
+		return
+	}
+	objects, err := git.NewGitService().WithContext(ctx).CountObjects(repo.Path)
+	if err != nil {
+		response.InternalServerError(c, err.Error())
+		return
+	}
+	response.Success(c, map[string]interface{}{
+		"health":  api.NewRepoHealthDTO(*repo, *health, maintenance.MaintenanceSvc.Running(repo.ID)),
+		"objects": objects,
+	})
+}
+
+// RunMaintenance .
+// @router /api/v1/repo/maintenance/run [POST]
+func RunMaintenance(ctx context.Context, c *app.RequestContext) {
+	var req api.RunMaintenanceReq
+	if err := c.BindAndValidate(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+	repo, err := db.NewRepoDAO().FindByKey(req.RepoKey)
+	if err != nil {
+		response.NotFound(c, "repo not found")
+		return
+	}
+	tasks, err := maintenance.Tasks(req.Tasks)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+	if maintenance.MaintenanceSvc.Running(repo.ID) {
+		response.Conflict(c, maintenance.ErrRunning.Error())
+		return
+	}
+
+	// gc and fsck can outlast the request, progress is polled via /api/v1/repo/task
+	taskID := uuid.New().String()
+	git.GlobalTaskManager.AddTask(taskID)
+	go func() {
+		logf := func(format string, args ...interface{}) {
+			git.GlobalTaskManager.AppendLog(taskID, fmt.Sprintf(format, args...))
+		}
+		health, err := maintenance.MaintenanceSvc.Run(*repo, tasks, logf)
+		switch {
+		case err != nil:
+			git.GlobalTaskManager.UpdateStatus(taskID, "failed", err.Error())
+		case health.Status != po.RepoHealthOK:
+			git.GlobalTaskManager.UpdateStatus(taskID, "failed", "repository health: "+health.Status)
+		default:
+			git.GlobalTaskManager.UpdateStatus(taskID, "success", "")
+		}
+	}()
+	audit.AuditSvc.Log(c, "MAINTENANCE", "repo:"+repo.Key, map[string]interface{}{"tasks": tasks, "task_id": taskID})
+
+	response.Success(c, map[string]string{"task_id": taskID})
+}
@@ -0,0 +1,57 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/yi-nology/git-manage-service/biz/service/git"
+	"github.com/yi-nology/git-manage-service/pkg/response"
+)
+
+// GetRemoteRefs .
+// @router /api/v1/repo/remote-refs [GET]
+func GetRemoteRefs(ctx context.Context, c *app.RequestContext) {
+	repo, ok := findRepo(c)
+	if !ok {
+		return
+	}
+	remote := c.DefaultQuery("remote", "origin")
+
+	gitSvc := git.NewGitService().WithContext(ctx)
+	url, err := gitSvc.GetRemoteURL(repo.Path, remote)
+	if err != nil {
+		response.NotFound(c, "remote not found")
+		return
+	}
+
+	auth := repo.AuthForRemote(remote)
+	refs, err := gitSvc.LsRemote(url, auth.Type, auth.Key, auth.Secret)
+	if err != nil {
+		response.Success(c, map[string]string{"status": "failed", "error": err.Error(), "reason": git.RemoteErrorReason(err)})
+		return
+	}
+	compared, err := gitSvc.CompareRemoteRefs(repo.Path, remote, refs)
+	if err != nil {
+		response.InternalServerError(c, err.Error())
+		return
+	}
+
+	summary := make(map[string]int)
+	for _, r := range compared {
+		summary[r.Status]++
+	}
+	var head string
+	for _, r := range refs {
+		if r.Name == "HEAD" {
+			head = r.Target
+		}
+	}
+	response.Success(c, map[string]interface{}{
+		"status":  "success",
+		"remote":  remote,
+		"url":     url,
+		"head":    head,
+		"refs":    compared,
+		"summary": summary,
+	})
+}
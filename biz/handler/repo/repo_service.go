@@ -21,7 +21,13 @@ import (
 // List .
 // @router /api/v1/repo/list [GET]
 func List(ctx context.Context, c *app.RequestContext) {
-	repos, err := db.NewRepoDAO().FindAll()
+	var repos []po.Repo
+	var err error
+	if group := c.Query("group"); group != "" {
+		repos, err = db.NewRepoDAO().FindByGroup(group)
+	} else {
+		repos, err = db.NewRepoDAO().FindAll()
+	}
 	if err != nil {
 		response.InternalServerError(c, err.Error())
 		return
@@ -98,6 +104,7 @@ func Create(ctx context.Context, c *app.RequestContext) {
 		Key:          uuid.New().String(),
 		Name:         req.Name,
 		Path:         req.Path,
+		Group:        req.Group,
 		RemoteURL:    req.RemoteURL,
 		AuthType:     req.AuthType,
 		AuthKey:      req.AuthKey,
@@ -146,7 +153,7 @@ func Update(ctx context.Context, c *app.RequestContext) {
 
 	// Validate path if changed
 	if req.Path != repo.Path {
-		gitSvc := git.NewGitService()
+		gitSvc := git.NewGitService().WithContext(ctx)
 		if !gitSvc.IsGitRepo(req.Path) {
 			response.BadRequest(c, "path is not a valid git repository")
 			return
@@ -155,7 +162,7 @@ func Update(ctx context.Context, c *app.RequestContext) {
 
 	// Sync Remotes if provided
 	if len(req.Remotes) > 0 {
-		gitSvc := git.NewGitService()
+		gitSvc := git.NewGitService().WithContext(ctx)
 		existingConfig, err := gitSvc.GetRepoConfig(req.Path)
 		if err == nil {
 			for _, existing := range existingConfig.Remotes {
@@ -185,6 +192,7 @@ func Update(ctx context.Context, c *app.RequestContext) {
 
 	repo.Name = req.Name
 	repo.Path = req.Path
+	repo.Group = req.Group
 	repo.RemoteURL = req.RemoteURL
 	repo.AuthType = req.AuthType
 	repo.AuthKey = req.AuthKey
@@ -232,6 +240,7 @@ func Delete(ctx context.Context, c *app.RequestContext) {
 		response.InternalServerError(c, err.Error())
 		return
 	}
+	db.NewRepoHealthDAO().DeleteByRepoID(repo.ID)
 	audit.AuditSvc.Log(c, "DELETE", "repo:"+repo.Key, nil)
 	response.Success(c, map[string]string{"message": "deleted"})
 }
@@ -245,7 +254,7 @@ func Scan(ctx context.Context, c *app.RequestContext) {
 		return
 	}
 
-	gitSvc := git.NewGitService()
+	gitSvc := git.NewGitService().WithContext(ctx)
 	if !gitSvc.IsGitRepo(req.Path) {
 		response.BadRequest(c, "path is not a valid git repository")
 		return
@@ -343,7 +352,7 @@ func Fetch(ctx context.Context, c *app.RequestContext) {
 		return
 	}
 
-	gitSvc := git.NewGitService()
+	gitSvc := git.NewGitService().WithContext(ctx)
 	if err := gitSvc.FetchAll(repo.Path); err != nil {
 		response.InternalServerError(c, err.Error())
 		return
@@ -0,0 +1,99 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/yi-nology/git-manage-service/biz/service/search"
+	"github.com/yi-nology/git-manage-service/pkg/response"
+)
+
+func splitList(v string) []string {
+	var list []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+func queryInt(c *app.RequestContext, name string) (int, bool) {
+	v := c.Query(name)
+	if v == "" {
+		return 0, true
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		response.BadRequest(c, "invalid "+name)
+		return 0, false
+	}
+	return n, true
+}
+
+func searchError(c *app.RequestContext, err error) {
+	switch {
+	case errors.Is(err, search.ErrInvalidQuery):
+		response.BadRequest(c, err.Error())
+	case errors.Is(err, search.ErrRepoNotFound):
+		response.NotFound(c, err.Error())
+	default:
+		response.InternalServerError(c, err.Error())
+	}
+}
+
+// SearchCode .
+// @router /api/v1/search/code [GET]
+func SearchCode(ctx context.Context, c *app.RequestContext) {
+	q := search.CodeQuery{
+		RepoKeys:   splitList(c.Query("repo_keys")),
+		Ref:        c.Query("ref"),
+		Pattern:    c.Query("pattern"),
+		Literal:    c.Query("literal") == "true",
+		IgnoreCase: c.Query("ignore_case") == "true",
+		Paths:      splitList(c.Query("paths")),
+	}
+	var ok bool
+	if q.Context, ok = queryInt(c, "context"); !ok {
+		return
+	}
+	if q.MaxMatches, ok = queryInt(c, "max_matches"); !ok {
+		return
+	}
+
+	results, err := search.SearchSvc.Code(ctx, q)
+	if err != nil {
+		searchError(c, err)
+		return
+	}
+	response.Success(c, results)
+}
+
+// SearchCommits .
+// @router /api/v1/search/commits [GET]
+func SearchCommits(ctx context.Context, c *app.RequestContext) {
+	q := search.CommitQuery{
+		RepoKeys:   splitList(c.Query("repo_keys")),
+		Ref:        c.Query("ref"),
+		Message:    c.Query("message"),
+		Author:     c.Query("author"),
+		Literal:    c.Query("literal") == "true",
+		IgnoreCase: c.Query("ignore_case") == "true",
+		Since:      c.Query("since"),
+		Until:      c.Query("until"),
+	}
+	var ok bool
+	if q.Limit, ok = queryInt(c, "limit"); !ok {
+		return
+	}
+
+	results, err := search.SearchSvc.Commits(ctx, q)
+	if err != nil {
+		searchError(c, err)
+		return
+	}
+	response.Success(c, results)
+}
@@ -4,7 +4,9 @@ package stats
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
@@ -32,7 +34,7 @@ func ListBranches(ctx context.Context, c *app.RequestContext) {
 		return
 	}
 
-	branches, err := git.NewGitService().GetBranches(repo.Path)
+	branches, err := git.NewGitService().WithContext(ctx).GetBranches(repo.Path)
 	if err != nil {
 		response.InternalServerError(c, err.Error())
 		return
@@ -56,7 +58,7 @@ func ListAuthors(ctx context.Context, c *app.RequestContext) {
 		return
 	}
 
-	authors, err := git.NewGitService().GetAuthors(repo.Path)
+	authors, err := git.NewGitService().WithContext(ctx).GetAuthors(repo.Path)
 	if err != nil {
 		response.InternalServerError(c, err.Error())
 		return
@@ -84,7 +86,7 @@ func ListCommits(ctx context.Context, c *app.RequestContext) {
 		return
 	}
 
-	raw, err := git.NewGitService().GetCommits(repo.Path, branch, since, until)
+	raw, err := git.NewGitService().WithContext(ctx).GetCommits(repo.Path, branch, since, until)
 	if err != nil {
 		response.InternalServerError(c, err.Error())
 		return
@@ -94,6 +96,53 @@ func ListCommits(ctx context.Context, c *app.RequestContext) {
 	response.Success(c, commits)
 }
 
+// GetCommitGraph .
+// @router /api/v1/stats/graph [GET]
+func GetCommitGraph(ctx context.Context, c *app.RequestContext) {
+	repoKey := c.Query("repo_key")
+	if repoKey == "" {
+		response.BadRequest(c, "repo_key is required")
+		return
+	}
+
+	repo, err := db.NewRepoDAO().FindByKey(repoKey)
+	if err != nil {
+		response.NotFound(c, "repo not found")
+		return
+	}
+
+	opts := git.GraphOptions{
+		Path:   c.Query("path"),
+		Author: c.Query("author"),
+		Cursor: c.Query("cursor"),
+	}
+	if refs := c.Query("refs"); refs != "" {
+		for _, ref := range strings.Split(refs, ",") {
+			if ref = strings.TrimSpace(ref); ref != "" {
+				opts.Refs = append(opts.Refs, ref)
+			}
+		}
+	}
+	if limit := c.Query("limit"); limit != "" {
+		if opts.Limit, err = strconv.Atoi(limit); err != nil {
+			response.BadRequest(c, "invalid limit")
+			return
+		}
+	}
+
+	page, err := git.NewGitService().WithContext(ctx).CommitGraph(repo.Path, opts)
+	if errors.Is(err, git.ErrInvalidGraphQuery) {
+		response.BadRequest(c, err.Error())
+		return
+	}
+	if err != nil {
+		response.InternalServerError(c, err.Error())
+		return
+	}
+
+	response.Success(c, page)
+}
+
 // GetStats .
 // @router /api/v1/stats/analyze [GET]
 func GetStats(ctx context.Context, c *app.RequestContext) {
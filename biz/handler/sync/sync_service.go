@@ -4,6 +4,7 @@ package sync
 
 import (
 	"context"
+	"errors"
 	"strconv"
 
 	"github.com/cloudwego/hertz/pkg/app"
@@ -12,6 +13,7 @@ import (
 	"github.com/yi-nology/git-manage-service/biz/model/api"
 	"github.com/yi-nology/git-manage-service/biz/model/po"
 	"github.com/yi-nology/git-manage-service/biz/service/audit"
+	"github.com/yi-nology/git-manage-service/biz/service/git"
 	syncSvc "github.com/yi-nology/git-manage-service/biz/service/sync"
 	"github.com/yi-nology/git-manage-service/pkg/response"
 )
@@ -108,6 +110,13 @@ func UpdateTask(ctx context.Context, c *app.RequestContext) {
 	task.PushOptions = req.PushOptions
 	task.Cron = req.Cron
 	task.Enabled = req.Enabled
+	task.LagThresholdCommits = req.LagThresholdCommits
+	task.LagThresholdMinutes = req.LagThresholdMinutes
+	task.SyncLFS = req.SyncLFS
+	task.LFSTargetURL = req.LFSTargetURL
+	task.VerifySignatures = req.VerifySignatures
+	task.SourceBundleDir = req.SourceBundleDir
+	task.TargetBundleDir = req.TargetBundleDir
 
 	if err := taskDAO.Save(task); err != nil {
 		response.InternalServerError(c, err.Error())
@@ -232,6 +241,77 @@ func ListHistory(ctx context.Context, c *app.RequestContext) {
 	response.Success(c, dtos)
 }
 
+// GetHistory .
+// @router /api/v1/sync/history/detail [GET]
+func GetHistory(ctx context.Context, c *app.RequestContext) {
+	id, err := strconv.Atoi(c.Query("id"))
+	if err != nil || id <= 0 {
+		response.BadRequest(c, "id is required")
+		return
+	}
+
+	run, err := db.NewSyncRunDAO().FindByIDWithDetails(uint(id))
+	if err != nil {
+		response.NotFound(c, "sync run not found")
+		return
+	}
+	response.Success(c, api.NewSyncRunDTO(*run))
+}
+
+// RollbackHistory .
+// @router /api/v1/sync/history/rollback [POST]
+func RollbackHistory(ctx context.Context, c *app.RequestContext) {
+	var req api.RollbackRunReq
+	if err := c.BindAndValidate(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	runDAO := db.NewSyncRunDAO()
+	orig, err := runDAO.FindByIDWithDetails(req.RunID)
+	if err != nil {
+		response.NotFound(c, "sync run not found")
+		return
+	}
+	if err := syncSvc.CheckRollback(orig); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	task, err := db.NewSyncTaskDAO().FindByKey(orig.TaskKey)
+	if err != nil {
+		response.NotFound(c, "task not found")
+		return
+	}
+	if task.TargetBundleDir != "" {
+		response.BadRequest(c, syncSvc.ErrBundleRollback.Error())
+		return
+	}
+
+	run, err := syncSvc.NewSyncService().Rollback(orig, task)
+	audit.AuditSvc.Log(c, "ROLLBACK", "task:"+task.Key, map[string]interface{}{
+		"run_id":          orig.ID,
+		"rollback_run_id": run.ID,
+		"from":            orig.PushedHash,
+		"to":              orig.PreviousTargetHash,
+		"status":          run.Status,
+	})
+	if errors.Is(err, git.ErrStaleLease) {
+		response.Conflict(c, err.Error())
+		return
+	}
+	if err != nil {
+		response.InternalServerError(c, err.Error())
+		return
+	}
+
+	detail, err := runDAO.FindByIDWithDetails(run.ID)
+	if err != nil {
+		detail = run
+	}
+	response.Success(c, api.NewSyncRunDTO(*detail))
+}
+
 // DeleteHistory .
 // @router /api/v1/sync/history/delete [POST]
 func DeleteHistory(ctx context.Context, c *app.RequestContext) {
@@ -255,3 +335,38 @@ func DeleteHistory(ctx context.Context, c *app.RequestContext) {
 	}
 	response.Success(c, map[string]string{"message": "deleted"})
 }
+
+// GetLag .
+// @router /api/v1/sync/lag [GET]
+func GetLag(ctx context.Context, c *app.RequestContext) {
+	taskKey := c.Query("task_key")
+	if taskKey == "" {
+		response.Success(c, syncSvc.LagMonitorSvc.ListStatuses())
+		return
+	}
+
+	status, ok := syncSvc.LagMonitorSvc.GetStatus(taskKey)
+	if !ok {
+		response.NotFound(c, "lag has not been checked for this task yet")
+		return
+	}
+	response.Success(c, status)
+}
+
+// CheckLag .
+// @router /api/v1/sync/lag/check [POST]
+func CheckLag(ctx context.Context, c *app.RequestContext) {
+	var req api.CheckLagReq
+	if err := c.BindAndValidate(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	task, err := db.NewSyncTaskDAO().FindByKey(req.TaskKey)
+	if err != nil {
+		response.NotFound(c, "task not found")
+		return
+	}
+
+	response.Success(c, syncSvc.LagMonitorSvc.CheckTask(task))
+}
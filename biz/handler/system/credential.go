@@ -0,0 +1,112 @@
+package system
+
+import (
+	"context"
+	"errors"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/yi-nology/git-manage-service/biz/dal/db"
+	"github.com/yi-nology/git-manage-service/biz/model/api"
+	"github.com/yi-nology/git-manage-service/biz/model/po"
+	"github.com/yi-nology/git-manage-service/biz/service/audit"
+	"github.com/yi-nology/git-manage-service/biz/service/credential"
+	"github.com/yi-nology/git-manage-service/pkg/response"
+)
+
+// ListCredentials .
+// @router /api/v1/system/credentials [GET]
+func ListCredentials(ctx context.Context, c *app.RequestContext) {
+	creds, err := db.NewCredentialDAO().FindAll()
+	if err != nil {
+		response.InternalServerError(c, err.Error())
+		return
+	}
+
+	dtos := make([]api.CredentialDTO, 0, len(creds))
+	for _, cred := range creds {
+		dtos = append(dtos, api.NewCredentialDTO(cred))
+	}
+	response.Success(c, dtos)
+}
+
+// CreateCredential .
+// @router /api/v1/system/credentials/create [POST]
+func CreateCredential(ctx context.Context, c *app.RequestContext) {
+	var req api.CredentialReq
+	if err := c.BindAndValidate(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	cred := credentialFromReq(req)
+	err := credential.CredentialSvc.Create(&cred)
+	respondCredential(c, "CREATE", &cred, err)
+}
+
+// UpdateCredential .
+// @router /api/v1/system/credentials/update [POST]
+func UpdateCredential(ctx context.Context, c *app.RequestContext) {
+	var req api.CredentialReq
+	if err := c.BindAndValidate(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	cred, err := credential.CredentialSvc.Update(req.ID, credentialFromReq(req))
+	respondCredential(c, "UPDATE", cred, err)
+}
+
+// DeleteCredential .
+// @router /api/v1/system/credentials/delete [POST]
+func DeleteCredential(ctx context.Context, c *app.RequestContext) {
+	var req api.CredentialIDReq
+	if err := c.BindAndValidate(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	cred, err := credential.CredentialSvc.Delete(req.ID)
+	if errors.Is(err, credential.ErrCredentialNotFound) {
+		response.NotFound(c, "credential not found")
+		return
+	}
+	if err != nil {
+		response.InternalServerError(c, err.Error())
+		return
+	}
+	audit.AuditSvc.Log(c, "DELETE", "credential:"+cred.Name, api.NewCredentialDTO(*cred))
+
+	response.Success(c, nil)
+}
+
+func credentialFromReq(req api.CredentialReq) po.Credential {
+	return po.Credential{
+		Name:      req.Name,
+		Type:      req.Type,
+		Patterns:  credential.PatternsFromList(req.Patterns),
+		Username:  req.Username,
+		Secret:    req.Secret,
+		ExpiresAt: req.ExpiresAt,
+	}
+}
+
+func respondCredential(c *app.RequestContext, action string, cred *po.Credential, err error) {
+	switch {
+	case errors.Is(err, credential.ErrInvalidCredential):
+		response.BadRequest(c, err.Error())
+		return
+	case errors.Is(err, credential.ErrCredentialNotFound):
+		response.NotFound(c, "credential not found")
+		return
+	case errors.Is(err, credential.ErrCredentialExists):
+		response.Conflict(c, err.Error())
+		return
+	case err != nil:
+		response.InternalServerError(c, err.Error())
+		return
+	}
+	dto := api.NewCredentialDTO(*cred)
+	audit.AuditSvc.Log(c, action, "credential:"+cred.Name, dto)
+
+	response.Success(c, dto)
+}
@@ -0,0 +1,104 @@
+package system
+
+import (
+	"context"
+	"errors"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/yi-nology/git-manage-service/biz/dal/db"
+	"github.com/yi-nology/git-manage-service/biz/model/api"
+	"github.com/yi-nology/git-manage-service/biz/service/audit"
+	"github.com/yi-nology/git-manage-service/biz/service/hostkey"
+	"github.com/yi-nology/git-manage-service/pkg/response"
+	"gorm.io/gorm"
+)
+
+// ListKnownHosts .
+// @router /api/v1/system/known-hosts [GET]
+func ListKnownHosts(ctx context.Context, c *app.RequestContext) {
+	hosts, err := db.NewKnownHostDAO().FindAll()
+	if err != nil {
+		response.InternalServerError(c, err.Error())
+		return
+	}
+
+	dtos := make([]api.KnownHostDTO, 0, len(hosts))
+	for _, h := range hosts {
+		dtos = append(dtos, api.NewKnownHostDTO(h))
+	}
+	response.Success(c, map[string]interface{}{
+		"mode":  hostkey.HostKeySvc.Mode(),
+		"items": dtos,
+	})
+}
+
+// CreateKnownHost .
+// @router /api/v1/system/known-hosts/create [POST]
+func CreateKnownHost(ctx context.Context, c *app.RequestContext) {
+	var req api.CreateKnownHostReq
+	if err := c.BindAndValidate(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	host, err := hostkey.HostKeySvc.Add(req.Host, req.KeyData)
+	if errors.Is(err, hostkey.ErrInvalidHostKey) {
+		response.BadRequest(c, err.Error())
+		return
+	}
+	if err != nil {
+		response.InternalServerError(c, err.Error())
+		return
+	}
+	dto := api.NewKnownHostDTO(*host)
+	audit.AuditSvc.Log(c, "CREATE", "known_host:"+host.Host, dto)
+
+	response.Success(c, dto)
+}
+
+// ApproveKnownHost .
+// @router /api/v1/system/known-hosts/approve [POST]
+func ApproveKnownHost(ctx context.Context, c *app.RequestContext) {
+	var req api.KnownHostIDReq
+	if err := c.BindAndValidate(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	host, err := hostkey.HostKeySvc.Approve(req.ID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		response.NotFound(c, "known host not found")
+		return
+	}
+	if err != nil {
+		response.InternalServerError(c, err.Error())
+		return
+	}
+	dto := api.NewKnownHostDTO(*host)
+	audit.AuditSvc.Log(c, "APPROVE", "known_host:"+host.Host, dto)
+
+	response.Success(c, dto)
+}
+
+// DeleteKnownHost .
+// @router /api/v1/system/known-hosts/delete [POST]
+func DeleteKnownHost(ctx context.Context, c *app.RequestContext) {
+	var req api.KnownHostIDReq
+	if err := c.BindAndValidate(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	host, err := hostkey.HostKeySvc.Delete(req.ID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		response.NotFound(c, "known host not found")
+		return
+	}
+	if err != nil {
+		response.InternalServerError(c, err.Error())
+		return
+	}
+	audit.AuditSvc.Log(c, "DELETE", "known_host:"+host.Host, api.NewKnownHostDTO(*host))
+
+	response.Success(c, nil)
+}
@@ -0,0 +1,102 @@
+package system
+
+import (
+	"context"
+	"errors"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/yi-nology/git-manage-service/biz/dal/db"
+	"github.com/yi-nology/git-manage-service/biz/model/api"
+	"github.com/yi-nology/git-manage-service/biz/model/po"
+	"github.com/yi-nology/git-manage-service/biz/service/audit"
+	"github.com/yi-nology/git-manage-service/biz/service/sshkey"
+	"github.com/yi-nology/git-manage-service/pkg/response"
+)
+
+// ListManagedSSHKeys .
+// @router /api/v1/system/ssh-keys/managed [GET]
+func ListManagedSSHKeys(ctx context.Context, c *app.RequestContext) {
+	keys, err := db.NewSSHKeyDAO().FindAll()
+	if err != nil {
+		response.InternalServerError(c, err.Error())
+		return
+	}
+
+	dtos := make([]api.ManagedSSHKeyDTO, 0, len(keys))
+	for _, k := range keys {
+		dtos = append(dtos, api.NewManagedSSHKeyDTO(k, sshkey.Ref(k.ID)))
+	}
+	response.Success(c, dtos)
+}
+
+// GenerateSSHKey .
+// @router /api/v1/system/ssh-keys/generate [POST]
+func GenerateSSHKey(ctx context.Context, c *app.RequestContext) {
+	var req api.GenerateSSHKeyReq
+	if err := c.BindAndValidate(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	key, err := sshkey.SSHKeySvc.Generate(req.Name, req.Type, req.Bits, req.Comment)
+	respondSSHKey(c, "GENERATE", key, err)
+}
+
+// ImportSSHKey .
+// @router /api/v1/system/ssh-keys/import [POST]
+func ImportSSHKey(ctx context.Context, c *app.RequestContext) {
+	var req api.ImportSSHKeyReq
+	if err := c.BindAndValidate(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	key, err := sshkey.SSHKeySvc.Import(req.Name, req.PrivateKey, req.Passphrase, req.Comment)
+	respondSSHKey(c, "IMPORT", key, err)
+}
+
+func respondSSHKey(c *app.RequestContext, action string, key *po.SSHKey, err error) {
+	if errors.Is(err, sshkey.ErrInvalidKey) || errors.Is(err, sshkey.ErrPassphraseRequired) {
+		response.BadRequest(c, err.Error())
+		return
+	}
+	if errors.Is(err, sshkey.ErrKeyExists) {
+		response.Conflict(c, err.Error())
+		return
+	}
+	if err != nil {
+		response.InternalServerError(c, err.Error())
+		return
+	}
+	dto := api.NewManagedSSHKeyDTO(*key, sshkey.Ref(key.ID))
+	audit.AuditSvc.Log(c, action, "ssh_key:"+key.Name, dto)
+
+	response.Success(c, dto)
+}
+
+// DeleteSSHKey .
+// @router /api/v1/system/ssh-keys/delete [POST]
+func DeleteSSHKey(ctx context.Context, c *app.RequestContext) {
+	var req api.SSHKeyIDReq
+	if err := c.BindAndValidate(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	key, err := sshkey.SSHKeySvc.Delete(req.ID)
+	if errors.Is(err, sshkey.ErrKeyNotFound) {
+		response.NotFound(c, "ssh key not found")
+		return
+	}
+	if errors.Is(err, sshkey.ErrKeyInUse) {
+		response.Conflict(c, err.Error())
+		return
+	}
+	if err != nil {
+		response.InternalServerError(c, err.Error())
+		return
+	}
+	audit.AuditSvc.Log(c, "DELETE", "ssh_key:"+key.Name, api.NewManagedSSHKeyDTO(*key, sshkey.Ref(key.ID)))
+
+	response.Success(c, nil)
+}
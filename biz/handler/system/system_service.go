@@ -15,6 +15,7 @@ import (
 	"github.com/yi-nology/git-manage-service/biz/model/api"
 	"github.com/yi-nology/git-manage-service/biz/service/audit"
 	"github.com/yi-nology/git-manage-service/biz/service/git"
+	"github.com/yi-nology/git-manage-service/biz/service/sshkey"
 	"github.com/yi-nology/git-manage-service/pkg/configs"
 	"github.com/yi-nology/git-manage-service/pkg/response"
 )
@@ -22,7 +23,7 @@ import (
 // GetConfig .
 // @router /api/v1/system/config [GET]
 func GetConfig(ctx context.Context, c *app.RequestContext) {
-	gitSvc := git.NewGitService()
+	gitSvc := git.NewGitService().WithContext(ctx)
 	name, email, _ := gitSvc.GetGlobalGitUser()
 
 	response.Success(c, map[string]interface{}{
@@ -43,7 +44,7 @@ func UpdateConfig(ctx context.Context, c *app.RequestContext) {
 
 	configs.DebugMode = req.DebugMode
 
-	gitSvc := git.NewGitService()
+	gitSvc := git.NewGitService().WithContext(ctx)
 	if err := gitSvc.SetGlobalGitUser(req.AuthorName, req.AuthorEmail); err != nil {
 		response.InternalServerError(c, "Failed to set git config: "+err.Error())
 		return
@@ -119,21 +120,31 @@ func ListSSHKeys(ctx context.Context, c *app.RequestContext) {
 		return
 	}
 
+	keys := []api.SSHKey{}
 	sshDir := filepath.Join(home, ".ssh")
-	entries, err := os.ReadDir(sshDir)
+	if entries, err := os.ReadDir(sshDir); err == nil {
+		for _, entry := range entries {
+			if !entry.IsDir() {
+				keys = append(keys, api.SSHKey{
+					Name: entry.Name(),
+					Path: filepath.Join(sshDir, entry.Name()),
+				})
+			}
+		}
+	}
+
+	// Managed keys are referenced as sshkey:<id>
+	managed, err := db.NewSSHKeyDAO().FindAll()
 	if err != nil {
-		response.Success(c, []api.SSHKey{})
+		response.InternalServerError(c, err.Error())
 		return
 	}
-
-	var keys []api.SSHKey
-	for _, entry := range entries {
-		if !entry.IsDir() {
-			keys = append(keys, api.SSHKey{
-				Name: entry.Name(),
-				Path: filepath.Join(sshDir, entry.Name()),
-			})
-		}
+	for _, k := range managed {
+		keys = append(keys, api.SSHKey{
+			Name:    k.Name,
+			Path:    sshkey.Ref(k.ID),
+			Managed: true,
+		})
 	}
 
 	response.Success(c, keys)
@@ -148,7 +159,7 @@ func TestConnection(ctx context.Context, c *app.RequestContext) {
 		return
 	}
 
-	gitSvc := git.NewGitService()
+	gitSvc := git.NewGitService().WithContext(ctx)
 	if err := gitSvc.TestRemoteConnection(req.URL); err != nil {
 		response.Success(c, map[string]string{"status": "failed", "error": err.Error()})
 		return
@@ -157,6 +168,29 @@ func TestConnection(ctx context.Context, c *app.RequestContext) {
 	response.Success(c, map[string]string{"status": "success"})
 }
 
+// LsRemote .
+// @router /api/v1/system/ls-remote [POST]
+func LsRemote(ctx context.Context, c *app.RequestContext) {
+	var req api.LsRemoteReq
+	if err := c.BindAndValidate(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+	if req.URL == "" {
+		response.BadRequest(c, "url is required")
+		return
+	}
+
+	gitSvc := git.NewGitService().WithContext(ctx)
+	refs, err := gitSvc.LsRemote(req.URL, req.AuthType, req.AuthKey, req.AuthSecret)
+	if err != nil {
+		response.Success(c, map[string]string{"status": "failed", "error": err.Error(), "reason": git.RemoteErrorReason(err)})
+		return
+	}
+
+	response.Success(c, map[string]interface{}{"status": "success", "refs": refs})
+}
+
 // GetRepoStatus .
 // @router /api/v1/system/repo/status [GET]
 func GetRepoStatus(ctx context.Context, c *app.RequestContext) {
@@ -172,7 +206,7 @@ func GetRepoStatus(ctx context.Context, c *app.RequestContext) {
 		return
 	}
 
-	gitSvc := git.NewGitService()
+	gitSvc := git.NewGitService().WithContext(ctx)
 	status, err := gitSvc.GetStatus(repo.Path)
 	if err != nil {
 		response.InternalServerError(c, err.Error())
@@ -197,7 +231,7 @@ func GetRepoGitConfig(ctx context.Context, c *app.RequestContext) {
 		return
 	}
 
-	gitSvc := git.NewGitService()
+	gitSvc := git.NewGitService().WithContext(ctx)
 	name, email, _ := gitSvc.GetGitUser(repo.Path)
 
 	response.Success(c, map[string]string{
@@ -232,7 +266,7 @@ func SubmitChanges(ctx context.Context, c *app.RequestContext) {
 		return
 	}
 
-	gitSvc := git.NewGitService()
+	gitSvc := git.NewGitService().WithContext(ctx)
 
 	if err := gitSvc.AddAll(repo.Path); err != nil {
 		response.InternalServerError(c, "Failed to stage files: "+err.Error())
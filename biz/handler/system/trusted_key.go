@@ -0,0 +1,82 @@
+package system
+
+import (
+	"context"
+	"strings"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/yi-nology/git-manage-service/biz/dal/db"
+	"github.com/yi-nology/git-manage-service/biz/model/api"
+	"github.com/yi-nology/git-manage-service/biz/service/audit"
+	"github.com/yi-nology/git-manage-service/biz/service/signature"
+	"github.com/yi-nology/git-manage-service/pkg/response"
+)
+
+// ListTrustedKeys .
+// @router /api/v1/system/trusted-keys [GET]
+func ListTrustedKeys(ctx context.Context, c *app.RequestContext) {
+	keys, err := db.NewTrustedKeyDAO().FindAll()
+	if err != nil {
+		response.InternalServerError(c, err.Error())
+		return
+	}
+
+	dtos := make([]api.TrustedKeyDTO, 0, len(keys))
+	for _, k := range keys {
+		dtos = append(dtos, api.NewTrustedKeyDTO(k))
+	}
+	response.Success(c, dtos)
+}
+
+// CreateTrustedKey .
+// @router /api/v1/system/trusted-keys/create [POST]
+func CreateTrustedKey(ctx context.Context, c *app.RequestContext) {
+	var req api.CreateTrustedKeyReq
+	if err := c.BindAndValidate(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	key, err := signature.ParseKey(strings.ToLower(req.Type), req.KeyData)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+	key.Name = req.Name
+	if key.Name == "" {
+		key.Name = key.Identity
+	}
+
+	if err := db.NewTrustedKeyDAO().Create(key); err != nil {
+		response.InternalServerError(c, err.Error())
+		return
+	}
+	dto := api.NewTrustedKeyDTO(*key)
+	audit.AuditSvc.Log(c, "CREATE", "trusted_key:"+key.Fingerprint, dto)
+
+	response.Success(c, dto)
+}
+
+// DeleteTrustedKey .
+// @router /api/v1/system/trusted-keys/delete [POST]
+func DeleteTrustedKey(ctx context.Context, c *app.RequestContext) {
+	var req api.DeleteTrustedKeyReq
+	if err := c.BindAndValidate(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	dao := db.NewTrustedKeyDAO()
+	key, err := dao.FindByID(req.ID)
+	if err != nil {
+		response.NotFound(c, "trusted key not found")
+		return
+	}
+	if err := dao.Delete(key); err != nil {
+		response.InternalServerError(c, err.Error())
+		return
+	}
+	audit.AuditSvc.Log(c, "DELETE", "trusted_key:"+key.Fingerprint, api.NewTrustedKeyDTO(*key))
+
+	response.Success(c, nil)
+}
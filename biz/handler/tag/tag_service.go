@@ -28,7 +28,7 @@ func List(ctx context.Context, c *app.RequestContext) {
 		return
 	}
 
-	svc := git.NewGitService()
+	svc := git.NewGitService().WithContext(ctx)
 	tags, err := svc.GetTags(repo.Path)
 	if err != nil {
 		response.InternalServerError(c, err.Error())
@@ -59,7 +59,7 @@ func Create(ctx context.Context, c *app.RequestContext) {
 		return
 	}
 
-	svc := git.NewGitService()
+	svc := git.NewGitService().WithContext(ctx)
 	authorName, authorEmail, _ := svc.GetGlobalGitUser()
 
 	tagName := req.TagName
@@ -26,7 +26,7 @@ func GetVersion(ctx context.Context, c *app.RequestContext) {
 		return
 	}
 
-	svc := git.NewGitService()
+	svc := git.NewGitService().WithContext(ctx)
 	version, err := svc.GetDescribe(repo.Path)
 	if err != nil {
 		response.InternalServerError(c, "failed to determine version: "+err.Error())
@@ -51,7 +51,7 @@ func ListVersions(ctx context.Context, c *app.RequestContext) {
 		return
 	}
 
-	svc := git.NewGitService()
+	svc := git.NewGitService().WithContext(ctx)
 	tags, err := svc.GetTagList(repo.Path)
 	if err != nil {
 		response.InternalServerError(c, err.Error())
@@ -76,7 +76,7 @@ func GetNextVersions(ctx context.Context, c *app.RequestContext) {
 		return
 	}
 
-	svc := git.NewGitService()
+	svc := git.NewGitService().WithContext(ctx)
 	info, err := svc.GetNextVersions(repo.Path)
 	if err != nil {
 		response.InternalServerError(c, "failed to calculate next versions: "+err.Error())
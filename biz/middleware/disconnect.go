@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"context"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/network/netpoll"
+)
+
+// CancelOnDisconnect cancels the request context once the client closed its connection,
+// so handlers waiting on git (fetch, push, clone) stop their work. The hertz version in
+// use does not notice disconnects itself, so the connection is polled every interval.
+func CancelOnDisconnect(interval time.Duration) app.HandlerFunc {
+	return func(ctx context.Context, c *app.RequestContext) {
+		conn, ok := c.GetConn().(*netpoll.Conn)
+		if !ok {
+			c.Next(ctx)
+			return
+		}
+		active, ok := conn.Conn.(interface{ IsActive() bool })
+		if !ok {
+			c.Next(ctx)
+			return
+		}
+
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		done := make(chan struct{})
+		defer close(done)
+		go func() {
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-done:
+					return
+				case <-ticker.C:
+					if !active.IsActive() {
+						cancel()
+						return
+					}
+				}
+			}
+		}()
+		c.Next(ctx)
+	}
+}
@@ -0,0 +1,18 @@
+package api
+
+type BatchJobReq struct {
+	RepoKeys    []string `json:"repo_keys"`
+	Group       string   `json:"group"`
+	All         bool     `json:"all"`
+	Operation   string   `json:"operation"` // create_branch, create_tag, push, delete_branch
+	Branch      string   `json:"branch"`
+	Tag         string   `json:"tag"`
+	Ref         string   `json:"ref"`
+	Message     string   `json:"message"`
+	Remote      string   `json:"remote"`
+	Force       bool     `json:"force"`       // delete_branch: also delete unmerged branches
+	Concurrency int      `json:"concurrency"` // default 4, at most 16
+
+	AuthorName  string `json:"author_name"`
+	AuthorEmail string `json:"author_email"`
+}
@@ -10,6 +10,7 @@ import (
 type RegisterRepoReq struct {
 	Name         string                     `json:"name"`
 	Path         string                     `json:"path"`
+	Group        string                     `json:"group"`
 	RemoteURL    string                     `json:"remote_url"`
 	AuthType     string                     `json:"auth_type"`
 	AuthKey      string                     `json:"auth_key"`
@@ -36,11 +37,56 @@ type TestConnectionReq struct {
 	URL string `json:"url"`
 }
 
+type LsRemoteReq struct {
+	URL        string `json:"url"`
+	AuthType   string `json:"auth_type"`
+	AuthKey    string `json:"auth_key"`
+	AuthSecret string `json:"auth_secret"`
+}
+
 type MergeReq struct {
 	Source   string `json:"source"`
 	Target   string `json:"target"`
 	Message  string `json:"message"`
-	Strategy string `json:"strategy"` // Not implemented yet
+	Strategy string `json:"strategy"` // merge (default), ff-only, no-ff, squash, rebase
+
+	AuthorName  string `json:"author_name"`
+	AuthorEmail string `json:"author_email"`
+}
+
+type CherryPickReq struct {
+	RepoKey string   `json:"repo_key"`
+	Commits []string `json:"commits"` // applied in order
+	Targets []string `json:"targets"` // target branches
+	Push    bool     `json:"push"`
+	Remote  string   `json:"remote"` // default origin
+
+	AuthorName  string `json:"author_name"`
+	AuthorEmail string `json:"author_email"`
+}
+
+type RevertReq struct {
+	RepoKey  string `json:"repo_key"`
+	Commit   string `json:"commit"`
+	Branch   string `json:"branch"`
+	Mainline int    `json:"mainline"` // parent number to keep, required for merge commits
+	Message  string `json:"message"`
+	Push     bool   `json:"push"`
+	Remote   string `json:"remote"` // default origin
+
+	AuthorName  string `json:"author_name"`
+	AuthorEmail string `json:"author_email"`
+}
+
+type BranchCleanupReq struct {
+	RepoKey      string   `json:"repo_key"`
+	Branches     []string `json:"branches"`
+	Base         string   `json:"base"`          // protected from deletion
+	Archive      bool     `json:"archive"`       // tag each branch before deleting it
+	Force        bool     `json:"force"`         // delete unmerged branches without archiving
+	TagPrefix    string   `json:"tag_prefix"`    // default archive/
+	DeleteRemote bool     `json:"delete_remote"` // also delete the upstream branch
+	DryRun       bool     `json:"dry_run"`
 }
 
 type RepoDTO struct {
@@ -48,6 +94,7 @@ type RepoDTO struct {
 	Key          string                     `json:"key"`
 	Name         string                     `json:"name"`
 	Path         string                     `json:"path"`
+	Group        string                     `json:"group"`
 	RemoteURL    string                     `json:"remote_url"`
 	AuthType     string                     `json:"auth_type"`
 	AuthKey      string                     `json:"auth_key"`
@@ -64,6 +111,7 @@ func NewRepoDTO(r po.Repo) RepoDTO {
 		Key:          r.Key,
 		Name:         r.Name,
 		Path:         r.Path,
+		Group:        r.Group,
 		RemoteURL:    r.RemoteURL,
 		AuthType:     r.AuthType,
 		AuthKey:      r.AuthKey,
@@ -74,3 +122,42 @@ func NewRepoDTO(r po.Repo) RepoDTO {
 		UpdatedAt:    r.UpdatedAt,
 	}
 }
+
+// RepoHealthDTO is the maintenance state of a repository. Status is unknown until
+// maintenance ran once.
+type RepoHealthDTO struct {
+	RepoKey      string     `json:"repo_key"`
+	RepoName     string     `json:"repo_name"`
+	Status       string     `json:"status"` // ok, corrupt, error, unknown
+	FsckAt       *time.Time `json:"fsck_at"`
+	FsckProblems []string   `json:"fsck_problems"`
+	LastTasks    string     `json:"last_tasks"`
+	LastError    string     `json:"last_error"`
+	LastRunAt    *time.Time `json:"last_run_at"`
+	Duration     int64      `json:"duration"` // ms
+	Running      bool       `json:"running"`
+}
+
+func NewRepoHealthDTO(r po.Repo, h po.RepoHealth, running bool) RepoHealthDTO {
+	status := h.Status
+	if status == "" {
+		status = "unknown"
+	}
+	return RepoHealthDTO{
+		RepoKey:      r.Key,
+		RepoName:     r.Name,
+		Status:       status,
+		FsckAt:       h.FsckAt,
+		FsckProblems: h.Problems(),
+		LastTasks:    h.LastTasks,
+		LastError:    h.LastError,
+		LastRunAt:    h.LastRunAt,
+		Duration:     h.Duration,
+		Running:      running,
+	}
+}
+
+type RunMaintenanceReq struct {
+	RepoKey string   `json:"repo_key"`
+	Tasks   []string `json:"tasks"` // fsck, gc, repack, commit-graph; empty runs the configured tasks
+}
@@ -18,6 +18,36 @@ type SyncRunDTO struct {
 	CreatedAt    time.Time   `json:"created_at"`
 	UpdatedAt    time.Time   `json:"updated_at"`
 	Task         SyncTaskDTO `json:"task"`
+
+	Kind               string `json:"kind"`
+	PreviousTargetHash string `json:"previous_target_hash"`
+	PushedHash         string `json:"pushed_hash"`
+	RollbackOf         uint   `json:"rollback_of,omitempty"`
+
+	Steps   []SyncRunStepDTO   `json:"steps,omitempty"`
+	Commits []SyncRunCommitDTO `json:"commits,omitempty"`
+}
+
+type RollbackRunReq struct {
+	RunID uint `json:"run_id"`
+}
+
+type SyncRunStepDTO struct {
+	Seq        int       `json:"seq"`
+	Name       string    `json:"name"`
+	Status     string    `json:"status"`
+	Message    string    `json:"message"`
+	StartTime  time.Time `json:"start_time"`
+	EndTime    time.Time `json:"end_time"`
+	DurationMs int64     `json:"duration_ms"`
+}
+
+type SyncRunCommitDTO struct {
+	Hash        string    `json:"hash"`
+	AuthorName  string    `json:"author_name"`
+	AuthorEmail string    `json:"author_email"`
+	Subject     string    `json:"subject"`
+	CommitTime  time.Time `json:"commit_time"`
 }
 
 func NewSyncRunDTO(r po.SyncRun) SyncRunDTO {
@@ -32,9 +62,34 @@ func NewSyncRunDTO(r po.SyncRun) SyncRunDTO {
 		EndTime:      r.EndTime,
 		CreatedAt:    r.CreatedAt,
 		UpdatedAt:    r.UpdatedAt,
+
+		Kind:               r.Kind,
+		PreviousTargetHash: r.PreviousTargetHash,
+		PushedHash:         r.PushedHash,
+		RollbackOf:         r.RollbackOf,
 	}
 	if r.Task.ID != 0 {
 		dto.Task = NewSyncTaskDTO(r.Task)
 	}
+	for _, st := range r.Steps {
+		dto.Steps = append(dto.Steps, SyncRunStepDTO{
+			Seq:        st.Seq,
+			Name:       st.Name,
+			Status:     st.Status,
+			Message:    st.Message,
+			StartTime:  st.StartTime,
+			EndTime:    st.EndTime,
+			DurationMs: st.DurationMs,
+		})
+	}
+	for _, cm := range r.Commits {
+		dto.Commits = append(dto.Commits, SyncRunCommitDTO{
+			Hash:        cm.Hash,
+			AuthorName:  cm.AuthorName,
+			AuthorEmail: cm.AuthorEmail,
+			Subject:     cm.Subject,
+			CommitTime:  cm.CommitTime,
+		})
+	}
 	return dto
 }
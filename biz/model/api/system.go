@@ -1,5 +1,11 @@
 package api
 
+import (
+	"time"
+
+	"github.com/yi-nology/git-manage-service/biz/model/po"
+)
+
 type ListDirsReq struct {
 	Path   string `query:"path"`
 	Search string `query:"search"`
@@ -17,8 +23,9 @@ type ListDirsResp struct {
 }
 
 type SSHKey struct {
-	Name string `json:"name"`
-	Path string `json:"path"`
+	Name    string `json:"name"`
+	Path    string `json:"path"` // key file path, or sshkey:<id> for a managed key
+	Managed bool   `json:"managed"`
 }
 
 type ConfigReq struct {
@@ -26,3 +33,156 @@ type ConfigReq struct {
 	AuthorName  string `json:"author_name"`
 	AuthorEmail string `json:"author_email"`
 }
+
+type TrustedKeyDTO struct {
+	ID          uint      `json:"id"`
+	Name        string    `json:"name"`
+	Type        string    `json:"type"`
+	Fingerprint string    `json:"fingerprint"`
+	Identity    string    `json:"identity"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func NewTrustedKeyDTO(k po.TrustedKey) TrustedKeyDTO {
+	return TrustedKeyDTO{
+		ID:          k.ID,
+		Name:        k.Name,
+		Type:        k.Type,
+		Fingerprint: k.Fingerprint,
+		Identity:    k.Identity,
+		CreatedAt:   k.CreatedAt,
+	}
+}
+
+type CreateTrustedKeyReq struct {
+	Name    string `json:"name"`
+	Type    string `json:"type"`     // gpg, ssh
+	KeyData string `json:"key_data"` // armored GPG public key or authorized_keys line
+}
+
+type DeleteTrustedKeyReq struct {
+	ID uint `json:"id"`
+}
+
+type KnownHostDTO struct {
+	ID          uint       `json:"id"`
+	Host        string     `json:"host"`
+	KeyType     string     `json:"key_type"`
+	Fingerprint string     `json:"fingerprint"`
+	PublicKey   string     `json:"public_key"`
+	Status      string     `json:"status"`
+	Source      string     `json:"source"`
+	LastSeenAt  *time.Time `json:"last_seen_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+func NewKnownHostDTO(h po.KnownHost) KnownHostDTO {
+	return KnownHostDTO{
+		ID:          h.ID,
+		Host:        h.Host,
+		KeyType:     h.KeyType,
+		Fingerprint: h.Fingerprint,
+		PublicKey:   h.PublicKey,
+		Status:      h.Status,
+		Source:      h.Source,
+		LastSeenAt:  h.LastSeenAt,
+		CreatedAt:   h.CreatedAt,
+	}
+}
+
+type CreateKnownHostReq struct {
+	Host    string `json:"host"`     // host or host:port
+	KeyData string `json:"key_data"` // authorized_keys or known_hosts line; empty fetches the key from the host
+}
+
+type KnownHostIDReq struct {
+	ID uint `json:"id"`
+}
+
+// ManagedSSHKeyDTO never carries the private key or passphrase
+type ManagedSSHKeyDTO struct {
+	ID          uint      `json:"id"`
+	Name        string    `json:"name"`
+	Ref         string    `json:"ref"` // value to use as auth_key
+	Type        string    `json:"type"`
+	Bits        int       `json:"bits"`
+	Fingerprint string    `json:"fingerprint"`
+	PublicKey   string    `json:"public_key"`
+	Protected   bool      `json:"protected"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func NewManagedSSHKeyDTO(k po.SSHKey, ref string) ManagedSSHKeyDTO {
+	return ManagedSSHKeyDTO{
+		ID:          k.ID,
+		Name:        k.Name,
+		Ref:         ref,
+		Type:        k.Type,
+		Bits:        k.Bits,
+		Fingerprint: k.Fingerprint,
+		PublicKey:   k.PublicKey,
+		Protected:   k.Passphrase != "",
+		CreatedAt:   k.CreatedAt,
+	}
+}
+
+type GenerateSSHKeyReq struct {
+	Name    string `json:"name"`
+	Type    string `json:"type"` // ed25519 (default), rsa
+	Bits    int    `json:"bits"` // rsa only, default 4096
+	Comment string `json:"comment"`
+}
+
+type ImportSSHKeyReq struct {
+	Name       string `json:"name"`
+	PrivateKey string `json:"private_key"`
+	Passphrase string `json:"passphrase"`
+	Comment    string `json:"comment"`
+}
+
+type SSHKeyIDReq struct {
+	ID uint `json:"id"`
+}
+
+// CredentialDTO never carries the secret
+type CredentialDTO struct {
+	ID         uint       `json:"id"`
+	Name       string     `json:"name"`
+	Type       string     `json:"type"`
+	Patterns   []string   `json:"patterns"`
+	Username   string     `json:"username"`
+	HasSecret  bool       `json:"has_secret"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	Expired    bool       `json:"expired"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+func NewCredentialDTO(c po.Credential) CredentialDTO {
+	return CredentialDTO{
+		ID:         c.ID,
+		Name:       c.Name,
+		Type:       c.Type,
+		Patterns:   c.PatternList(),
+		Username:   c.Username,
+		HasSecret:  c.Secret != "",
+		ExpiresAt:  c.ExpiresAt,
+		Expired:    c.Expired(time.Now()),
+		LastUsedAt: c.LastUsedAt,
+		CreatedAt:  c.CreatedAt,
+	}
+}
+
+type CredentialReq struct {
+	ID        uint       `json:"id"` // update only
+	Name      string     `json:"name"`
+	Type      string     `json:"type"`     // http, ssh
+	Patterns  []string   `json:"patterns"` // hosts (git.example.com, *.example.com) or URL prefixes
+	Username  string     `json:"username"` // http username, or SSH key path / sshkey:<id>
+	Secret    string     `json:"secret"`   // token, password or key passphrase; empty keeps the stored one on update
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+type CredentialIDReq struct {
+	ID uint `json:"id"`
+}
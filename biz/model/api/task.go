@@ -10,6 +10,10 @@ type RunSyncReq struct {
 	TaskKey string `json:"task_key"`
 }
 
+type CheckLagReq struct {
+	TaskKey string `json:"task_key"`
+}
+
 type ExecuteSyncReq struct {
 	RepoKey      string `json:"repo_key"`
 	SourceRemote string `json:"source_remote"` // "local", "origin", etc
@@ -34,6 +38,17 @@ type SyncTaskDTO struct {
 	CreatedAt     time.Time `json:"created_at"`
 	UpdatedAt     time.Time `json:"updated_at"`
 
+	LagThresholdCommits int `json:"lag_threshold_commits"`
+	LagThresholdMinutes int `json:"lag_threshold_minutes"`
+
+	SyncLFS      bool   `json:"sync_lfs"`
+	LFSTargetURL string `json:"lfs_target_url"`
+
+	VerifySignatures bool `json:"verify_signatures"`
+
+	SourceBundleDir string `json:"source_bundle_dir"`
+	TargetBundleDir string `json:"target_bundle_dir"`
+
 	SourceRepo RepoDTO `json:"source_repo"`
 	TargetRepo RepoDTO `json:"target_repo"`
 }
@@ -53,6 +68,17 @@ func NewSyncTaskDTO(t po.SyncTask) SyncTaskDTO {
 		Enabled:       t.Enabled,
 		CreatedAt:     t.CreatedAt,
 		UpdatedAt:     t.UpdatedAt,
+
+		LagThresholdCommits: t.LagThresholdCommits,
+		LagThresholdMinutes: t.LagThresholdMinutes,
+
+		SyncLFS:      t.SyncLFS,
+		LFSTargetURL: t.LFSTargetURL,
+
+		VerifySignatures: t.VerifySignatures,
+
+		SourceBundleDir: t.SourceBundleDir,
+		TargetBundleDir: t.TargetBundleDir,
 	}
 	// Map relations if loaded
 	if t.SourceRepo.ID != 0 {
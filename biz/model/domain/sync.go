@@ -0,0 +1,24 @@
+package domain
+
+import "time"
+
+// SyncLag describes how far a sync task's target is behind its source
+type SyncLag struct {
+	TaskKey      string `json:"task_key"`
+	Enabled      bool   `json:"enabled"`
+	SourceHash   string `json:"source_hash"`
+	TargetHash   string `json:"target_hash"`
+	TargetExists bool   `json:"target_exists"`
+
+	LagCommits      int       `json:"lag_commits"`       // commits on source missing from target
+	LagSeconds      int64     `json:"lag_seconds"`       // age of the oldest missing commit
+	OldestPendingAt time.Time `json:"oldest_pending_at"` // commit time of the oldest missing commit
+
+	ThresholdCommits int    `json:"threshold_commits"`
+	ThresholdMinutes int    `json:"threshold_minutes"`
+	Exceeded         bool   `json:"exceeded"`
+	Reason           string `json:"reason,omitempty"`
+
+	Error     string    `json:"error,omitempty"`
+	CheckedAt time.Time `json:"checked_at"`
+}
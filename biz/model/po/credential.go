@@ -0,0 +1,62 @@
+package po
+
+import (
+	"strings"
+	"time"
+
+	"github.com/yi-nology/git-manage-service/biz/model/domain"
+	"github.com/yi-nology/git-manage-service/biz/utils"
+	"gorm.io/gorm"
+)
+
+// Credential is a named credential shared by all remotes whose URL matches one of its
+// patterns, unless a repo or remote configures its own auth.
+type Credential struct {
+	gorm.Model
+	Name       string     `gorm:"uniqueIndex" json:"name"`
+	Type       string     `json:"type"`     // http, ssh
+	Patterns   string     `json:"patterns"` // newline separated hosts (git.example.com) or URL prefixes (https://git.example.com/team/)
+	Username   string     `json:"username"` // http username, or SSH key path / sshkey:<id>
+	Secret     string     `json:"-"`        // token, password or key passphrase (Encrypted in DB)
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+}
+
+func (Credential) TableName() string {
+	return "credentials"
+}
+
+// PatternList returns the non-empty patterns
+func (c *Credential) PatternList() []string {
+	var list []string
+	for _, p := range strings.Split(c.Patterns, "\n") {
+		if p = strings.TrimSpace(p); p != "" {
+			list = append(list, p)
+		}
+	}
+	return list
+}
+
+func (c *Credential) Expired(now time.Time) bool {
+	return c.ExpiresAt != nil && !c.ExpiresAt.After(now)
+}
+
+func (c *Credential) AuthInfo() domain.AuthInfo {
+	return domain.AuthInfo{Type: c.Type, Key: c.Username, Secret: c.Secret}
+}
+
+func (c *Credential) BeforeSave(tx *gorm.DB) (err error) {
+	c.Secret, err = utils.Encrypt(c.Secret)
+	return err
+}
+
+// AfterSave restores the plain secret for callers still holding the struct
+func (c *Credential) AfterSave(tx *gorm.DB) (err error) {
+	c.Secret, err = utils.Decrypt(c.Secret)
+	return err
+}
+
+func (c *Credential) AfterFind(tx *gorm.DB) (err error) {
+	c.Secret, err = utils.Decrypt(c.Secret)
+	return err
+}
@@ -0,0 +1,24 @@
+package po
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// KnownHost is an SSH server host key. Only trusted keys are accepted when connecting;
+// pending keys were seen but wait for approval.
+type KnownHost struct {
+	gorm.Model
+	Host        string     `gorm:"uniqueIndex:idx_known_host_key" json:"host"` // "host" or "[host]:port"
+	KeyType     string     `json:"key_type"`
+	Fingerprint string     `gorm:"uniqueIndex:idx_known_host_key" json:"fingerprint"`
+	PublicKey   string     `gorm:"type:text" json:"public_key"` // authorized_keys format
+	Status      string     `gorm:"index" json:"status"`         // trusted, pending
+	Source      string     `json:"source"`                      // tofu, manual, scan
+	LastSeenAt  *time.Time `json:"last_seen_at"`
+}
+
+func (KnownHost) TableName() string {
+	return "known_hosts"
+}
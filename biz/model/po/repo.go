@@ -13,6 +13,7 @@ type Repo struct {
 	Key          string `gorm:"uniqueIndex" json:"key"`
 	Name         string `gorm:"uniqueIndex" json:"name"`
 	Path         string `json:"path"`
+	Group        string `gorm:"index" json:"group"` // free-form label for batch operations
 	RemoteURL    string `json:"remote_url"`
 	AuthType     string `json:"auth_type"`     // ssh, http, none
 	AuthKey      string `json:"auth_key"`      // SSH Key Path or Username
@@ -27,6 +28,15 @@ func (Repo) TableName() string {
 	return "repos"
 }
 
+// AuthForRemote returns the credentials configured for a remote, falling back to the
+// repository's default credentials
+func (r *Repo) AuthForRemote(remote string) domain.AuthInfo {
+	if auth, ok := r.RemoteAuths[remote]; ok {
+		return auth
+	}
+	return domain.AuthInfo{Type: r.AuthType, Key: r.AuthKey, Secret: r.AuthSecret}
+}
+
 func (r *Repo) BeforeSave(tx *gorm.DB) (err error) {
 	// Encrypt main secret
 	if r.AuthSecret != "" {
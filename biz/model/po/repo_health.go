@@ -0,0 +1,40 @@
+package po
+
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Repository health states
+const (
+	RepoHealthOK      = "ok"
+	RepoHealthCorrupt = "corrupt" // fsck found missing or broken objects
+	RepoHealthError   = "error"   // a maintenance task failed
+)
+
+// RepoHealth is the outcome of the latest maintenance of a repository
+type RepoHealth struct {
+	gorm.Model
+	RepoID       uint       `gorm:"uniqueIndex" json:"repo_id"`
+	Status       string     `json:"status"`
+	FsckAt       *time.Time `json:"fsck_at"`
+	FsckProblems string     `gorm:"type:text" json:"-"` // newline separated
+	LastTasks    string     `json:"last_tasks"`         // comma separated
+	LastError    string     `gorm:"type:text" json:"last_error"`
+	LastRunAt    *time.Time `json:"last_run_at"`
+	Duration     int64      `json:"duration"` // of the last run, in ms
+}
+
+func (RepoHealth) TableName() string {
+	return "repo_health"
+}
+
+// Problems returns the corruption reported by the latest fsck
+func (h *RepoHealth) Problems() []string {
+	if h.FsckProblems == "" {
+		return []string{}
+	}
+	return strings.Split(h.FsckProblems, "\n")
+}
@@ -0,0 +1,39 @@
+package po
+
+import (
+	"github.com/yi-nology/git-manage-service/biz/utils"
+	"gorm.io/gorm"
+)
+
+// SSHKey is a key pair managed by the service. The private key and its passphrase are
+// stored encrypted.
+type SSHKey struct {
+	gorm.Model
+	Name        string `gorm:"uniqueIndex" json:"name"`
+	Type        string `json:"type"` // ed25519, rsa, ecdsa
+	Bits        int    `json:"bits"`
+	Fingerprint string `gorm:"uniqueIndex" json:"fingerprint"`
+	PublicKey   string `gorm:"type:text" json:"public_key"` // authorized_keys line
+	PrivateKey  string `gorm:"type:text" json:"-"`          // PEM, as generated or imported
+	Passphrase  string `json:"-"`                           // of an imported protected key
+}
+
+func (SSHKey) TableName() string {
+	return "ssh_keys"
+}
+
+func (k *SSHKey) BeforeSave(tx *gorm.DB) (err error) {
+	if k.PrivateKey, err = utils.Encrypt(k.PrivateKey); err != nil {
+		return err
+	}
+	k.Passphrase, err = utils.Encrypt(k.Passphrase)
+	return err
+}
+
+func (k *SSHKey) AfterFind(tx *gorm.DB) (err error) {
+	if k.PrivateKey, err = utils.Decrypt(k.PrivateKey); err != nil {
+		return err
+	}
+	k.Passphrase, err = utils.Decrypt(k.Passphrase)
+	return err
+}
@@ -16,8 +16,16 @@ type SyncRun struct {
 	StartTime    time.Time `json:"start_time"`
 	EndTime      time.Time `json:"end_time"`
 
+	// Kind is "sync" for regular runs and "rollback" for runs that reverted an earlier one
+	Kind               string `gorm:"default:sync" json:"kind"`
+	PreviousTargetHash string `json:"previous_target_hash"` // target branch before the push, empty if it did not exist
+	PushedHash         string `json:"pushed_hash"`          // hash the target branch was moved to
+	RollbackOf         uint   `json:"rollback_of"`          // run reverted by this rollback run
+
 	// Associations
-	Task SyncTask `gorm:"foreignKey:TaskKey;references:Key" json:"task"`
+	Task    SyncTask        `gorm:"foreignKey:TaskKey;references:Key" json:"task"`
+	Steps   []SyncRunStep   `gorm:"foreignKey:RunID" json:"steps,omitempty"`
+	Commits []SyncRunCommit `gorm:"foreignKey:RunID" json:"commits,omitempty"`
 }
 
 func (SyncRun) TableName() string {
@@ -0,0 +1,39 @@
+package po
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SyncRunStep records one phase of a sync run (fetch_source, fetch_target, ancestry_check, hooks, push)
+type SyncRunStep struct {
+	gorm.Model
+	RunID      uint      `gorm:"index" json:"run_id"`
+	Seq        int       `json:"seq"`
+	Name       string    `json:"name"`
+	Status     string    `json:"status"` // success, failed, skipped
+	Message    string    `json:"message" gorm:"type:text"`
+	StartTime  time.Time `json:"start_time"`
+	EndTime    time.Time `json:"end_time"`
+	DurationMs int64     `json:"duration_ms"`
+}
+
+func (SyncRunStep) TableName() string {
+	return "sync_run_steps"
+}
+
+// SyncRunCommit is a commit that was pushed by a sync run
+type SyncRunCommit struct {
+	gorm.Model
+	RunID       uint      `gorm:"index" json:"run_id"`
+	Hash        string    `gorm:"type:varchar(64)" json:"hash"`
+	AuthorName  string    `json:"author_name"`
+	AuthorEmail string    `json:"author_email"`
+	Subject     string    `json:"subject"`
+	CommitTime  time.Time `json:"commit_time"`
+}
+
+func (SyncRunCommit) TableName() string {
+	return "sync_run_commits"
+}
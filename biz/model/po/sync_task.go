@@ -18,6 +18,23 @@ type SyncTask struct {
 	Cron          string `json:"cron"`         // e.g. "0 2 * * *"
 	Enabled       bool   `json:"enabled"`
 
+	// Lag alert thresholds, 0 falls back to the monitor defaults
+	LagThresholdCommits int `json:"lag_threshold_commits"`
+	LagThresholdMinutes int `json:"lag_threshold_minutes"`
+
+	// Git LFS: transfer objects referenced by pushed pointers to the target's LFS server
+	SyncLFS      bool   `json:"sync_lfs"`
+	LFSTargetURL string `json:"lfs_target_url"` // overrides the endpoint derived from the target remote
+
+	// Refuse to push commits that are not signed by a trusted key
+	VerifySignatures bool `json:"verify_signatures"`
+
+	// Bundle drop directories replace the git remote as transport (air-gapped setups):
+	// the source imports the bundles found in SourceBundleDir instead of fetching,
+	// the target writes an incremental bundle to TargetBundleDir instead of pushing.
+	SourceBundleDir string `json:"source_bundle_dir"`
+	TargetBundleDir string `json:"target_bundle_dir"`
+
 	// Associations
 	SourceRepo Repo `gorm:"foreignKey:SourceRepoKey;references:Key" json:"source_repo"`
 	TargetRepo Repo `gorm:"foreignKey:TargetRepoKey;references:Key" json:"target_repo"`
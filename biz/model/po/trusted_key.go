@@ -0,0 +1,17 @@
+package po
+
+import "gorm.io/gorm"
+
+// TrustedKey is a public key of the managed signing keyring
+type TrustedKey struct {
+	gorm.Model
+	Name        string `json:"name"`
+	Type        string `gorm:"index" json:"type"` // gpg, ssh
+	Fingerprint string `gorm:"uniqueIndex" json:"fingerprint"`
+	Identity    string `json:"identity"`           // GPG user ids or SSH key comment
+	KeyData     string `gorm:"type:text" json:"-"` // armored GPG public key or authorized_keys line
+}
+
+func (TrustedKey) TableName() string {
+	return "trusted_keys"
+}
@@ -0,0 +1,42 @@
+// Code generated by hertz generator.
+
+package batch
+
+import (
+	"github.com/cloudwego/hertz/pkg/app"
+)
+
+func rootMw() []app.HandlerFunc {
+	// your code...
+	return nil
+}
+
+func _apiMw() []app.HandlerFunc {
+	// your code...
+	return nil
+}
+
+func _v1Mw() []app.HandlerFunc {
+	// your code...
+	return nil
+}
+
+func _batchMw() []app.HandlerFunc {
+	// your code...
+	return nil
+}
+
+func _getjobMw() []app.HandlerFunc {
+	// your code...
+	return nil
+}
+
+func _listjobsMw() []app.HandlerFunc {
+	// your code...
+	return nil
+}
+
+func _submitMw() []app.HandlerFunc {
+	// your code...
+	return nil
+}
@@ -23,7 +23,10 @@ func Register(r *server.Hertz) {
 			_v1 := _api.Group("/v1", _v1Mw()...)
 			{
 				_branch := _v1.Group("/branch", _branchMw()...)
+				_branch.GET("/analyze", append(_analyzeMw(), branch.Analyze)...)
 				_branch.POST("/checkout", append(_checkoutMw(), branch.Checkout)...)
+				_branch.POST("/cherry-pick", append(_cherrypickMw(), branch.CherryPick)...)
+				_branch.POST("/cleanup", append(_cleanupMw(), branch.Cleanup)...)
 				_branch.GET("/compare", append(_compareMw(), branch.Compare)...)
 				_branch.POST("/create", append(_createMw(), branch.Create)...)
 				_branch.POST("/delete", append(_deleteMw(), branch.Delete)...)
@@ -35,6 +38,7 @@ func Register(r *server.Hertz) {
 				_branch.GET("/patch", append(_getpatchMw(), branch.GetPatch)...)
 				_branch.POST("/pull", append(_pullMw(), branch.Pull)...)
 				_branch.POST("/push", append(_pushMw(), branch.Push)...)
+				_branch.POST("/revert", append(_revertMw(), branch.Revert)...)
 				_branch.POST("/update", append(_updateMw(), branch.Update)...)
 			}
 		}
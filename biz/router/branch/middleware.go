@@ -31,6 +31,11 @@ func _checkoutMw() []app.HandlerFunc {
 	return nil
 }
 
+func _cherrypickMw() []app.HandlerFunc {
+	// your code...
+	return nil
+}
+
 func _compareMw() []app.HandlerFunc {
 	// your code...
 	return nil
@@ -86,7 +91,22 @@ func _pushMw() []app.HandlerFunc {
 	return nil
 }
 
+func _revertMw() []app.HandlerFunc {
+	// your code...
+	return nil
+}
+
 func _updateMw() []app.HandlerFunc {
 	// your code...
 	return nil
 }
+
+func _analyzeMw() []app.HandlerFunc {
+	// your code...
+	return nil
+}
+
+func _cleanupMw() []app.HandlerFunc {
+	// your code...
+	return nil
+}
@@ -6,8 +6,10 @@ import (
 	"github.com/cloudwego/hertz/pkg/app"
 	"github.com/cloudwego/hertz/pkg/app/server"
 	"github.com/yi-nology/git-manage-service/biz/router/audit"
+	"github.com/yi-nology/git-manage-service/biz/router/batch"
 	"github.com/yi-nology/git-manage-service/biz/router/branch"
 	"github.com/yi-nology/git-manage-service/biz/router/repo"
+	"github.com/yi-nology/git-manage-service/biz/router/search"
 	"github.com/yi-nology/git-manage-service/biz/router/stats"
 	"github.com/yi-nology/git-manage-service/biz/router/sync"
 	"github.com/yi-nology/git-manage-service/biz/router/system"
@@ -26,6 +28,8 @@ func GeneratedRegister(h *server.Hertz) {
 	sync.Register(h)
 	stats.Register(h)
 	audit.Register(h)
+	search.Register(h)
+	batch.Register(h)
 
 	// 静态资源
 	h.StaticFile("/docs/swagger.json", "./docs/swagger.json")
@@ -70,3 +70,78 @@ func _updateMw() []app.HandlerFunc {
 	// your code...
 	return nil
 }
+
+func _getblobMw() []app.HandlerFunc {
+	// your code...
+	return nil
+}
+
+func _getfilehistoryMw() []app.HandlerFunc {
+	// your code...
+	return nil
+}
+
+func _getrawfileMw() []app.HandlerFunc {
+	// your code...
+	return nil
+}
+
+func _gettreeMw() []app.HandlerFunc {
+	// your code...
+	return nil
+}
+
+func _getblameMw() []app.HandlerFunc {
+	// your code...
+	return nil
+}
+
+func _getarchiveMw() []app.HandlerFunc {
+	// your code...
+	return nil
+}
+
+func _bundleMw() []app.HandlerFunc {
+	// your code...
+	return nil
+}
+
+func _exportbundleMw() []app.HandlerFunc {
+	// your code...
+	return nil
+}
+
+func _importbundleMw() []app.HandlerFunc {
+	// your code...
+	return nil
+}
+
+func _listhealthMw() []app.HandlerFunc {
+	// your code...
+	return nil
+}
+
+func _getmaintenanceMw() []app.HandlerFunc {
+	// your code...
+	return nil
+}
+
+func _maintenanceMw() []app.HandlerFunc {
+	// your code...
+	return nil
+}
+
+func _runmaintenanceMw() []app.HandlerFunc {
+	// your code...
+	return nil
+}
+
+func _getremoterefsMw() []app.HandlerFunc {
+	// your code...
+	return nil
+}
+
+func _getcommitdetailMw() []app.HandlerFunc {
+	// your code...
+	return nil
+}
@@ -23,15 +23,34 @@ func Register(r *server.Hertz) {
 			_v1 := _api.Group("/v1", _v1Mw()...)
 			{
 				_repo := _v1.Group("/repo", _repoMw()...)
+				_repo.GET("/archive", append(_getarchiveMw(), repo.GetArchive)...)
+				_repo.GET("/blame", append(_getblameMw(), repo.GetBlame)...)
+				_repo.GET("/blob", append(_getblobMw(), repo.GetBlob)...)
 				_repo.POST("/clone", append(_cloneMw(), repo.Clone)...)
+				_repo.GET("/commit", append(_getcommitdetailMw(), repo.GetCommitDetail)...)
 				_repo.POST("/create", append(_createMw(), repo.Create)...)
 				_repo.POST("/delete", append(_deleteMw(), repo.Delete)...)
 				_repo.GET("/detail", append(_getMw(), repo.Get)...)
 				_repo.POST("/fetch", append(_fetchMw(), repo.Fetch)...)
+				_repo.GET("/file-history", append(_getfilehistoryMw(), repo.GetFileHistory)...)
+				_repo.GET("/health", append(_listhealthMw(), repo.ListHealth)...)
 				_repo.GET("/list", append(_listMw(), repo.List)...)
+				_repo.GET("/maintenance", append(_getmaintenanceMw(), repo.GetMaintenance)...)
+				{
+					_maintenance := _repo.Group("/maintenance", _maintenanceMw()...)
+					_maintenance.POST("/run", append(_runmaintenanceMw(), repo.RunMaintenance)...)
+				}
+				_repo.GET("/raw", append(_getrawfileMw(), repo.GetRawFile)...)
+				_repo.GET("/remote-refs", append(_getremoterefsMw(), repo.GetRemoteRefs)...)
 				_repo.POST("/scan", append(_scanMw(), repo.Scan)...)
 				_repo.GET("/task", append(_getclonetaskMw(), repo.GetCloneTask)...)
+				_repo.GET("/tree", append(_gettreeMw(), repo.GetTree)...)
 				_repo.POST("/update", append(_updateMw(), repo.Update)...)
+				{
+					_bundle := _repo.Group("/bundle", _bundleMw()...)
+					_bundle.GET("/export", append(_exportbundleMw(), repo.ExportBundle)...)
+					_bundle.POST("/import", append(_importbundleMw(), repo.ImportBundle)...)
+				}
 			}
 		}
 	}
@@ -0,0 +1,37 @@
+// Code generated by hertz generator.
+
+package search
+
+import (
+	"github.com/cloudwego/hertz/pkg/app"
+)
+
+func rootMw() []app.HandlerFunc {
+	// your code...
+	return nil
+}
+
+func _apiMw() []app.HandlerFunc {
+	// your code...
+	return nil
+}
+
+func _v1Mw() []app.HandlerFunc {
+	// your code...
+	return nil
+}
+
+func _searchMw() []app.HandlerFunc {
+	// your code...
+	return nil
+}
+
+func _searchcodeMw() []app.HandlerFunc {
+	// your code...
+	return nil
+}
+
+func _searchcommitsMw() []app.HandlerFunc {
+	// your code...
+	return nil
+}
@@ -46,6 +46,11 @@ func _listcommitsMw() []app.HandlerFunc {
 	return nil
 }
 
+func _getcommitgraphMw() []app.HandlerFunc {
+	// your code...
+	return nil
+}
+
 func _exportMw() []app.HandlerFunc {
 	// your code...
 	return nil
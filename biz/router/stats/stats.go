@@ -27,6 +27,7 @@ func Register(r *server.Hertz) {
 				_stats.GET("/authors", append(_listauthorsMw(), stats.ListAuthors)...)
 				_stats.GET("/branches", append(_listbranchesMw(), stats.ListBranches)...)
 				_stats.GET("/commits", append(_listcommitsMw(), stats.ListCommits)...)
+				_stats.GET("/graph", append(_getcommitgraphMw(), stats.GetCommitGraph)...)
 				{
 					_export := _stats.Group("/export", _exportMw()...)
 					_export.GET("/csv", append(_exportcsvMw(), stats.ExportCSV)...)
@@ -41,11 +41,31 @@ func _listhistoryMw() []app.HandlerFunc {
 	return nil
 }
 
+func _gethistoryMw() []app.HandlerFunc {
+	// your code...
+	return nil
+}
+
 func _deletehistoryMw() []app.HandlerFunc {
 	// your code...
 	return nil
 }
 
+func _lagMw() []app.HandlerFunc {
+	// your code...
+	return nil
+}
+
+func _getlagMw() []app.HandlerFunc {
+	// your code...
+	return nil
+}
+
+func _checklagMw() []app.HandlerFunc {
+	// your code...
+	return nil
+}
+
 func _runtaskMw() []app.HandlerFunc {
 	// your code...
 	return nil
@@ -80,3 +100,8 @@ func _listtasksMw() []app.HandlerFunc {
 	// your code...
 	return nil
 }
+
+func _rollbackhistoryMw() []app.HandlerFunc {
+	// your code...
+	return nil
+}
@@ -27,6 +27,11 @@ func Register(r *server.Hertz) {
 				_sync.GET("/history", append(_listhistoryMw(), sync.ListHistory)...)
 				_history := _sync.Group("/history", _historyMw()...)
 				_history.POST("/delete", append(_deletehistoryMw(), sync.DeleteHistory)...)
+				_history.GET("/detail", append(_gethistoryMw(), sync.GetHistory)...)
+				_history.POST("/rollback", append(_rollbackhistoryMw(), sync.RollbackHistory)...)
+				_sync.GET("/lag", append(_getlagMw(), sync.GetLag)...)
+				_lag := _sync.Group("/lag", _lagMw()...)
+				_lag.POST("/check", append(_checklagMw(), sync.CheckLag)...)
 				_sync.POST("/run", append(_runtaskMw(), sync.RunTask)...)
 				_sync.GET("/task", append(_gettaskMw(), sync.GetTask)...)
 				_task := _sync.Group("/task", _taskMw()...)
@@ -70,3 +70,103 @@ func _submitchangesMw() []app.HandlerFunc {
 	// your code...
 	return nil
 }
+
+func _listtrustedkeysMw() []app.HandlerFunc {
+	// your code...
+	return nil
+}
+
+func _trusted_keysMw() []app.HandlerFunc {
+	// your code...
+	return nil
+}
+
+func _createtrustedkeyMw() []app.HandlerFunc {
+	// your code...
+	return nil
+}
+
+func _deletetrustedkeyMw() []app.HandlerFunc {
+	// your code...
+	return nil
+}
+
+func _listknownhostsMw() []app.HandlerFunc {
+	// your code...
+	return nil
+}
+
+func _known_hostsMw() []app.HandlerFunc {
+	// your code...
+	return nil
+}
+
+func _approveknownhostMw() []app.HandlerFunc {
+	// your code...
+	return nil
+}
+
+func _createknownhostMw() []app.HandlerFunc {
+	// your code...
+	return nil
+}
+
+func _deleteknownhostMw() []app.HandlerFunc {
+	// your code...
+	return nil
+}
+
+func _ssh_keysMw() []app.HandlerFunc {
+	// your code...
+	return nil
+}
+
+func _deletesshkeyMw() []app.HandlerFunc {
+	// your code...
+	return nil
+}
+
+func _generatesshkeyMw() []app.HandlerFunc {
+	// your code...
+	return nil
+}
+
+func _importsshkeyMw() []app.HandlerFunc {
+	// your code...
+	return nil
+}
+
+func _listmanagedsshkeysMw() []app.HandlerFunc {
+	// your code...
+	return nil
+}
+
+func _listcredentialsMw() []app.HandlerFunc {
+	// your code...
+	return nil
+}
+
+func _credentialsMw() []app.HandlerFunc {
+	// your code...
+	return nil
+}
+
+func _createcredentialMw() []app.HandlerFunc {
+	// your code...
+	return nil
+}
+
+func _deletecredentialMw() []app.HandlerFunc {
+	// your code...
+	return nil
+}
+
+func _updatecredentialMw() []app.HandlerFunc {
+	// your code...
+	return nil
+}
+
+func _lsremoteMw() []app.HandlerFunc {
+	// your code...
+	return nil
+}
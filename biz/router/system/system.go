@@ -25,9 +25,37 @@ func Register(r *server.Hertz) {
 				_system := _v1.Group("/system", _systemMw()...)
 				_system.GET("/config", append(_getconfigMw(), system.GetConfig)...)
 				_system.POST("/config", append(_updateconfigMw(), system.UpdateConfig)...)
+				_system.GET("/credentials", append(_listcredentialsMw(), system.ListCredentials)...)
+				{
+					_credentials := _system.Group("/credentials", _credentialsMw()...)
+					_credentials.POST("/create", append(_createcredentialMw(), system.CreateCredential)...)
+					_credentials.POST("/delete", append(_deletecredentialMw(), system.DeleteCredential)...)
+					_credentials.POST("/update", append(_updatecredentialMw(), system.UpdateCredential)...)
+				}
 				_system.GET("/dirs", append(_listdirsMw(), system.ListDirs)...)
+				_system.GET("/known-hosts", append(_listknownhostsMw(), system.ListKnownHosts)...)
+				{
+					_known_hosts := _system.Group("/known-hosts", _known_hostsMw()...)
+					_known_hosts.POST("/approve", append(_approveknownhostMw(), system.ApproveKnownHost)...)
+					_known_hosts.POST("/create", append(_createknownhostMw(), system.CreateKnownHost)...)
+					_known_hosts.POST("/delete", append(_deleteknownhostMw(), system.DeleteKnownHost)...)
+				}
+				_system.POST("/ls-remote", append(_lsremoteMw(), system.LsRemote)...)
 				_system.GET("/ssh-keys", append(_listsshkeysMw(), system.ListSSHKeys)...)
+				{
+					_ssh_keys := _system.Group("/ssh-keys", _ssh_keysMw()...)
+					_ssh_keys.POST("/delete", append(_deletesshkeyMw(), system.DeleteSSHKey)...)
+					_ssh_keys.POST("/generate", append(_generatesshkeyMw(), system.GenerateSSHKey)...)
+					_ssh_keys.POST("/import", append(_importsshkeyMw(), system.ImportSSHKey)...)
+					_ssh_keys.GET("/managed", append(_listmanagedsshkeysMw(), system.ListManagedSSHKeys)...)
+				}
 				_system.POST("/test-connection", append(_testconnectionMw(), system.TestConnection)...)
+				_system.GET("/trusted-keys", append(_listtrustedkeysMw(), system.ListTrustedKeys)...)
+				{
+					_trusted_keys := _system.Group("/trusted-keys", _trusted_keysMw()...)
+					_trusted_keys.POST("/create", append(_createtrustedkeyMw(), system.CreateTrustedKey)...)
+					_trusted_keys.POST("/delete", append(_deletetrustedkeyMw(), system.DeleteTrustedKey)...)
+				}
 				{
 					_repo := _system.Group("/repo", _repoMw()...)
 					_repo.GET("/git-config", append(_getrepogitconfigMw(), system.GetRepoGitConfig)...)
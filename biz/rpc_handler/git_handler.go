@@ -76,7 +76,7 @@ func (s *GitServiceImpl) ListBranches(ctx context.Context, req *git.ListBranches
 		return nil, fmt.Errorf("repo not found")
 	}
 
-	svc := gitSvc.NewGitService()
+	svc := gitSvc.NewGitService().WithContext(ctx)
 	branches, err := svc.ListBranchesWithInfo(r.Path)
 	if err != nil {
 		return nil, err
@@ -106,7 +106,7 @@ func (s *GitServiceImpl) CreateBranch(ctx context.Context, req *git.CreateBranch
 		return &git.CreateBranchResponse{Success: false, Message: "repo not found"}, nil
 	}
 
-	svc := gitSvc.NewGitService()
+	svc := gitSvc.NewGitService().WithContext(ctx)
 	if err := svc.CreateBranch(r.Path, req.BranchName, req.Ref); err != nil {
 		return &git.CreateBranchResponse{Success: false, Message: err.Error()}, nil
 	}
@@ -121,7 +121,7 @@ func (s *GitServiceImpl) DeleteBranch(ctx context.Context, req *git.DeleteBranch
 		return &git.DeleteBranchResponse{Success: false, Message: "repo not found"}, nil
 	}
 
-	svc := gitSvc.NewGitService()
+	svc := gitSvc.NewGitService().WithContext(ctx)
 	if err := svc.DeleteBranch(r.Path, req.BranchName, req.Force); err != nil {
 		return &git.DeleteBranchResponse{Success: false, Message: err.Error()}, nil
 	}
@@ -0,0 +1,353 @@
+package batch
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/yi-nology/git-manage-service/biz/dal/db"
+	"github.com/yi-nology/git-manage-service/biz/model/po"
+	"github.com/yi-nology/git-manage-service/biz/service/git"
+)
+
+const (
+	OpCreateBranch = "create_branch"
+	OpCreateTag    = "create_tag"
+	OpPush         = "push"
+	OpDeleteBranch = "delete_branch"
+)
+
+const (
+	JobRunning = "running"
+	JobSuccess = "success"
+	JobPartial = "partial" // some repositories failed
+	JobFailed  = "failed"
+)
+
+const (
+	defaultConcurrency = 4
+	maxConcurrency     = 16
+	// maxJobs bounds the in-memory job history; the oldest finished jobs are dropped first
+	maxJobs = 100
+)
+
+var (
+	ErrInvalidJob   = errors.New("invalid batch job")
+	ErrRepoNotFound = errors.New("repo not found")
+)
+
+// Selection picks the target repositories: explicit keys, a group, or all of them
+type Selection struct {
+	RepoKeys []string `json:"repo_keys,omitempty"`
+	Group    string   `json:"group,omitempty"`
+	All      bool     `json:"all,omitempty"`
+}
+
+// Operation is applied unchanged to every selected repository
+type Operation struct {
+	Type    string `json:"type"`
+	Branch  string `json:"branch,omitempty"`  // create_branch, delete_branch, push
+	Tag     string `json:"tag,omitempty"`     // create_tag, push
+	Ref     string `json:"ref,omitempty"`     // start point for create_*, default HEAD
+	Message string `json:"message,omitempty"` // annotated tag message
+	Remote  string `json:"remote,omitempty"`  // push, default origin
+	Force   bool   `json:"force,omitempty"`   // delete_branch: also delete branches not merged into HEAD
+
+	AuthorName  string `json:"author_name,omitempty"`
+	AuthorEmail string `json:"author_email,omitempty"`
+}
+
+type RepoResult struct {
+	RepoKey  string `json:"repo_key"`
+	RepoName string `json:"repo_name"`
+	Status   string `json:"status"` // pending, running, success, failed
+	Hash     string `json:"hash,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+type Job struct {
+	ID         string       `json:"id"`
+	Operation  Operation    `json:"operation"`
+	Status     string       `json:"status"`
+	Total      int          `json:"total"`
+	Done       int          `json:"done"`
+	Failed     int          `json:"failed"`
+	Results    []RepoResult `json:"results"`
+	CreatedAt  time.Time    `json:"created_at"`
+	FinishedAt *time.Time   `json:"finished_at,omitempty"`
+}
+
+type BatchService struct {
+	git     *git.GitService
+	repoDAO *db.RepoDAO
+	// apply runs the operation in one repository, replaceable in tests
+	apply func(repo *po.Repo, op Operation) (string, error)
+
+	mu   sync.Mutex
+	jobs map[string]*Job
+}
+
+var BatchSvc *BatchService
+
+func InitBatchService() {
+	BatchSvc = newBatchService()
+}
+
+func newBatchService() *BatchService {
+	s := &BatchService{
+		git:     git.NewGitService(),
+		repoDAO: db.NewRepoDAO(),
+		jobs:    make(map[string]*Job),
+	}
+	s.apply = s.applyOp
+	return s
+}
+
+// Submit validates the operation, resolves the repositories and starts the job in the
+// background. At most concurrency repositories are processed at the same time.
+func (s *BatchService) Submit(sel Selection, op Operation, concurrency int) (*Job, error) {
+	if err := validate(&op); err != nil {
+		return nil, err
+	}
+	repos, err := s.selectRepos(sel)
+	if err != nil {
+		return nil, err
+	}
+	if len(repos) == 0 {
+		return nil, fmt.Errorf("%w: no repositories selected", ErrInvalidJob)
+	}
+	if concurrency <= 0 {
+		concurrency = defaultConcurrency
+	}
+	concurrency = min(concurrency, maxConcurrency)
+
+	job := &Job{
+		ID:        uuid.New().String(),
+		Operation: op,
+		Status:    JobRunning,
+		Total:     len(repos),
+		Results:   make([]RepoResult, len(repos)),
+		CreatedAt: time.Now(),
+	}
+	for i, repo := range repos {
+		job.Results[i] = RepoResult{RepoKey: repo.Key, RepoName: repo.Name, Status: "pending"}
+	}
+
+	s.mu.Lock()
+	s.pruneLocked()
+	s.jobs[job.ID] = job
+	snapshot := s.snapshotLocked(job)
+	s.mu.Unlock()
+
+	go s.run(job, repos, concurrency)
+	return snapshot, nil
+}
+
+// GetJob returns a copy of the job's current state
+func (s *BatchService) GetJob(id string) (*Job, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.jobs[id]
+	if !ok {
+		return nil, false
+	}
+	return s.snapshotLocked(job), true
+}
+
+// ListJobs returns the known jobs, newest first, without per-repository results
+func (s *BatchService) ListJobs() []Job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	list := make([]Job, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		j := *job
+		j.Results = nil
+		list = append(list, j)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].CreatedAt.After(list[j].CreatedAt) })
+	return list
+}
+
+func (s *BatchService) snapshotLocked(job *Job) *Job {
+	j := *job
+	j.Results = append([]RepoResult(nil), job.Results...)
+	return &j
+}
+
+func (s *BatchService) pruneLocked() {
+	if len(s.jobs) < maxJobs {
+		return
+	}
+	var finished []*Job
+	for _, job := range s.jobs {
+		if job.FinishedAt != nil {
+			finished = append(finished, job)
+		}
+	}
+	sort.Slice(finished, func(i, j int) bool { return finished[i].CreatedAt.Before(finished[j].CreatedAt) })
+	for i := 0; i < len(finished) && len(s.jobs) >= maxJobs; i++ {
+		delete(s.jobs, finished[i].ID)
+	}
+}
+
+func (s *BatchService) run(job *Job, repos []po.Repo, concurrency int) {
+	slots := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i := range repos {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			s.setResult(job, i, func(r *RepoResult) { r.Status = "running" })
+			hash, err := s.apply(&repos[i], job.Operation)
+			s.setResult(job, i, func(r *RepoResult) {
+				r.Hash = hash
+				if err != nil {
+					r.Status, r.Error = "failed", err.Error()
+					job.Failed++
+				} else {
+					r.Status = "success"
+				}
+				job.Done++
+			})
+		}(i)
+	}
+	wg.Wait()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	job.FinishedAt = &now
+	switch job.Failed {
+	case 0:
+		job.Status = JobSuccess
+	case job.Total:
+		job.Status = JobFailed
+	default:
+		job.Status = JobPartial
+	}
+}
+
+func (s *BatchService) setResult(job *Job, i int, fn func(*RepoResult)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fn(&job.Results[i])
+}
+
+// applyOp runs the operation in one repository and returns the commit it points at
+func (s *BatchService) applyOp(repo *po.Repo, op Operation) (string, error) {
+	ref := op.Ref
+	if ref == "" {
+		ref = "HEAD"
+	}
+	switch op.Type {
+	case OpCreateBranch:
+		if _, err := s.git.ResolveRevision(repo.Path, "refs/heads/"+op.Branch); err == nil {
+			return "", fmt.Errorf("branch %s already exists", op.Branch)
+		}
+		hash, err := s.git.ResolveRevision(repo.Path, ref)
+		if err != nil {
+			return "", fmt.Errorf("resolve %s failed: %v", ref, err)
+		}
+		return hash, s.git.CreateBranch(repo.Path, op.Branch, hash)
+
+	case OpCreateTag:
+		if err := s.git.CreateTag(repo.Path, op.Tag, ref, op.Message, op.AuthorName, op.AuthorEmail); err != nil {
+			return "", err
+		}
+		return s.git.ResolveRevision(repo.Path, "refs/tags/"+op.Tag+"^{commit}")
+
+	case OpPush:
+		if op.Tag != "" {
+			auth := repo.AuthForRemote(op.Remote)
+			if err := s.git.PushTag(repo.Path, op.Remote, op.Tag, auth.Type, auth.Key, auth.Secret); err != nil {
+				return "", err
+			}
+			return s.git.ResolveRevision(repo.Path, "refs/tags/"+op.Tag+"^{commit}")
+		}
+		hash, err := s.git.ResolveRevision(repo.Path, "refs/heads/"+op.Branch)
+		if err != nil {
+			return "", fmt.Errorf("branch %s not found", op.Branch)
+		}
+		auth := repo.AuthForRemote(op.Remote)
+		return hash, s.git.PushBranchWithAuth(repo.Path, op.Remote, op.Branch, auth.Type, auth.Key, auth.Secret)
+
+	case OpDeleteBranch:
+		hash, err := s.git.ResolveRevision(repo.Path, "refs/heads/"+op.Branch)
+		if err != nil {
+			return "", fmt.Errorf("branch %s not found", op.Branch)
+		}
+		if head, err := s.git.GetHeadBranch(repo.Path); err == nil && head == op.Branch {
+			return "", fmt.Errorf("branch %s is checked out", op.Branch)
+		}
+		if !op.Force {
+			// Like `git branch -d`: refuse to lose commits that HEAD does not have
+			head, err := s.git.ResolveRevision(repo.Path, "HEAD")
+			if err != nil {
+				return "", fmt.Errorf("resolve HEAD failed: %v", err)
+			}
+			merged, err := s.git.IsAncestor(repo.Path, hash, head)
+			if err != nil {
+				return "", err
+			}
+			if !merged {
+				return "", fmt.Errorf("branch %s is not fully merged into HEAD, set force to delete it", op.Branch)
+			}
+		}
+		return hash, s.git.DeleteBranch(repo.Path, op.Branch, op.Force)
+	}
+	return "", fmt.Errorf("%w: unknown operation %s", ErrInvalidJob, op.Type)
+}
+
+func validate(op *Operation) error {
+	validName := func(kind, name string) error {
+		if name == "" || strings.HasPrefix(name, "-") || strings.ContainsAny(name, " ~^:?*[\\") || strings.Contains(name, "..") {
+			return fmt.Errorf("%w: invalid %s name %q", ErrInvalidJob, kind, name)
+		}
+		return nil
+	}
+	switch op.Type {
+	case OpCreateBranch, OpDeleteBranch:
+		return validName("branch", op.Branch)
+	case OpCreateTag:
+		return validName("tag", op.Tag)
+	case OpPush:
+		if op.Remote == "" {
+			op.Remote = "origin"
+		}
+		if (op.Branch == "") == (op.Tag == "") {
+			return fmt.Errorf("%w: push needs exactly one of branch or tag", ErrInvalidJob)
+		}
+		if op.Tag != "" {
+			return validName("tag", op.Tag)
+		}
+		return validName("branch", op.Branch)
+	}
+	return fmt.Errorf("%w: unknown operation %q", ErrInvalidJob, op.Type)
+}
+
+func (s *BatchService) selectRepos(sel Selection) ([]po.Repo, error) {
+	switch {
+	case len(sel.RepoKeys) > 0:
+		repos := make([]po.Repo, 0, len(sel.RepoKeys))
+		for _, key := range sel.RepoKeys {
+			repo, err := s.repoDAO.FindByKey(key)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %s", ErrRepoNotFound, key)
+			}
+			repos = append(repos, *repo)
+		}
+		return repos, nil
+	case sel.Group != "":
+		return s.repoDAO.FindByGroup(sel.Group)
+	case sel.All:
+		return s.repoDAO.FindAll()
+	}
+	return nil, fmt.Errorf("%w: select repo_keys, a group or all", ErrInvalidJob)
+}
@@ -0,0 +1,222 @@
+package batch
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"github.com/yi-nology/git-manage-service/biz/dal/db"
+	"github.com/yi-nology/git-manage-service/biz/model/po"
+	"github.com/yi-nology/git-manage-service/biz/service/git/gittest"
+)
+
+func newTestService(t *testing.T) *BatchService {
+	var err error
+	db.DB, err = gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.DB.AutoMigrate(&po.Repo{}); err != nil {
+		t.Fatal(err)
+	}
+	return newBatchService()
+}
+
+// addRepo registers a repository with one commit on main
+func addRepo(t *testing.T, key, group string) po.Repo {
+	r := gittest.New(t)
+	r.Commit("init", "")
+	repo := po.Repo{Key: key, Name: key, Path: r.Dir, Group: group}
+	if err := db.NewRepoDAO().Create(&repo); err != nil {
+		t.Fatal(err)
+	}
+	return repo
+}
+
+func waitJob(t *testing.T, s *BatchService, id string) *Job {
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		if job, _ := s.GetJob(id); job.FinishedAt != nil {
+			return job
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("job %s did not finish", id)
+	return nil
+}
+
+func TestSelectRepos(t *testing.T) {
+	s := newTestService(t)
+	addRepo(t, "a", "web")
+	addRepo(t, "b", "web")
+	addRepo(t, "c", "infra")
+
+	keys := func(sel Selection) []string {
+		repos, err := s.selectRepos(sel)
+		if err != nil {
+			t.Fatalf("%+v: %v", sel, err)
+		}
+		var out []string
+		for _, r := range repos {
+			out = append(out, r.Key)
+		}
+		return out
+	}
+	if got := keys(Selection{RepoKeys: []string{"c", "a"}}); fmt.Sprint(got) != "[c a]" {
+		t.Errorf("by keys: %v", got)
+	}
+	if got := keys(Selection{Group: "web"}); len(got) != 2 {
+		t.Errorf("by group: %v", got)
+	}
+	if got := keys(Selection{All: true}); len(got) != 3 {
+		t.Errorf("all: %v", got)
+	}
+	if _, err := s.selectRepos(Selection{RepoKeys: []string{"a", "missing"}}); !errors.Is(err, ErrRepoNotFound) {
+		t.Errorf("unknown key: %v", err)
+	}
+	if _, err := s.selectRepos(Selection{}); !errors.Is(err, ErrInvalidJob) {
+		t.Errorf("empty selection: %v", err)
+	}
+	if _, err := s.Submit(Selection{Group: "none"}, Operation{Type: OpCreateBranch, Branch: "x"}, 0); !errors.Is(err, ErrInvalidJob) {
+		t.Errorf("empty group: %v", err)
+	}
+}
+
+func TestConcurrencyBound(t *testing.T) {
+	s := newTestService(t)
+	for i := 0; i < 8; i++ {
+		addRepo(t, fmt.Sprintf("r%d", i), "")
+	}
+
+	var running, peak int32
+	s.apply = func(repo *po.Repo, op Operation) (string, error) {
+		n := atomic.AddInt32(&running, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+		return "", nil
+	}
+
+	job, err := s.Submit(Selection{All: true}, Operation{Type: OpCreateBranch, Branch: "x"}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitJob(t, s, job.ID)
+	if peak != 2 {
+		t.Errorf("expected at most 2 repositories at a time, peak was %d", peak)
+	}
+}
+
+func TestJobLifecycle(t *testing.T) {
+	s := newTestService(t)
+	addRepo(t, "a", "")
+	addRepo(t, "b", "")
+
+	release := make(chan struct{})
+	s.apply = func(repo *po.Repo, op Operation) (string, error) {
+		<-release
+		if repo.Key == "b" {
+			return "", errors.New("boom")
+		}
+		return "abc", nil
+	}
+
+	job, err := s.Submit(Selection{All: true}, Operation{Type: OpCreateBranch, Branch: "x"}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.Status != JobRunning || job.FinishedAt != nil || job.Results[0].Status != "pending" {
+		t.Fatalf("submitted job: %+v", job)
+	}
+	// The first repository is picked up while the second waits for the single slot
+	for deadline := time.Now().Add(5 * time.Second); ; {
+		j, _ := s.GetJob(job.ID)
+		if j.Results[0].Status == "running" {
+			if j.Results[1].Status != "pending" {
+				t.Errorf("second repository should wait: %+v", j.Results[1])
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("first repository never started")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	close(release)
+
+	done := waitJob(t, s, job.ID)
+	if done.Status != JobPartial || done.Done != 2 || done.Failed != 1 {
+		t.Fatalf("finished job: %+v", done)
+	}
+	if r := done.Results[0]; r.Status != "success" || r.Hash != "abc" {
+		t.Errorf("a: %+v", r)
+	}
+	if r := done.Results[1]; r.Status != "failed" || r.Error != "boom" {
+		t.Errorf("b: %+v", r)
+	}
+	if list := s.ListJobs(); len(list) != 1 || list[0].Results != nil {
+		t.Errorf("job list: %+v", list)
+	}
+}
+
+func TestFailureIsolation(t *testing.T) {
+	s := newTestService(t)
+	good := addRepo(t, "good", "")
+	broken := addRepo(t, "broken", "")
+	os.RemoveAll(broken.Path)
+
+	job, err := s.Submit(Selection{All: true}, Operation{Type: OpCreateBranch, Branch: "feature"}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := waitJob(t, s, job.ID)
+	if done.Status != JobPartial {
+		t.Fatalf("expected partial, got %+v", done)
+	}
+	if _, err := s.git.ResolveRevision(good.Path, "refs/heads/feature"); err != nil {
+		t.Errorf("branch missing in the healthy repository: %v", err)
+	}
+
+	// Creating it again fails everywhere
+	job, _ = s.Submit(Selection{All: true}, Operation{Type: OpCreateBranch, Branch: "feature"}, 0)
+	if done := waitJob(t, s, job.ID); done.Status != JobFailed || done.Failed != 2 {
+		t.Errorf("expected failed, got %+v", done)
+	}
+}
+
+func TestDeleteUnmergedBranch(t *testing.T) {
+	s := newTestService(t)
+	repo := addRepo(t, "a", "")
+	gittest.Run(t, repo.Path, nil, "branch", "merged")
+	gittest.Run(t, repo.Path, nil, "checkout", "-q", "-b", "unmerged")
+	gittest.Run(t, repo.Path, nil, "commit", "-q", "--allow-empty", "-m", "wip")
+	gittest.Run(t, repo.Path, nil, "checkout", "-q", "main")
+
+	del := func(branch string, force bool) error {
+		_, err := s.applyOp(&repo, Operation{Type: OpDeleteBranch, Branch: branch, Force: force})
+		return err
+	}
+	if err := del("merged", false); err != nil {
+		t.Errorf("merged branch: %v", err)
+	}
+	if err := del("unmerged", false); err == nil {
+		t.Error("unmerged branch deleted without force")
+	}
+	if _, err := s.git.ResolveRevision(repo.Path, "refs/heads/unmerged"); err != nil {
+		t.Fatalf("unmerged branch lost: %v", err)
+	}
+	if err := del("unmerged", true); err != nil {
+		t.Errorf("forced delete: %v", err)
+	}
+}
@@ -0,0 +1,217 @@
+package credential
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+
+	"github.com/yi-nology/git-manage-service/biz/dal/db"
+	"github.com/yi-nology/git-manage-service/biz/model/domain"
+	"github.com/yi-nology/git-manage-service/biz/model/po"
+)
+
+const (
+	TypeHTTP = "http"
+	TypeSSH  = "ssh"
+)
+
+var (
+	ErrInvalidCredential  = errors.New("invalid credential")
+	ErrCredentialNotFound = errors.New("credential not found")
+	ErrCredentialExists   = errors.New("credential with the same name already exists")
+)
+
+type CredentialService struct {
+	dao *db.CredentialDAO
+}
+
+var CredentialSvc *CredentialService
+
+func InitCredentialService() {
+	CredentialSvc = &CredentialService{dao: db.NewCredentialDAO()}
+}
+
+// Create stores a new credential after validating it
+func (s *CredentialService) Create(cred *po.Credential) error {
+	if err := validate(cred); err != nil {
+		return err
+	}
+	if taken, err := s.dao.NameTaken(cred.Name, 0); err != nil {
+		return err
+	} else if taken {
+		return ErrCredentialExists
+	}
+	return s.dao.Create(cred)
+}
+
+// Update replaces the settings of a credential. An empty secret keeps the stored one,
+// so a credential can be edited without re-entering its token.
+func (s *CredentialService) Update(id uint, update po.Credential) (*po.Credential, error) {
+	cred, err := s.dao.FindByID(id)
+	if err != nil {
+		return nil, ErrCredentialNotFound
+	}
+	if taken, err := s.dao.NameTaken(update.Name, id); err != nil {
+		return nil, err
+	} else if taken {
+		return nil, ErrCredentialExists
+	}
+	cred.Name = update.Name
+	cred.Type = update.Type
+	cred.Patterns = update.Patterns
+	cred.Username = update.Username
+	cred.ExpiresAt = update.ExpiresAt
+	if update.Secret != "" {
+		cred.Secret = update.Secret
+	}
+	if err := validate(cred); err != nil {
+		return nil, err
+	}
+	if err := s.dao.Save(cred); err != nil {
+		return nil, err
+	}
+	return cred, nil
+}
+
+func (s *CredentialService) Delete(id uint) (*po.Credential, error) {
+	cred, err := s.dao.FindByID(id)
+	if err != nil {
+		return nil, ErrCredentialNotFound
+	}
+	if err := s.dao.Delete(cred); err != nil {
+		return nil, err
+	}
+	return cred, nil
+}
+
+// Resolve returns the auth of the most specific unexpired credential bound to the URL:
+// URL prefix patterns win over host patterns, longer patterns over shorter ones.
+func (s *CredentialService) Resolve(url string) (domain.AuthInfo, bool) {
+	ep, err := transport.NewEndpoint(url)
+	if err != nil {
+		return domain.AuthInfo{}, false
+	}
+	creds, err := s.dao.FindAll()
+	if err != nil {
+		log.Printf("Failed to load credentials: %v", err)
+		return domain.AuthInfo{}, false
+	}
+
+	now := time.Now()
+	var best *po.Credential
+	bestScore := 0
+	for i := range creds {
+		c := &creds[i]
+		if !protocolMatches(c.Type, ep.Protocol) {
+			continue
+		}
+		score := 0
+		for _, p := range c.PatternList() {
+			score = max(score, matchScore(p, ep))
+		}
+		if score == 0 {
+			continue
+		}
+		if c.Expired(now) {
+			log.Printf("Credential %s matches %s but expired at %s", c.Name, ep.Host, c.ExpiresAt.Format(time.RFC3339))
+			continue
+		}
+		if score > bestScore {
+			best, bestScore = c, score
+		}
+	}
+	if best == nil {
+		return domain.AuthInfo{}, false
+	}
+	if err := s.dao.TouchLastUsed(best.ID); err != nil {
+		log.Printf("Failed to update last use of credential %s: %v", best.Name, err)
+	}
+	return best.AuthInfo(), true
+}
+
+func protocolMatches(credType, protocol string) bool {
+	switch credType {
+	case TypeHTTP:
+		return protocol == "http" || protocol == "https"
+	case TypeSSH:
+		return protocol == "ssh"
+	}
+	return false
+}
+
+// matchScore rates how specific a pattern matches the endpoint, 0 means no match.
+// Patterns are a host ("git.example.com", "*.example.com") or a URL prefix
+// ("https://git.example.com/team/"), which must end at a path segment boundary.
+// Host patterns never match plain http, so that a token is not sent in the clear
+// unless an http:// URL prefix pattern opts in.
+func matchScore(pattern string, ep *transport.Endpoint) int {
+	if !strings.Contains(pattern, "://") {
+		if ep.Protocol == "http" {
+			return 0
+		}
+		host := strings.ToLower(ep.Host)
+		pattern = strings.ToLower(pattern)
+		if host == pattern || (strings.HasPrefix(pattern, "*.") && strings.HasSuffix(host, pattern[1:])) {
+			return len(pattern)
+		}
+		return 0
+	}
+
+	pep, err := transport.NewEndpoint(pattern)
+	if err != nil || !strings.EqualFold(pep.Host, ep.Host) {
+		return 0
+	}
+	if pep.Protocol != ep.Protocol || (pep.Port != 0 && pep.Port != ep.Port) {
+		return 0
+	}
+	prefix := strings.Trim(pep.Path, "/")
+	path := strings.Trim(ep.Path, "/")
+	if prefix != "" && path != prefix && !strings.HasPrefix(path, prefix+"/") {
+		return 0
+	}
+	// Any URL prefix is more specific than a host pattern
+	return 1000 + len(prefix)
+}
+
+func validate(c *po.Credential) error {
+	if c.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidCredential)
+	}
+	if c.Type != TypeHTTP && c.Type != TypeSSH {
+		return fmt.Errorf("%w: type must be http or ssh", ErrInvalidCredential)
+	}
+	if c.Username == "" {
+		if c.Type == TypeSSH {
+			return fmt.Errorf("%w: ssh key is required", ErrInvalidCredential)
+		}
+		return fmt.Errorf("%w: username is required", ErrInvalidCredential)
+	}
+	patterns := c.PatternList()
+	if len(patterns) == 0 {
+		return fmt.Errorf("%w: at least one host or URL pattern is required", ErrInvalidCredential)
+	}
+	for _, p := range patterns {
+		if strings.Contains(p, "://") {
+			ep, err := transport.NewEndpoint(p)
+			if err != nil || ep.Host == "" {
+				return fmt.Errorf("%w: invalid URL pattern %s", ErrInvalidCredential, p)
+			}
+			if !protocolMatches(c.Type, ep.Protocol) {
+				return fmt.Errorf("%w: pattern %s does not match type %s", ErrInvalidCredential, p, c.Type)
+			}
+		} else if strings.ContainsAny(p, "/ ") || strings.Contains(strings.TrimPrefix(p, "*."), "*") {
+			return fmt.Errorf("%w: invalid host pattern %s", ErrInvalidCredential, p)
+		}
+	}
+	c.Patterns = strings.Join(patterns, "\n")
+	return nil
+}
+
+// PatternsFromList joins patterns the way they are stored
+func PatternsFromList(patterns []string) string {
+	return strings.Join(patterns, "\n")
+}
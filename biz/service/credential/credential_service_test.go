@@ -0,0 +1,123 @@
+package credential
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"github.com/yi-nology/git-manage-service/biz/dal/db"
+	"github.com/yi-nology/git-manage-service/biz/model/po"
+	"github.com/yi-nology/git-manage-service/biz/utils"
+)
+
+func newTestService(t *testing.T) *CredentialService {
+	utils.InitEncryption()
+	var err error
+	db.DB, err = gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.DB.AutoMigrate(&po.Credential{}); err != nil {
+		t.Fatal(err)
+	}
+	return &CredentialService{dao: db.NewCredentialDAO()}
+}
+
+func create(t *testing.T, s *CredentialService, c po.Credential) *po.Credential {
+	if err := s.Create(&c); err != nil {
+		t.Fatalf("create %s: %v", c.Name, err)
+	}
+	return &c
+}
+
+func TestResolve(t *testing.T) {
+	s := newTestService(t)
+	create(t, s, po.Credential{Name: "host", Type: TypeHTTP, Patterns: "git.example.com", Username: "bot", Secret: "host-token"})
+	create(t, s, po.Credential{Name: "team", Type: TypeHTTP, Patterns: "https://git.example.com/team", Username: "bot", Secret: "team-token"})
+	create(t, s, po.Credential{Name: "wildcard", Type: TypeHTTP, Patterns: "*.corp.example", Username: "ci", Secret: "corp-token"})
+	create(t, s, po.Credential{Name: "deploy", Type: TypeSSH, Patterns: "git.example.com", Username: "sshkey:1"})
+	create(t, s, po.Credential{Name: "legacy", Type: TypeHTTP, Patterns: "http://legacy.example.com/", Username: "old", Secret: "legacy-token"})
+
+	tests := []struct {
+		url    string
+		secret string
+		key    string
+	}{
+		{"https://git.example.com/other/app.git", "host-token", "bot"},
+		{"https://git.example.com/team/app.git", "team-token", "bot"},
+		{"https://git.example.com/teamx/app.git", "host-token", "bot"},
+		{"https://GIT.example.com/team", "team-token", "bot"},
+		{"https://scm.corp.example/app.git", "corp-token", "ci"},
+		{"git@git.example.com:team/app.git", "", "sshkey:1"},
+		{"https://github.com/app.git", "", ""},
+		{"http://git.example.com/other/app.git", "", ""},
+		{"http://scm.corp.example/app.git", "", ""},
+		{"http://legacy.example.com/app.git", "legacy-token", "old"},
+	}
+	for _, tt := range tests {
+		auth, ok := s.Resolve(tt.url)
+		if ok != (tt.key != "") || auth.Secret != tt.secret || auth.Key != tt.key {
+			t.Errorf("Resolve(%s) = %+v, %v; want key %q secret %q", tt.url, auth, ok, tt.key, tt.secret)
+		}
+	}
+}
+
+func TestResolveExpiredAndLastUsed(t *testing.T) {
+	s := newTestService(t)
+	past := time.Now().Add(-time.Hour)
+	expired := create(t, s, po.Credential{Name: "old", Type: TypeHTTP, Patterns: "https://git.example.com/team/", Username: "bot", Secret: "old", ExpiresAt: &past})
+	current := create(t, s, po.Credential{Name: "new", Type: TypeHTTP, Patterns: "git.example.com", Username: "bot", Secret: "new"})
+
+	auth, ok := s.Resolve("https://git.example.com/team/app.git")
+	if !ok || auth.Secret != "new" {
+		t.Fatalf("expired credential used: %+v", auth)
+	}
+
+	got, _ := s.dao.FindByID(current.ID)
+	if got.LastUsedAt == nil || got.Secret != "new" {
+		t.Fatalf("last use not recorded or secret changed: %+v", got)
+	}
+	if got, _ := s.dao.FindByID(expired.ID); got.LastUsedAt != nil {
+		t.Fatal("expired credential marked as used")
+	}
+}
+
+func TestUpdateKeepsSecret(t *testing.T) {
+	s := newTestService(t)
+	cred := create(t, s, po.Credential{Name: "host", Type: TypeHTTP, Patterns: "git.example.com", Username: "bot", Secret: "token"})
+
+	updated, err := s.Update(cred.ID, po.Credential{Name: "host", Type: TypeHTTP, Patterns: "git.example.com\ngit.example.org", Username: "bot2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Secret != "token" || updated.Username != "bot2" || len(updated.PatternList()) != 2 {
+		t.Fatalf("unexpected update: %+v", updated)
+	}
+	if auth, ok := s.Resolve("https://git.example.org/app.git"); !ok || auth.Secret != "token" {
+		t.Fatalf("updated credential not resolved: %+v", auth)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	s := newTestService(t)
+	invalid := []po.Credential{
+		{Name: "", Type: TypeHTTP, Patterns: "git.example.com", Username: "bot"},
+		{Name: "a", Type: "ftp", Patterns: "git.example.com", Username: "bot"},
+		{Name: "a", Type: TypeHTTP, Patterns: " ", Username: "bot"},
+		{Name: "a", Type: TypeHTTP, Patterns: "ssh://git.example.com/team", Username: "bot"},
+		{Name: "a", Type: TypeHTTP, Patterns: "git.*.com", Username: "bot"},
+		{Name: "a", Type: TypeSSH, Patterns: "git.example.com"},
+	}
+	for _, c := range invalid {
+		if err := s.Create(&c); !errors.Is(err, ErrInvalidCredential) {
+			t.Errorf("Create(%+v) = %v, want invalid", c, err)
+		}
+	}
+	create(t, s, po.Credential{Name: "a", Type: TypeHTTP, Patterns: "git.example.com", Username: "bot"})
+	if err := s.Create(&po.Credential{Name: "a", Type: TypeHTTP, Patterns: "git.example.org", Username: "bot"}); !errors.Is(err, ErrCredentialExists) {
+		t.Fatalf("duplicate name: %v", err)
+	}
+}
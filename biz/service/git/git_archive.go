@@ -0,0 +1,63 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Archive formats supported by Archive
+const (
+	ArchiveFormatTarGz = "tar.gz"
+	ArchiveFormatZip   = "zip"
+)
+
+func IsValidArchiveFormat(format string) bool {
+	return format == ArchiveFormatTarGz || format == ArchiveFormatZip
+}
+
+// Archive streams an archive of ref built from the object database, so the working tree
+// and its uncommitted changes are never included. With a subdirectory only its contents
+// are archived, placed directly under prefix. git is stopped once ctx is done.
+func (s *GitService) Archive(ctx context.Context, repoPath, ref, format, prefix, subdir string) (io.ReadCloser, error) {
+	if !IsValidArchiveFormat(format) {
+		return nil, fmt.Errorf("unknown archive format: %s", format)
+	}
+	subdir, err := cleanTreePath(subdir)
+	if err != nil {
+		return nil, err
+	}
+	if prefix != "" {
+		if prefix, err = cleanTreePath(prefix); err != nil {
+			return nil, err
+		}
+		prefix += "/"
+	}
+	commit, err := s.resolveRefCommit(repoPath, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	treeish := commit
+	if subdir != "" {
+		treeish = commit + ":" + subdir
+		if t, err := s.RunCommand(repoPath, "cat-file", "-t", treeish); err != nil {
+			return nil, ErrPathNotFound
+		} else if t != "tree" {
+			return nil, fmt.Errorf("%w: %s is not a directory", ErrInvalidPath, subdir)
+		}
+	}
+
+	// Errors are detected up front, git archive itself does not fail on a valid tree
+	return s.RunCommandStreamContext(ctx, repoPath, "archive", "--format="+format, "--prefix="+prefix, treeish)
+}
+
+// ArchiveName is the default file name and prefix for an archive of ref, e.g. "repo-v1.2"
+func ArchiveName(repoName, ref string) string {
+	name := repoName
+	if ref != "" && ref != "HEAD" {
+		name += "-" + strings.NewReplacer("/", "-", "\\", "-").Replace(ref)
+	}
+	return name
+}
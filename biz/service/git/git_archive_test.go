@@ -0,0 +1,105 @@
+package git
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/yi-nology/git-manage-service/biz/service/git/gittest"
+)
+
+func TestArchive(t *testing.T) {
+	repo := gittest.New(t)
+
+	repo.Write("README.md", "v1\n")
+	repo.Write("src/a.go", "package a\n")
+	repo.Commit("v1", "")
+	repo.Git("tag", "v1")
+	// Uncommitted changes must not leak into the archive
+	repo.Write("README.md", "dirty\n")
+	repo.Write("secret.txt", "secret\n")
+
+	s := NewGitService()
+	read := func(format, prefix, subdir string) map[string]string {
+		stream, err := s.Archive(context.Background(), repo.Dir, "v1", format, prefix, subdir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(stream)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := stream.Close(); err != nil {
+			t.Fatal(err)
+		}
+		files := map[string]string{}
+		if format == ArchiveFormatZip {
+			zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, f := range zr.File {
+				if f.FileInfo().IsDir() {
+					continue
+				}
+				rc, _ := f.Open()
+				content, _ := io.ReadAll(rc)
+				rc.Close()
+				files[f.Name] = string(content)
+			}
+			return files
+		}
+		gz, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		tr := tar.NewReader(gz)
+		for {
+			h, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if h.Typeflag == tar.TypeReg {
+				content, _ := io.ReadAll(tr)
+				files[h.Name] = string(content)
+			}
+		}
+		return files
+	}
+	names := func(files map[string]string) string {
+		var list []string
+		for name := range files {
+			list = append(list, name)
+		}
+		sort.Strings(list)
+		return strings.Join(list, ",")
+	}
+
+	files := read(ArchiveFormatTarGz, "proj-v1", "")
+	if names(files) != "proj-v1/README.md,proj-v1/src/a.go" || files["proj-v1/README.md"] != "v1\n" {
+		t.Errorf("unexpected tar.gz content %v", files)
+	}
+	files = read(ArchiveFormatZip, "", "src")
+	if names(files) != "a.go" {
+		t.Errorf("unexpected zip content %v", files)
+	}
+
+	if _, err := s.Archive(context.Background(), repo.Dir, "v1", "rar", "", ""); err == nil {
+		t.Error("expected unknown format error")
+	}
+	if _, err := s.Archive(context.Background(), repo.Dir, "v1", ArchiveFormatZip, "", "missing"); err != ErrPathNotFound {
+		t.Errorf("expected ErrPathNotFound, got %v", err)
+	}
+	if _, err := s.Archive(context.Background(), repo.Dir, "v1", ArchiveFormatZip, "../x", ""); err == nil {
+		t.Error("expected invalid prefix error")
+	}
+}
@@ -0,0 +1,208 @@
+package git
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// MaxBlameLines caps the number of lines returned by a single Blame call
+const MaxBlameLines = 5000
+
+// IgnoreRevsFile is the conventional list of commits to skip in blame (e.g. reformatting)
+const IgnoreRevsFile = ".git-blame-ignore-revs"
+
+var ErrInvalidLineRange = errors.New("invalid line range")
+
+type BlameOptions struct {
+	IgnoreRevs    []string // commits whose changes are attributed to their parents
+	UseIgnoreFile bool     // also honour IgnoreRevsFile as found at the blamed ref
+	StartLine     int      // 1-based, inclusive; 0 means 1
+	EndLine       int      // inclusive; 0 means up to MaxBlameLines lines from StartLine
+}
+
+type BlameCommit struct {
+	Hash        string `json:"hash"`
+	AuthorName  string `json:"author_name"`
+	AuthorEmail string `json:"author_email"`
+	AuthorTime  int64  `json:"author_time"`
+	Summary     string `json:"summary"`
+	Boundary    bool   `json:"boundary,omitempty"` // root commit of the history
+}
+
+// BlameRange is a run of consecutive lines last changed by the same commit
+type BlameRange struct {
+	Commit    string   `json:"commit"`
+	StartLine int      `json:"start_line"`
+	EndLine   int      `json:"end_line"`
+	OrigPath  string   `json:"orig_path"`       // path in Commit, differs after renames
+	OrigStart int      `json:"orig_start_line"` // line number in Commit
+	Lines     []string `json:"lines"`
+}
+
+type BlameResult struct {
+	Path       string                  `json:"path"`
+	Ref        string                  `json:"ref"` // resolved commit
+	IgnoreRevs []string                `json:"ignore_revs,omitempty"`
+	Ranges     []BlameRange            `json:"ranges"`
+	Commits    map[string]*BlameCommit `json:"commits"`
+	Truncated  bool                    `json:"truncated"` // more lines follow EndLine
+}
+
+// Blame attributes each line of file at ref to the commit that last changed it, grouping
+// consecutive lines of the same commit into ranges.
+func (s *GitService) Blame(repoPath, ref, file string, opts BlameOptions) (*BlameResult, error) {
+	file, err := cleanTreePath(file)
+	if err != nil {
+		return nil, err
+	}
+	if file == "" {
+		return nil, ErrNotAFile
+	}
+	commit, err := s.resolveRefCommit(repoPath, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	start, end := opts.StartLine, opts.EndLine
+	if start == 0 {
+		start = 1
+	}
+	if start < 1 || (end != 0 && end < start) {
+		return nil, fmt.Errorf("%w: %d-%d", ErrInvalidLineRange, opts.StartLine, opts.EndLine)
+	}
+	if end == 0 || end-start+1 > MaxBlameLines {
+		end = start + MaxBlameLines - 1
+	}
+
+	ignoreRevs, err := s.blameIgnoreRevs(repoPath, commit, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// One extra line tells whether the file continues after the range
+	args := []string{"blame", "--porcelain", "-L", fmt.Sprintf("%d,%d", start, end+1)}
+	for _, rev := range ignoreRevs {
+		args = append(args, "--ignore-rev", rev)
+	}
+	args = append(args, commit, "--", file)
+	out, err := s.RunCommandRaw(repoPath, args...)
+	if err != nil {
+		if strings.Contains(out, "has only") {
+			return nil, fmt.Errorf("%w: file has fewer than %d lines", ErrInvalidLineRange, start)
+		}
+		if strings.Contains(out, "no such path") {
+			return nil, ErrPathNotFound
+		}
+		return nil, err
+	}
+
+	result := &BlameResult{Path: file, Ref: commit, IgnoreRevs: ignoreRevs, Ranges: []BlameRange{}, Commits: map[string]*BlameCommit{}}
+	var (
+		cur      *BlameCommit
+		origLine int
+		line     int
+		origPath string
+	)
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		text := scanner.Text()
+		if strings.HasPrefix(text, "\t") {
+			if line > end {
+				result.Truncated = true
+				break
+			}
+			content := text[1:]
+			n := len(result.Ranges)
+			if n > 0 && result.Ranges[n-1].Commit == cur.Hash && result.Ranges[n-1].EndLine == line-1 {
+				result.Ranges[n-1].EndLine = line
+				result.Ranges[n-1].Lines = append(result.Ranges[n-1].Lines, content)
+			} else {
+				result.Ranges = append(result.Ranges, BlameRange{
+					Commit: cur.Hash, StartLine: line, EndLine: line,
+					OrigPath: origPath, OrigStart: origLine, Lines: []string{content},
+				})
+			}
+			continue
+		}
+
+		key, value, _ := strings.Cut(text, " ")
+		if len(key) == 40 && isHex(key) {
+			// "<hash> <orig line> <final line> [<lines in group>]"
+			f := strings.Fields(value)
+			if len(f) < 2 {
+				continue
+			}
+			origLine, _ = strconv.Atoi(f[0])
+			line, _ = strconv.Atoi(f[1])
+			if cur = result.Commits[key]; cur == nil {
+				cur = &BlameCommit{Hash: key}
+				result.Commits[key] = cur
+			}
+			continue
+		}
+		if cur == nil {
+			continue
+		}
+		switch key {
+		case "author":
+			cur.AuthorName = value
+		case "author-mail":
+			cur.AuthorEmail = strings.Trim(value, "<>")
+		case "author-time":
+			cur.AuthorTime, _ = strconv.ParseInt(value, 10, 64)
+		case "summary":
+			cur.Summary = value
+		case "boundary":
+			cur.Boundary = true
+		case "filename":
+			origPath = value
+		}
+	}
+	return result, nil
+}
+
+// blameIgnoreRevs resolves the requested ignore revisions to full hashes
+func (s *GitService) blameIgnoreRevs(repoPath, commit string, opts BlameOptions) ([]string, error) {
+	revs := append([]string{}, opts.IgnoreRevs...)
+	if opts.UseIgnoreFile {
+		blob, err := s.GetBlob(repoPath, commit, IgnoreRevsFile, 0)
+		if err != nil && !errors.Is(err, ErrPathNotFound) {
+			return nil, err
+		}
+		if err == nil && !blob.Binary {
+			for _, line := range strings.Split(blob.Content, "\n") {
+				line, _, _ = strings.Cut(line, "#")
+				if line = strings.TrimSpace(line); line != "" {
+					revs = append(revs, line)
+				}
+			}
+		}
+	}
+
+	seen := make(map[string]bool, len(revs))
+	resolved := make([]string, 0, len(revs))
+	for _, rev := range revs {
+		hash, err := s.resolveRefCommit(repoPath, rev)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[hash] {
+			seen[hash] = true
+			resolved = append(resolved, hash)
+		}
+	}
+	return resolved, nil
+}
+
+func isHex(s string) bool {
+	for _, c := range s {
+		if !((c >= '0' && c <= '9') || (c >= 'a' && c <= 'f')) {
+			return false
+		}
+	}
+	return true
+}
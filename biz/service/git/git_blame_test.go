@@ -0,0 +1,74 @@
+package git
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/yi-nology/git-manage-service/biz/service/git/gittest"
+)
+
+func TestBlame(t *testing.T) {
+	repo := gittest.New(t)
+	repo.Write("f.txt", "one\ntwo\nthree\nfour\n")
+	c1 := repo.Commit("initial", "alice <alice@example.com>")
+	repo.Write("f.txt", "one\nTWO\nthree\nfour\n\n")
+	c2 := repo.Commit("edit two", "bob <bob@example.com>")
+	// Whitespace-only change of line one, to be ignored
+	repo.Write("f.txt", "one \nTWO\nthree\nfour\n\n")
+	c3 := repo.Commit("reformat", "carol <carol@example.com>")
+
+	s := NewGitService()
+	res, err := s.Blame(repo.Dir, "main", "f.txt", BlameOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := []string{}
+	for _, r := range res.Ranges {
+		got = append(got, r.Commit[:7]+":"+strings.Join(r.Lines, "|"))
+	}
+	want := []string{c3[:7] + ":one ", c2[:7] + ":TWO", c1[:7] + ":three|four", c2[:7] + ":"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("unexpected ranges\n got %v\nwant %v", got, want)
+	}
+	if r := res.Ranges[2]; r.StartLine != 3 || r.EndLine != 4 || r.OrigStart != 3 {
+		t.Errorf("unexpected range %+v", r)
+	}
+	if cm := res.Commits[c2]; cm == nil || cm.AuthorName != "bob" || cm.AuthorEmail != "bob@example.com" || cm.Summary != "edit two" {
+		t.Errorf("unexpected commit %+v", cm)
+	}
+	if res.Truncated {
+		t.Error("unexpected truncation")
+	}
+
+	res, err = s.Blame(repo.Dir, "main", "f.txt", BlameOptions{IgnoreRevs: []string{c3[:8]}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Ranges[0].Commit != c1 || len(res.IgnoreRevs) != 1 || res.IgnoreRevs[0] != c3 {
+		t.Errorf("ignored revision still blamed: %+v", res.Ranges[0])
+	}
+
+	// The ignore file is read at the blamed ref
+	repo.Write(IgnoreRevsFile, "# formatting\n"+c3+"\n")
+	repo.Commit("ignore reformat", "alice <alice@example.com>")
+	res, err = s.Blame(repo.Dir, "main", "f.txt", BlameOptions{UseIgnoreFile: true})
+	if err != nil || res.Ranges[0].Commit != c1 {
+		t.Errorf("ignore file not honoured: %v %+v", err, res)
+	}
+
+	res, err = s.Blame(repo.Dir, "main", "f.txt", BlameOptions{StartLine: 2, EndLine: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Ranges) != 2 || res.Ranges[0].StartLine != 2 || res.Ranges[1].EndLine != 3 || !res.Truncated {
+		t.Errorf("unexpected line range result %+v", res)
+	}
+
+	if _, err := s.Blame(repo.Dir, "main", "f.txt", BlameOptions{StartLine: 50}); !errors.Is(err, ErrInvalidLineRange) {
+		t.Errorf("expected ErrInvalidLineRange, got %v", err)
+	}
+	if _, err := s.Blame(repo.Dir, "main", "missing.txt", BlameOptions{}); !errors.Is(err, ErrPathNotFound) {
+		t.Errorf("expected ErrPathNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,304 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yi-nology/git-manage-service/biz/model/domain"
+)
+
+const DefaultArchiveTagPrefix = "archive/"
+
+var ErrInvalidCleanup = errors.New("invalid branch cleanup request")
+
+const (
+	BranchCleanupDeleted     = "deleted"
+	BranchCleanupWouldDelete = "would_delete"
+	BranchCleanupSkipped     = "skipped"
+	BranchCleanupFailed      = "failed"
+)
+
+// BranchAnalysis classifies a local branch for cleanup. Protected branches (the base
+// and the checked out branch) are reported but never suggested for deletion.
+type BranchAnalysis struct {
+	domain.BranchInfo
+	Merged    bool `json:"merged"`   // all commits reachable from the base
+	Stale     bool `json:"stale"`    // no commit for StaleDays
+	Orphaned  bool `json:"orphaned"` // upstream configured but gone from the remote
+	AgeDays   int  `json:"age_days"`
+	Protected bool `json:"protected"`
+}
+
+type BranchAnalysisOptions struct {
+	Base      string // branch merged-ness is measured against, default HEAD's branch
+	StaleDays int    // default 90
+}
+
+type BranchCleanupOptions struct {
+	Branches     []string
+	Base         string // protected from deletion like in the analysis, default HEAD
+	Archive      bool   // tag each branch tip before deleting it
+	Force        bool   // also delete branches not merged into Base without archiving them
+	TagPrefix    string // default DefaultArchiveTagPrefix
+	DeleteRemote bool   // also delete the upstream branch on its remote
+	DryRun       bool
+	// Auth returns the credentials for a remote, used when DeleteRemote is set
+	Auth func(remote string) domain.AuthInfo
+}
+
+// BranchCleanupResult is the outcome for one branch
+type BranchCleanupResult struct {
+	Branch        string `json:"branch"`
+	Hash          string `json:"hash,omitempty"`
+	Status        string `json:"status"`
+	ArchiveTag    string `json:"archive_tag,omitempty"`
+	RemoteBranch  string `json:"remote_branch,omitempty"` // e.g. origin/feature
+	RemoteDeleted bool   `json:"remote_deleted"`
+	Error         string `json:"error,omitempty"`
+}
+
+type localBranch struct {
+	hash     string
+	remote   string // upstream remote name
+	merge    string // upstream branch on the remote
+	upstream string // upstream remote-tracking ref, e.g. origin/feature
+	gone     bool
+}
+
+// localBranches reads every local branch with its upstream tracking state
+func (s *GitService) localBranches(path string) (map[string]localBranch, error) {
+	out, err := s.RunCommand(path, "for-each-ref",
+		"--format=%(refname:short)%00%(objectname)%00%(upstream:remotename)%00%(upstream:short)%00%(upstream:track)%00%(upstream)",
+		"refs/heads")
+	if err != nil {
+		return nil, err
+	}
+	cfgMerge := func(name string) string {
+		merge, _ := s.RunCommand(path, "config", "--get", "branch."+name+".merge")
+		return strings.TrimPrefix(merge, "refs/heads/")
+	}
+	branches := make(map[string]localBranch)
+	for _, line := range strings.Split(out, "\n") {
+		f := strings.Split(line, "\x00")
+		if len(f) != 6 {
+			continue
+		}
+		b := localBranch{hash: f[1], remote: f[2], upstream: f[3], gone: f[4] == "[gone]"}
+		if b.remote != "" && b.remote != "." && strings.HasPrefix(f[5], "refs/remotes/") {
+			b.merge = cfgMerge(f[0])
+		}
+		branches[f[0]] = b
+	}
+	return branches, nil
+}
+
+func (s *GitService) protectedBranches(path, base string) map[string]bool {
+	protected := map[string]bool{}
+	if base != "" {
+		protected[base] = true
+	}
+	if head, err := s.RunCommand(path, "symbolic-ref", "-q", "--short", "HEAD"); err == nil {
+		protected[head] = true
+	}
+	return protected
+}
+
+// AnalyzeBranches classifies the local branches as merged into opts.Base, stale and
+// orphaned. The result is sorted with the oldest branches first.
+func (s *GitService) AnalyzeBranches(path string, opts BranchAnalysisOptions) ([]BranchAnalysis, error) {
+	if opts.StaleDays <= 0 {
+		opts.StaleDays = 90
+	}
+	if opts.Base == "" {
+		head, err := s.RunCommand(path, "symbolic-ref", "-q", "--short", "HEAD")
+		if err != nil {
+			return nil, fmt.Errorf("%w: base is required when HEAD is detached", ErrInvalidCleanup)
+		}
+		opts.Base = head
+	}
+	baseHash, err := s.resolveRefCommit(path, opts.Base)
+	if err != nil {
+		return nil, err
+	}
+
+	infos, err := s.ListBranchesWithInfo(path)
+	if err != nil {
+		return nil, err
+	}
+	locals, err := s.localBranches(path)
+	if err != nil {
+		return nil, err
+	}
+	out, err := s.RunCommand(path, "for-each-ref", "--format=%(refname:short)", "--merged", baseHash, "refs/heads")
+	if err != nil {
+		return nil, err
+	}
+	merged := map[string]bool{}
+	for _, name := range strings.Split(out, "\n") {
+		merged[name] = true
+	}
+	protected := s.protectedBranches(path, opts.Base)
+
+	now := time.Now()
+	var result []BranchAnalysis
+	for _, info := range infos {
+		local, ok := locals[info.Name]
+		if !ok || local.hash != info.Hash {
+			// remote-tracking branch
+			continue
+		}
+		a := BranchAnalysis{
+			BranchInfo: info,
+			Merged:     merged[info.Name],
+			Orphaned:   local.gone,
+			AgeDays:    int(now.Sub(info.Date).Hours() / 24),
+			Protected:  protected[info.Name],
+		}
+		a.Stale = a.AgeDays >= opts.StaleDays
+		result = append(result, a)
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].Date.Before(result[j].Date) })
+	return result, nil
+}
+
+// CleanupBranches deletes the branches, optionally tagging each tip first and deleting
+// the upstream branch on its remote. Branches are handled independently; a failure only
+// affects that branch. With DryRun nothing is changed and the planned actions are returned.
+func (s *GitService) CleanupBranches(path string, opts BranchCleanupOptions) ([]BranchCleanupResult, error) {
+	if len(opts.Branches) == 0 {
+		return nil, fmt.Errorf("%w: no branches given", ErrInvalidCleanup)
+	}
+	if opts.TagPrefix == "" {
+		opts.TagPrefix = DefaultArchiveTagPrefix
+	}
+	locals, err := s.localBranches(path)
+	if err != nil {
+		return nil, err
+	}
+	protected := s.protectedBranches(path, opts.Base)
+	// Like `git branch -d`, unmerged commits are only dropped when archived or forced
+	merged := map[string]bool{}
+	if !opts.Archive && !opts.Force {
+		base := opts.Base
+		if base == "" {
+			base = "HEAD"
+		}
+		baseHash, err := s.resolveRefCommit(path, base)
+		if err != nil {
+			return nil, err
+		}
+		out, err := s.RunCommand(path, "for-each-ref", "--format=%(refname:short)", "--merged", baseHash, "refs/heads")
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range strings.Split(out, "\n") {
+			merged[name] = true
+		}
+	}
+
+	gitDir, err := s.GetGitDir(path)
+	if err != nil {
+		return nil, err
+	}
+	lock := repoLock(gitDir)
+	lock.Lock()
+	defer lock.Unlock()
+
+	results := make([]BranchCleanupResult, 0, len(opts.Branches))
+	for _, name := range opts.Branches {
+		res := BranchCleanupResult{Branch: name}
+		local, ok := locals[name]
+		switch {
+		case !ok:
+			res.Status, res.Error = BranchCleanupSkipped, "branch not found"
+		case protected[name]:
+			res.Status, res.Error = BranchCleanupSkipped, "branch is protected (base or checked out)"
+		case !opts.Archive && !opts.Force && !merged[name]:
+			res.Hash = local.hash
+			res.Status, res.Error = BranchCleanupSkipped, "branch is not merged into the base, archive or force to delete it"
+		default:
+			res.Hash = local.hash
+			s.cleanupBranch(path, name, local, opts, &res)
+		}
+		results = append(results, res)
+	}
+	return results, nil
+}
+
+func (s *GitService) cleanupBranch(path, name string, b localBranch, opts BranchCleanupOptions, res *BranchCleanupResult) {
+	fail := func(format string, args ...interface{}) {
+		res.Status, res.Error = BranchCleanupFailed, fmt.Sprintf(format, args...)
+	}
+
+	tagRef := ""
+	if opts.Archive {
+		res.ArchiveTag = opts.TagPrefix + name
+		tagRef = "refs/tags/" + res.ArchiveTag
+		if existing, err := s.RunCommand(path, "rev-parse", "--verify", "-q", tagRef+"^{commit}"); err == nil && existing != b.hash {
+			fail("tag %s already exists at another commit", res.ArchiveTag)
+			return
+		}
+	}
+
+	// Only an upstream that still exists can be deleted; the lease is its last known hash
+	remoteHash := ""
+	if opts.DeleteRemote && b.merge != "" && !b.gone {
+		res.RemoteBranch = b.remote + "/" + b.merge
+		h, err := s.RunCommand(path, "rev-parse", "--verify", "-q", "refs/remotes/"+b.upstream)
+		if err != nil {
+			fail("remote-tracking branch %s not found", b.upstream)
+			return
+		}
+		remoteHash = h
+	}
+
+	if opts.DryRun {
+		res.Status = BranchCleanupWouldDelete
+		return
+	}
+
+	if tagRef != "" {
+		// The empty old value makes creation fail if the tag appeared in the meantime
+		if _, err := s.RunCommand(path, "update-ref", tagRef, b.hash, ""); err != nil {
+			if existing, _ := s.RunCommand(path, "rev-parse", "--verify", "-q", tagRef+"^{commit}"); existing != b.hash {
+				fail("create tag %s failed: %v", res.ArchiveTag, err)
+				return
+			}
+		}
+	}
+
+	if remoteHash != "" {
+		var auth domain.AuthInfo
+		if opts.Auth != nil {
+			auth = opts.Auth(b.remote)
+		}
+		// Remote branch deletion loses the commits for everybody else, so archive there too
+		if tagRef != "" {
+			if out, err := s.runPush(path, b.remote, auth.Type, auth.Key, auth.Secret, tagRef+":"+tagRef); err != nil {
+				fail("push tag %s failed: %v %s", res.ArchiveTag, err, out)
+				return
+			}
+		}
+		if out, err := s.runPush(path, b.remote, auth.Type, auth.Key, auth.Secret,
+			fmt.Sprintf("--force-with-lease=refs/heads/%s:%s", b.merge, remoteHash),
+			":refs/heads/"+b.merge); err != nil {
+			if strings.Contains(out, "stale info") {
+				err = ErrStaleLease
+			}
+			fail("delete remote branch %s failed: %v %s", res.RemoteBranch, err, out)
+			return
+		}
+		res.RemoteDeleted = true
+	}
+
+	if _, err := s.RunCommand(path, "update-ref", "-d", "refs/heads/"+name, b.hash); err != nil {
+		fail("delete branch failed: %v", err)
+		return
+	}
+	// Drop upstream and description settings like `git branch -d` does
+	s.RunCommand(path, "config", "--remove-section", "branch."+name)
+	res.Status = BranchCleanupDeleted
+}
@@ -0,0 +1,142 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yi-nology/git-manage-service/biz/service/git/gittest"
+)
+
+func TestBranchCleanup(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "git-test-cleanup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	remote := filepath.Join(tmpDir, "remote.git")
+	old := []string{"GIT_AUTHOR_DATE=2020-01-01T00:00:00Z", "GIT_COMMITTER_DATE=2020-01-01T00:00:00Z"}
+
+	gittest.Run(t, tmpDir, nil, "init", "-q", "--bare", remote)
+	work := gittest.Init(t, filepath.Join(tmpDir, "work"))
+	work.Git("remote", "add", "origin", remote)
+	work.Git("commit", "-q", "--allow-empty", "-m", "initial")
+
+	// merged: fully contained in main, pushed with upstream
+	work.Git("branch", "merged")
+	work.Git("push", "-q", "-u", "origin", "main", "merged")
+	// stale: old and unmerged
+	work.Git("checkout", "-q", "-b", "stale")
+	gittest.Run(t, work.Dir, old, "commit", "-q", "--allow-empty", "-m", "old work")
+	// orphaned: upstream deleted on the remote
+	work.Git("checkout", "-q", "-b", "orphan", "main")
+	work.Git("commit", "-q", "--allow-empty", "-m", "orphan work")
+	work.Git("push", "-q", "-u", "origin", "orphan")
+	work.Git("push", "-q", "origin", ":orphan")
+	work.Git("fetch", "-q", "--prune", "origin")
+	work.Git("checkout", "-q", "main")
+
+	s := NewGitService()
+	analysis, err := s.AnalyzeBranches(work.Dir, BranchAnalysisOptions{Base: "main", StaleDays: 30})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]BranchAnalysis{}
+	for _, a := range analysis {
+		got[a.Name] = a
+	}
+	if len(got) != 4 || analysis[0].Name != "stale" {
+		t.Fatalf("unexpected analysis %+v", analysis)
+	}
+	if a := got["main"]; !a.Protected || !a.Merged {
+		t.Fatalf("main: %+v", a)
+	}
+	if a := got["merged"]; !a.Merged || a.Stale || a.Orphaned || a.Protected {
+		t.Fatalf("merged: %+v", a)
+	}
+	if a := got["stale"]; a.Merged || !a.Stale || a.Orphaned {
+		t.Fatalf("stale: %+v", a)
+	}
+	if a := got["orphan"]; a.Merged || a.Stale || !a.Orphaned {
+		t.Fatalf("orphan: %+v", a)
+	}
+
+	opts := BranchCleanupOptions{
+		Branches:     []string{"merged", "orphan", "main", "missing"},
+		Base:         "main",
+		Archive:      true,
+		DeleteRemote: true,
+		DryRun:       true,
+	}
+	results, err := s.CleanupBranches(work.Dir, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	statuses := func() string {
+		var parts []string
+		for _, r := range results {
+			parts = append(parts, r.Branch+"="+r.Status)
+		}
+		return strings.Join(parts, ",")
+	}
+	if statuses() != "merged=would_delete,orphan=would_delete,main=skipped,missing=skipped" {
+		t.Fatalf("dry run: %+v", results)
+	}
+	if results[0].RemoteBranch != "origin/merged" || results[1].RemoteBranch != "" || results[0].ArchiveTag != "archive/merged" {
+		t.Fatalf("dry run plan: %+v", results)
+	}
+	if work.Git("branch", "--list", "merged") == "" {
+		t.Fatal("dry run deleted the branch")
+	}
+
+	opts.DryRun = false
+	mergedHash := work.Git("rev-parse", "merged")
+	results, err = s.CleanupBranches(work.Dir, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if statuses() != "merged=deleted,orphan=deleted,main=skipped,missing=skipped" || !results[0].RemoteDeleted {
+		t.Fatalf("cleanup: %+v", results)
+	}
+	if work.Git("branch", "--list", "merged", "orphan") != "" {
+		t.Fatal("branches not deleted")
+	}
+	if work.Git("rev-parse", "archive/merged") != mergedHash {
+		t.Fatal("archive tag missing")
+	}
+	if out := gittest.Run(t, remote, nil, "for-each-ref", "--format=%(refname)"); strings.Contains(out, "refs/heads/merged") || !strings.Contains(out, "refs/tags/archive/merged") {
+		t.Fatalf("remote refs: %s", out)
+	}
+	if out, _ := exec.Command("git", "-C", work.Dir, "config", "--get-regexp", `^branch\.merged\.`).Output(); len(out) != 0 {
+		t.Fatalf("branch config left behind: %s", out)
+	}
+
+	// Unmerged branches are kept unless archived or forced
+	results, err = s.CleanupBranches(work.Dir, BranchCleanupOptions{Branches: []string{"stale"}, Base: "main"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0].Status != BranchCleanupSkipped || work.Git("branch", "--list", "stale") == "" {
+		t.Fatalf("unmerged without force: %+v", results)
+	}
+	results, err = s.CleanupBranches(work.Dir, BranchCleanupOptions{Branches: []string{"stale"}, Base: "main", Force: true, DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0].Status != BranchCleanupWouldDelete {
+		t.Fatalf("unmerged with force: %+v", results)
+	}
+
+	// An archive tag at another commit blocks the deletion
+	work.Git("tag", "archive/stale", "main")
+	results, err = s.CleanupBranches(work.Dir, BranchCleanupOptions{Branches: []string{"stale"}, Archive: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0].Status != BranchCleanupFailed || work.Git("branch", "--list", "stale") == "" {
+		t.Fatalf("tag conflict: %+v", results)
+	}
+}
@@ -86,6 +86,12 @@ func (s *GitService) GetBranchSyncStatus(path, branch, upstream string) (int, in
 
 // PushBranch pushes local branch to remote
 func (s *GitService) PushBranch(path, remote, branch string) error {
+	return s.PushBranchWithAuth(path, remote, branch, "", "", "")
+}
+
+// PushBranchWithAuth is PushBranch with explicit credentials; without them the stored
+// credentials for the remote URL are used
+func (s *GitService) PushBranchWithAuth(path, remote, branch, authType, authKey, authSecret string) error {
 	r, err := s.openRepo(path)
 	if err != nil {
 		return err
@@ -96,16 +102,23 @@ func (s *GitService) PushBranch(path, remote, branch string) error {
 	refSpec := config.RefSpec(fmt.Sprintf("refs/heads/%s:refs/heads/%s", branch, branch))
 
 	// Detect Auth
-	var auth transport.AuthMethod
-	rem, err := r.Remote(remote)
-	if err == nil {
-		urls := rem.Config().URLs
-		if len(urls) > 0 {
-			auth = s.detectSSHAuth(urls[0])
+	auth, err := s.getAuth(authType, authKey, authSecret)
+	if err != nil {
+		return err
+	}
+	if auth == nil {
+		rem, err := r.Remote(remote)
+		if err == nil {
+			urls := rem.Config().URLs
+			if len(urls) > 0 {
+				auth = s.authForURL(urls[0])
+			}
 		}
 	}
 
-	err = r.Push(&git.PushOptions{
+	ctx, cancel := s.opContext(OpPush)
+	defer cancel()
+	err = r.PushContext(ctx, &git.PushOptions{
 		RemoteName: remote,
 		RefSpecs:   []config.RefSpec{refSpec},
 		Auth:       auth,
@@ -134,11 +147,13 @@ func (s *GitService) PullBranch(path, remote, branch string) error {
 	if err == nil {
 		urls := rem.Config().URLs
 		if len(urls) > 0 {
-			auth = s.detectSSHAuth(urls[0])
+			auth = s.authForURL(urls[0])
 		}
 	}
 
-	err = w.Pull(&git.PullOptions{
+	ctx, cancel := s.opContext(OpFetch)
+	defer cancel()
+	err = w.PullContext(ctx, &git.PullOptions{
 		RemoteName:    remote,
 		ReferenceName: plumbing.ReferenceName("refs/heads/" + branch),
 		Auth:          auth,
@@ -163,7 +178,7 @@ func (s *GitService) UpdateBranchFastForward(path, remote, branch, remoteBranch
 	if err == nil {
 		urls := rem.Config().URLs
 		if len(urls) > 0 {
-			auth = s.detectSSHAuth(urls[0])
+			auth = s.authForURL(urls[0])
 		}
 	}
 
@@ -171,7 +186,9 @@ func (s *GitService) UpdateBranchFastForward(path, remote, branch, remoteBranch
 	// e.g. git fetch origin main:main
 	refSpec := config.RefSpec(fmt.Sprintf("refs/heads/%s:refs/heads/%s", remoteBranch, branch))
 
-	err = rem.Fetch(&git.FetchOptions{
+	ctx, cancel := s.opContext(OpFetch)
+	defer cancel()
+	err = rem.FetchContext(ctx, &git.FetchOptions{
 		RemoteName: remote,
 		RefSpecs:   []config.RefSpec{refSpec},
 		Auth:       auth,
@@ -199,12 +216,14 @@ func (s *GitService) FetchAll(path string) error {
 		var auth transport.AuthMethod
 		urls := remote.Config().URLs
 		if len(urls) > 0 {
-			auth = s.detectSSHAuth(urls[0])
+			auth = s.authForURL(urls[0])
 		}
 
-		err := remote.Fetch(&git.FetchOptions{
+		ctx, cancel := s.opContext(OpFetch)
+		err := remote.FetchContext(ctx, &git.FetchOptions{
 			Auth: auth,
 		})
+		cancel()
 		if err != nil && err != git.NoErrAlreadyUpToDate {
 			// Log error but continue?
 		}
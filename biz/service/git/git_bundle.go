@@ -0,0 +1,271 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	ErrEmptyBundle         = errors.New("nothing to bundle, the receiver already has every commit")
+	ErrBundlePrerequisites = errors.New("repository lacks commits the bundle depends on")
+)
+
+// bundleImportNamespace holds fetched bundle refs while ImportBundle applies them
+const bundleImportNamespace = "refs/bundle-import/"
+
+type BundleRef struct {
+	Name string `json:"name"` // full ref name, e.g. refs/heads/main
+	Hash string `json:"hash"`
+}
+
+// Outcome of importing one bundle ref
+const (
+	BundleRefCreated  = "created"
+	BundleRefUpdated  = "updated"
+	BundleRefUpToDate = "up_to_date"
+	BundleRefBehind   = "behind"   // the local ref already contains the bundled commit
+	BundleRefConflict = "conflict" // diverged branch or moved tag, left unchanged
+	BundleRefForced   = "forced"
+	BundleRefSkipped  = "skipped" // neither a branch nor a tag
+)
+
+type BundleImportResult struct {
+	Ref    string `json:"ref"`
+	Old    string `json:"old,omitempty"`
+	New    string `json:"new"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// ResolveBundleRefs resolves branch and tag names (or full ref names) of the repository;
+// without names it returns every branch and tag.
+func (s *GitService) ResolveBundleRefs(repoPath string, names []string) ([]BundleRef, error) {
+	out, err := s.RunCommand(repoPath, "for-each-ref", "--format=%(objectname) %(refname)", "refs/heads", "refs/tags")
+	if err != nil {
+		return nil, err
+	}
+	all := make(map[string]string)
+	var refs []BundleRef
+	for _, line := range strings.Split(out, "\n") {
+		hash, name, ok := strings.Cut(line, " ")
+		if !ok {
+			continue
+		}
+		all[name] = hash
+		if len(names) == 0 {
+			refs = append(refs, BundleRef{Name: name, Hash: hash})
+		}
+	}
+	for _, n := range names {
+		found := false
+		for _, full := range []string{n, "refs/heads/" + n, "refs/tags/" + n} {
+			if hash, ok := all[full]; ok {
+				refs = append(refs, BundleRef{Name: full, Hash: hash})
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("%w: %s", ErrRefNotFound, n)
+		}
+	}
+	return refs, nil
+}
+
+// CreateBundle writes a bundle with the given heads to file. Commits reachable from
+// since (e.g. the heads of a previously exported bundle) are left out, making the bundle
+// incremental. Head names need not exist in the repository: the bundle is assembled in a
+// scratch repository that borrows this repository's objects.
+func (s *GitService) CreateBundle(repoPath, file string, heads []BundleRef, since []string) error {
+	if len(heads) == 0 {
+		return ErrEmptyBundle
+	}
+	gitDir, err := s.GetGitDir(repoPath)
+	if err != nil {
+		return err
+	}
+
+	scratch, err := os.MkdirTemp("", "git-bundle-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(scratch)
+	if _, err := s.RunCommand(scratch, "init", "-q", "--bare", "."); err != nil {
+		return err
+	}
+	alternates := filepath.Join(scratch, "objects", "info", "alternates")
+	if err := os.WriteFile(alternates, []byte(filepath.Join(gitDir, "objects")+"\n"), 0644); err != nil {
+		return err
+	}
+
+	args := []string{"bundle", "create", "-q", file}
+	for _, h := range heads {
+		if !strings.HasPrefix(h.Name, "refs/") {
+			return fmt.Errorf("invalid bundle ref: %s", h.Name)
+		}
+		if _, err := s.RunCommand(scratch, "update-ref", h.Name, h.Hash); err != nil {
+			return err
+		}
+		args = append(args, h.Name)
+	}
+	for _, rev := range since {
+		hash, err := s.resolveRefCommit(repoPath, rev)
+		if err != nil {
+			return err
+		}
+		args = append(args, "^"+hash)
+	}
+
+	if out, err := s.RunCommand(scratch, args...); err != nil {
+		os.Remove(file)
+		if strings.Contains(out, "empty bundle") {
+			return ErrEmptyBundle
+		}
+		return err
+	}
+	return nil
+}
+
+// ListBundleHeads returns the refs recorded in a bundle file
+func (s *GitService) ListBundleHeads(file string) ([]BundleRef, error) {
+	out, err := s.RunCommand(filepath.Dir(file), "bundle", "list-heads", file)
+	if err != nil {
+		return nil, fmt.Errorf("read bundle failed: %v", err)
+	}
+	var heads []BundleRef
+	for _, line := range strings.Split(out, "\n") {
+		if hash, name, ok := strings.Cut(line, " "); ok {
+			heads = append(heads, BundleRef{Name: name, Hash: hash})
+		}
+	}
+	return heads, nil
+}
+
+// VerifyBundle checks that the bundle is valid and that the repository has all the
+// commits it was created against
+func (s *GitService) VerifyBundle(repoPath, file string) error {
+	out, err := s.RunCommand(repoPath, "bundle", "verify", file)
+	if err != nil {
+		if strings.Contains(out, "prerequisite") {
+			return fmt.Errorf("%w: %s", ErrBundlePrerequisites, strings.TrimSpace(out))
+		}
+		return fmt.Errorf("invalid bundle: %v", err)
+	}
+	return nil
+}
+
+// FetchBundleRef fetches a single ref of a bundle into dst without touching any other ref
+func (s *GitService) FetchBundleRef(repoPath, file, src, dst string) error {
+	if err := s.VerifyBundle(repoPath, file); err != nil {
+		return err
+	}
+	_, err := s.RunCommand(repoPath, "fetch", "-q", "--no-tags", file, "+"+src+":"+dst)
+	return err
+}
+
+// ImportBundle fetches a bundle and applies its branches and tags to the repository.
+// Branches only move forward, like a sync push: a branch that is ahead of the bundle is
+// left as is and a diverged branch is reported as a conflict unless force is set.
+// Existing tags are never moved unless force is set.
+func (s *GitService) ImportBundle(repoPath, file string, force bool) ([]BundleImportResult, error) {
+	if err := s.VerifyBundle(repoPath, file); err != nil {
+		return nil, err
+	}
+	heads, err := s.ListBundleHeads(file)
+	if err != nil {
+		return nil, err
+	}
+
+	gitDir, err := s.GetGitDir(repoPath)
+	if err != nil {
+		return nil, err
+	}
+	lock := repoLock(gitDir)
+	lock.Lock()
+	defer lock.Unlock()
+
+	// Fetch into a private namespace first, then move the real refs one by one
+	if _, err := s.RunCommand(repoPath, "fetch", "-q", "--no-tags", file,
+		"+refs/heads/*:"+bundleImportNamespace+"heads/*",
+		"+refs/tags/*:"+bundleImportNamespace+"tags/*"); err != nil {
+		return nil, fmt.Errorf("fetch bundle failed: %v", err)
+	}
+	defer s.clearBundleImportRefs(repoPath)
+
+	results := make([]BundleImportResult, 0, len(heads))
+	for _, h := range heads {
+		res := BundleImportResult{Ref: h.Name, New: h.Hash}
+		switch {
+		case strings.HasPrefix(h.Name, "refs/heads/"):
+			s.importBundleBranch(repoPath, strings.TrimPrefix(h.Name, "refs/heads/"), force, &res)
+		case strings.HasPrefix(h.Name, "refs/tags/"):
+			s.importBundleTag(repoPath, h.Name, force, &res)
+		default:
+			res.Status = BundleRefSkipped
+		}
+		results = append(results, res)
+	}
+	return results, nil
+}
+
+func (s *GitService) importBundleBranch(repoPath, branch string, force bool, res *BundleImportResult) {
+	old, _ := s.RunCommand(repoPath, "rev-parse", "-q", "--verify", "refs/heads/"+branch)
+	res.Old = old
+	switch {
+	case old == res.New:
+		res.Status = BundleRefUpToDate
+		return
+	case old == "":
+		res.Status = BundleRefCreated
+	default:
+		if ok, err := s.IsAncestor(repoPath, old, res.New); err == nil && ok {
+			res.Status = BundleRefUpdated
+		} else if ok, err := s.IsAncestor(repoPath, res.New, old); err == nil && ok {
+			res.Status = BundleRefBehind
+			return
+		} else if force {
+			res.Status = BundleRefForced
+		} else {
+			res.Status = BundleRefConflict
+			return
+		}
+	}
+	if err := s.updateBranchRef(repoPath, branch, res.New, old); err != nil {
+		res.Status = BundleRefConflict
+		res.Error = err.Error()
+	}
+}
+
+func (s *GitService) importBundleTag(repoPath, ref string, force bool, res *BundleImportResult) {
+	old, _ := s.RunCommand(repoPath, "rev-parse", "-q", "--verify", ref)
+	res.Old = old
+	switch {
+	case old == res.New:
+		res.Status = BundleRefUpToDate
+		return
+	case old == "":
+		res.Status = BundleRefCreated
+	case force:
+		res.Status = BundleRefForced
+	default:
+		res.Status = BundleRefConflict
+		return
+	}
+	if _, err := s.RunCommand(repoPath, "update-ref", ref, res.New, old); err != nil {
+		res.Status = BundleRefConflict
+		res.Error = err.Error()
+	}
+}
+
+func (s *GitService) clearBundleImportRefs(repoPath string) {
+	out, err := s.RunCommand(repoPath, "for-each-ref", "--format=%(refname)", bundleImportNamespace)
+	if err != nil {
+		return
+	}
+	for _, ref := range strings.Fields(out) {
+		s.RunCommand(repoPath, "update-ref", "-d", ref)
+	}
+}
@@ -0,0 +1,108 @@
+package git
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/yi-nology/git-manage-service/biz/service/git/gittest"
+)
+
+func TestBundleExportImport(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "git-test-bundle")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	src := gittest.Init(t, filepath.Join(tmpDir, "src"))
+	dst := gittest.Init(t, filepath.Join(tmpDir, "dst"))
+	c1 := src.CommitFile("a.txt", "1\n")
+	src.Git("tag", "-a", "v1", "-m", "v1")
+	src.Git("branch", "dev")
+
+	s := NewGitService()
+	full := filepath.Join(tmpDir, "full.bundle")
+	heads, err := s.ResolveBundleRefs(src.Dir, []string{"main", "v1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.CreateBundle(src.Dir, full, heads, nil); err != nil {
+		t.Fatal(err)
+	}
+	results, err := s.ImportBundle(dst.Dir, full, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 || results[0].Status != BundleRefCreated || results[1].Status != BundleRefCreated {
+		t.Fatalf("unexpected full import %+v", results)
+	}
+	// dst has main checked out (unborn until now)
+	if dst.Git("rev-parse", "main") != c1 || dst.Git("rev-parse", "v1^{commit}") != c1 {
+		t.Fatal("refs not imported")
+	}
+	if out := dst.Git("for-each-ref", bundleImportNamespace); out != "" {
+		t.Errorf("import namespace not cleared: %s", out)
+	}
+
+	// Incremental bundle since the heads of the previous one
+	c2 := src.CommitFile("b.txt", "2\n")
+	inc := filepath.Join(tmpDir, "inc.bundle")
+	if err := s.CreateBundle(src.Dir, inc, []BundleRef{{Name: "refs/heads/main", Hash: c2}}, []string{c1}); err != nil {
+		t.Fatal(err)
+	}
+	empty := filepath.Join(tmpDir, "empty.bundle")
+	if err := s.CreateBundle(src.Dir, empty, []BundleRef{{Name: "refs/heads/main", Hash: c2}}, []string{c2}); !errors.Is(err, ErrEmptyBundle) {
+		t.Errorf("expected ErrEmptyBundle, got %v", err)
+	}
+
+	// A repository without the prerequisites cannot take the incremental bundle
+	other := gittest.Init(t, filepath.Join(tmpDir, "other"))
+	if _, err := s.ImportBundle(other.Dir, inc, false); !errors.Is(err, ErrBundlePrerequisites) {
+		t.Errorf("expected ErrBundlePrerequisites, got %v", err)
+	}
+
+	results, err = s.ImportBundle(dst.Dir, inc, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0].Status != BundleRefUpdated || results[0].Old != c1 || dst.Git("rev-parse", "main") != c2 {
+		t.Fatalf("unexpected incremental import %+v", results)
+	}
+	// The checked out branch moved along with its working tree
+	if _, err := os.Stat(filepath.Join(dst.Dir, "b.txt")); err != nil {
+		t.Error("working tree of checked out branch not updated")
+	}
+
+	// Importing an older bundle does not move the branch back
+	results, _ = s.ImportBundle(dst.Dir, full, false)
+	if results[0].Status != BundleRefBehind || dst.Git("rev-parse", "main") != c2 {
+		t.Errorf("expected behind, got %+v", results[0])
+	}
+
+	// Diverged branches are only replaced with force
+	local := dst.CommitFile("local.txt", "x\n")
+	c3 := src.CommitFile("c.txt", "3\n")
+	div := filepath.Join(tmpDir, "div.bundle")
+	if err := s.CreateBundle(src.Dir, div, []BundleRef{{Name: "refs/heads/main", Hash: c3}}, []string{c2}); err != nil {
+		t.Fatal(err)
+	}
+	results, _ = s.ImportBundle(dst.Dir, div, false)
+	if results[0].Status != BundleRefConflict || dst.Git("rev-parse", "main") != local {
+		t.Errorf("expected conflict, got %+v", results[0])
+	}
+	dst.Git("checkout", "-q", "--detach")
+	results, _ = s.ImportBundle(dst.Dir, div, true)
+	if results[0].Status != BundleRefForced || dst.Git("rev-parse", "main") != c3 {
+		t.Errorf("expected forced update, got %+v", results[0])
+	}
+
+	// Single-ref fetch used by bundle-based sync sources
+	if err := s.FetchBundleRef(other.Dir, full, "refs/heads/main", "refs/bundles/task/main"); err != nil {
+		t.Fatal(err)
+	}
+	if other.Git("rev-parse", "refs/bundles/task/main") != c1 {
+		t.Error("bundle ref not fetched")
+	}
+}
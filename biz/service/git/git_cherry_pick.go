@@ -0,0 +1,149 @@
+package git
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/yi-nology/git-manage-service/biz/model/domain"
+)
+
+type CherryPickOptions struct {
+	Commits     []string // applied in the given order
+	Targets     []string // branch names
+	Push        bool     // push each updated branch to Remote
+	Remote      string
+	Auth        func(remote string) domain.AuthInfo
+	AuthorName  string // committer identity; picked commits keep their authors
+	AuthorEmail string
+}
+
+// CherryPickResult is the outcome for one target branch
+type CherryPickResult struct {
+	Branch       string          `json:"branch"`
+	Status       string          `json:"status"` // success, up_to_date, conflict, failed
+	PreviousHead string          `json:"previous_head,omitempty"`
+	Head         string          `json:"head,omitempty"`
+	Picked       []PickedCommit  `json:"picked,omitempty"`
+	Skipped      []string        `json:"skipped,omitempty"` // already present on the branch
+	FailedCommit string          `json:"failed_commit,omitempty"`
+	Conflicts    []MergeConflict `json:"conflicts,omitempty"`
+	Pushed       bool            `json:"pushed"`
+	Error        string          `json:"error,omitempty"`
+}
+
+type PickedCommit struct {
+	Source string `json:"source"`
+	Result string `json:"result"`
+}
+
+// CherryPick applies the commits, in order and with `-x` provenance, on top of every
+// target branch. Each branch is handled in its own workspace and independently of the
+// others: a conflict on one branch leaves that branch unchanged and does not stop the rest.
+func (s *GitService) CherryPick(path string, opts CherryPickOptions) ([]CherryPickResult, error) {
+	r, err := s.openRepo(path)
+	if err != nil {
+		return nil, err
+	}
+	commits := make([]string, 0, len(opts.Commits))
+	for _, rev := range opts.Commits {
+		c, err := s.resolveCommit(r, rev)
+		if err != nil {
+			return nil, fmt.Errorf("resolve commit %s failed: %v", rev, err)
+		}
+		if c.NumParents() > 1 {
+			return nil, fmt.Errorf("commit %s is a merge commit and cannot be cherry-picked", rev)
+		}
+		commits = append(commits, c.Hash.String())
+	}
+	if opts.Remote == "" {
+		opts.Remote = "origin"
+	}
+
+	var env []string
+	if opts.AuthorName != "" && opts.AuthorEmail != "" {
+		env = []string{"GIT_COMMITTER_NAME=" + opts.AuthorName, "GIT_COMMITTER_EMAIL=" + opts.AuthorEmail}
+	}
+
+	var auth domain.AuthInfo
+	if opts.Push && opts.Auth != nil {
+		auth = opts.Auth(opts.Remote)
+	}
+
+	results := make([]CherryPickResult, 0, len(opts.Targets))
+	for _, branch := range opts.Targets {
+		res := s.cherryPickBranch(path, branch, commits, env)
+		if opts.Push && (res.Status == "success" || res.Status == "up_to_date") {
+			if err := s.PushBranchWithAuth(path, opts.Remote, branch, auth.Type, auth.Key, auth.Secret); err != nil {
+				res.Status = "failed"
+				res.Error = fmt.Sprintf("push failed: %v", err)
+			} else {
+				res.Pushed = true
+			}
+		}
+		results = append(results, res)
+	}
+	return results, nil
+}
+
+func (s *GitService) cherryPickBranch(path, branch string, commits, env []string) CherryPickResult {
+	res := CherryPickResult{Branch: branch}
+	oldHash, err := s.ResolveRevision(path, "refs/heads/"+branch)
+	if err != nil {
+		res.Status = "failed"
+		res.Error = fmt.Sprintf("resolve branch failed: %v", err)
+		return res
+	}
+	res.PreviousHead = oldHash
+	res.Head = oldHash
+
+	err = s.WithWorkspace(path, oldHash, func(ws *Workspace) error {
+		for _, hash := range commits {
+			out, err := ws.RunEnv(env, "cherry-pick", "-x", hash)
+			if err != nil {
+				// The change is already on the branch
+				if strings.Contains(out, "is now empty") || strings.Contains(out, "nothing to commit") {
+					ws.Run("cherry-pick", "--skip")
+					res.Skipped = append(res.Skipped, hash)
+					continue
+				}
+				conflicts, cErr := ws.Conflicts()
+				if cErr == nil && len(conflicts) > 0 {
+					res.Status = "conflict"
+					res.FailedCommit = hash
+					res.Conflicts = conflicts
+					return nil
+				}
+				return fmt.Errorf("cherry-pick %s failed: %v", hash, err)
+			}
+			head, err := ws.Head()
+			if err != nil {
+				return err
+			}
+			res.Picked = append(res.Picked, PickedCommit{Source: hash, Result: head})
+		}
+
+		newHash, err := ws.Head()
+		if err != nil {
+			return err
+		}
+		if newHash == oldHash {
+			res.Status = "up_to_date"
+			return nil
+		}
+		if err := ws.UpdateBranch(branch, newHash, oldHash); err != nil {
+			return err
+		}
+		res.Status = "success"
+		res.Head = newHash
+		return nil
+	})
+	if err != nil {
+		res.Status = "failed"
+		res.Error = err.Error()
+		res.Picked = nil
+	}
+	if res.Status == "conflict" {
+		res.Picked = nil
+	}
+	return res
+}
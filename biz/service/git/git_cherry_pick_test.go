@@ -0,0 +1,77 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yi-nology/git-manage-service/biz/service/git/gittest"
+	conf "github.com/yi-nology/git-manage-service/pkg/configs"
+)
+
+func TestCherryPick(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "git-test-cherry-pick")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	conf.GlobalConfig.Workspace.Root = filepath.Join(tmpDir, "workspaces")
+	defer func() { conf.GlobalConfig.Workspace.Root = "" }()
+
+	repo := gittest.Init(t, filepath.Join(tmpDir, "repo"))
+	repo.CommitFile("a.txt", "base\n")
+	repo.Git("branch", "release-1")
+	repo.Git("branch", "release-2")
+	repo.Git("branch", "release-3")
+	fix1 := repo.CommitFile("fix1.txt", "fix1\n")
+	fix2 := repo.CommitFile("fix2.txt", "fix2\n")
+
+	// release-2 already carries fix1, release-3 conflicts on fix2.txt
+	repo.Git("checkout", "-q", "release-2")
+	repo.Git("cherry-pick", fix1)
+	repo.Git("checkout", "-q", "release-3")
+	repo.CommitFile("fix2.txt", "other\n")
+	repo.Git("checkout", "-q", "main")
+	release3 := repo.Git("rev-parse", "release-3")
+
+	s := NewGitService()
+	results, err := s.CherryPick(repo.Dir, CherryPickOptions{
+		Commits: []string{fix1[:10], fix2},
+		Targets: []string{"release-1", "release-2", "release-3"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("expected 3 results, got %d", len(results))
+	}
+
+	r1 := results[0]
+	if r1.Status != "success" || len(r1.Picked) != 2 || r1.Head != repo.Git("rev-parse", "release-1") {
+		t.Errorf("release-1: unexpected result %+v", r1)
+	}
+	if msg := repo.Git("log", "-1", "--format=%B", "release-1"); !strings.Contains(msg, "(cherry picked from commit "+fix2+")") {
+		t.Errorf("release-1: missing provenance in %q", msg)
+	}
+
+	r2 := results[1]
+	if r2.Status != "success" || len(r2.Skipped) != 1 || r2.Skipped[0] != fix1 || len(r2.Picked) != 1 {
+		t.Errorf("release-2: unexpected result %+v", r2)
+	}
+
+	r3 := results[2]
+	if r3.Status != "conflict" || r3.FailedCommit != fix2 || len(r3.Conflicts) != 1 || r3.Conflicts[0].Path != "fix2.txt" {
+		t.Errorf("release-3: unexpected result %+v", r3)
+	}
+	if repo.Git("rev-parse", "release-3") != release3 {
+		t.Error("release-3 must be left unchanged on conflict")
+	}
+
+	// Merge commits are refused up front
+	repo.Git("merge", "-q", "--no-ff", "-m", "merge", "release-1")
+	if _, err := s.CherryPick(repo.Dir, CherryPickOptions{Commits: []string{"HEAD"}, Targets: []string{"release-1"}}); err == nil {
+		t.Error("expected merge commit to be refused")
+	}
+}
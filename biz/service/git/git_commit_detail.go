@@ -0,0 +1,237 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+var ErrInvalidParent = errors.New("invalid parent")
+
+// maxCommitFilePatch caps the unified diff returned per file; larger patches are
+// dropped and only their stats are kept
+const maxCommitFilePatch = 512 * 1024
+
+type CommitRefs struct {
+	Branches       []string `json:"branches"`
+	RemoteBranches []string `json:"remote_branches"`
+	Tags           []string `json:"tags"`
+}
+
+type CommitFile struct {
+	Path      string `json:"path"`
+	OldPath   string `json:"old_path,omitempty"` // renames and copies
+	Status    string `json:"status"`             // A, M, D, R, C, T; one letter per parent for combined diffs
+	Additions int    `json:"additions"`
+	Deletions int    `json:"deletions"`
+	Binary    bool   `json:"binary"`
+	Truncated bool   `json:"truncated"`
+	Patch     string `json:"patch,omitempty"`
+}
+
+type CommitDetail struct {
+	Hash           string       `json:"hash"`
+	Parents        []string     `json:"parents"`
+	AuthorName     string       `json:"author_name"`
+	AuthorEmail    string       `json:"author_email"`
+	AuthorTime     int64        `json:"author_time"`
+	CommitterName  string       `json:"committer_name"`
+	CommitterEmail string       `json:"committer_email"`
+	CommitTime     int64        `json:"commit_time"`
+	Subject        string       `json:"subject"`
+	Message        string       `json:"message"`
+	SignatureType  string       `json:"signature_type,omitempty"` // gpg or ssh
+	Refs           CommitRefs   `json:"refs"`
+	DiffBase       string       `json:"diff_base"` // parent hash, "combined", or empty for a root commit
+	Files          []CommitFile `json:"files"`
+	Additions      int          `json:"additions"`
+	Deletions      int          `json:"deletions"`
+}
+
+// GetCommitDetail returns the metadata of a commit, the refs containing it and its
+// diff. The diff is taken against parent (1-based, 0 means the first parent); with
+// combined a merge commit is shown as a combined diff against all its parents instead.
+func (s *GitService) GetCommitDetail(path, rev string, parent int, combined bool) (*CommitDetail, error) {
+	hash, err := s.resolveRefCommit(path, rev)
+	if err != nil {
+		return nil, err
+	}
+	r, err := s.openRepo(path)
+	if err != nil {
+		return nil, err
+	}
+	c, err := r.CommitObject(plumbing.NewHash(hash))
+	if err != nil {
+		return nil, err
+	}
+
+	d := &CommitDetail{
+		Hash:           hash,
+		Parents:        make([]string, 0, len(c.ParentHashes)),
+		AuthorName:     c.Author.Name,
+		AuthorEmail:    c.Author.Email,
+		AuthorTime:     c.Author.When.Unix(),
+		CommitterName:  c.Committer.Name,
+		CommitterEmail: c.Committer.Email,
+		CommitTime:     c.Committer.When.Unix(),
+		Message:        c.Message,
+	}
+	d.Subject, _, _ = strings.Cut(strings.TrimSpace(c.Message), "\n")
+	for _, p := range c.ParentHashes {
+		d.Parents = append(d.Parents, p.String())
+	}
+	switch sig := strings.TrimSpace(c.PGPSignature); {
+	case strings.HasPrefix(sig, "-----BEGIN SSH SIGNATURE-----"):
+		d.SignatureType = "ssh"
+	case sig != "":
+		d.SignatureType = "gpg"
+	}
+
+	if parent < 0 || parent > len(d.Parents) || (parent > 0 && combined) {
+		return nil, fmt.Errorf("%w: %d", ErrInvalidParent, parent)
+	}
+	if d.Refs, err = s.commitRefs(path, hash); err != nil {
+		return nil, err
+	}
+
+	// diff-tree options selecting the file list and the patch
+	var listArgs, patchArgs []string
+	switch {
+	case len(d.Parents) == 0:
+		listArgs = []string{"--root", "-M", "--name-status", hash}
+		patchArgs = []string{"--root", "-M", "-p", hash}
+	case combined && len(d.Parents) > 1:
+		d.DiffBase = "combined"
+		listArgs = []string{"-c", "--name-status", hash}
+		patchArgs = []string{"--cc", hash}
+	default:
+		if parent == 0 {
+			parent = 1
+		}
+		d.DiffBase = d.Parents[parent-1]
+		listArgs = []string{"-M", "--name-status", d.DiffBase, hash}
+		patchArgs = []string{"-M", "-p", d.DiffBase, hash}
+	}
+
+	out, err := s.RunCommandRaw(path, append([]string{"-c", "core.quotePath=false", "diff-tree", "-r", "-z", "--no-commit-id"}, listArgs...)...)
+	if err != nil {
+		return nil, err
+	}
+	d.Files = parseNameStatusZ(out, d.DiffBase == "combined")
+
+	out, err = s.RunCommandRaw(path, append([]string{"-c", "core.quotePath=false", "diff-tree", "-r", "--no-commit-id", "--no-color"}, patchArgs...)...)
+	if err != nil {
+		return nil, err
+	}
+	attachPatches(d.Files, out)
+	for _, f := range d.Files {
+		d.Additions += f.Additions
+		d.Deletions += f.Deletions
+	}
+	return d, nil
+}
+
+func (s *GitService) commitRefs(path, hash string) (CommitRefs, error) {
+	refs := CommitRefs{Branches: []string{}, RemoteBranches: []string{}, Tags: []string{}}
+	out, err := s.RunCommand(path, "for-each-ref", "--contains", hash, "--format=%(refname)")
+	if err != nil {
+		return refs, err
+	}
+	for _, name := range strings.Split(out, "\n") {
+		switch {
+		case strings.HasPrefix(name, "refs/heads/"):
+			refs.Branches = append(refs.Branches, strings.TrimPrefix(name, "refs/heads/"))
+		case strings.HasPrefix(name, "refs/remotes/") && !strings.HasSuffix(name, "/HEAD"):
+			refs.RemoteBranches = append(refs.RemoteBranches, strings.TrimPrefix(name, "refs/remotes/"))
+		case strings.HasPrefix(name, "refs/tags/"):
+			refs.Tags = append(refs.Tags, strings.TrimPrefix(name, "refs/tags/"))
+		}
+	}
+	return refs, nil
+}
+
+// parseNameStatusZ parses `diff-tree --name-status -z`. Renames and copies carry a
+// score and two paths; combined output has one status letter per parent.
+func parseNameStatusZ(out string, combined bool) []CommitFile {
+	fields := strings.Split(strings.TrimSuffix(out, "\x00"), "\x00")
+	files := []CommitFile{}
+	for i := 0; i+1 < len(fields); i += 2 {
+		f := CommitFile{Status: fields[i], Path: fields[i+1]}
+		if !combined && (f.Status[0] == 'R' || f.Status[0] == 'C') && i+2 < len(fields) {
+			f.Status, f.OldPath, f.Path = f.Status[:1], f.Path, fields[i+2]
+			i++
+		} else if !combined {
+			f.Status = f.Status[:1]
+		}
+		files = append(files, f)
+	}
+	return files
+}
+
+// attachPatches splits diff-tree patch output per file and counts the added and
+// deleted lines. Combined hunks carry one marker column per parent; like git's diffstat
+// for merges they are counted against the first parent.
+func attachPatches(files []CommitFile, out string) {
+	headers := make(map[string]int, len(files))
+	for i, f := range files {
+		old := f.OldPath
+		if old == "" {
+			old = f.Path
+		}
+		headers["diff --git a/"+old+" b/"+f.Path] = i
+		headers["diff --cc "+f.Path] = i
+	}
+
+	for _, chunk := range splitPatch(out) {
+		header, _, _ := strings.Cut(chunk, "\n")
+		i, ok := headers[header]
+		if !ok {
+			continue
+		}
+		f := &files[i]
+		inHunk := false
+		for _, line := range strings.Split(chunk, "\n") {
+			switch {
+			case strings.HasPrefix(line, "@@"):
+				inHunk = true
+			case !inHunk:
+				if strings.HasPrefix(line, "Binary files ") {
+					f.Binary = true
+				}
+			case strings.HasPrefix(line, "+"):
+				f.Additions++
+			case strings.HasPrefix(line, "-"):
+				f.Deletions++
+			}
+		}
+		if len(chunk) > maxCommitFilePatch {
+			f.Truncated = true
+		} else {
+			f.Patch = chunk
+		}
+	}
+}
+
+func splitPatch(out string) []string {
+	var chunks []string
+	start := -1
+	for pos := 0; pos < len(out); {
+		if strings.HasPrefix(out[pos:], "diff --git ") || strings.HasPrefix(out[pos:], "diff --cc ") {
+			if start >= 0 {
+				chunks = append(chunks, out[start:pos])
+			}
+			start = pos
+		}
+		next := strings.IndexByte(out[pos:], '\n')
+		if next < 0 {
+			break
+		}
+		pos += next + 1
+	}
+	if start >= 0 {
+		chunks = append(chunks, out[start:])
+	}
+	return chunks
+}
@@ -0,0 +1,90 @@
+package git
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/yi-nology/git-manage-service/biz/service/git/gittest"
+)
+
+func TestGetCommitDetail(t *testing.T) {
+	repo := gittest.New(t)
+
+	s := NewGitService()
+	repo.Write("a.txt", "one\ntwo\nthree\nfour\nfive\n")
+	repo.Write("bin.dat", "\x00\x01\x02")
+	repo.Commit("root\n\nbody", "")
+	repo.Git("tag", "v1")
+
+	root, err := s.GetCommitDetail(repo.Dir, "v1", 0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if root.Subject != "root" || len(root.Parents) != 0 || root.DiffBase != "" || len(root.Files) != 2 {
+		t.Fatalf("root commit: %+v", root)
+	}
+	if root.Additions != 5 || !root.Files[1].Binary || root.Files[0].Status != "A" {
+		t.Errorf("root stats: %+v", root.Files)
+	}
+	if len(root.Refs.Tags) != 1 || root.Refs.Tags[0] != "v1" || root.Refs.Branches[0] != "main" {
+		t.Errorf("root refs: %+v", root.Refs)
+	}
+
+	// A rename with an edit on a side branch, an edit of another line on main
+	repo.Git("checkout", "-q", "-b", "side")
+	repo.Git("mv", "bin.dat", "data.bin")
+	repo.Write("a.txt", "one\nTWO\nthree\nfour\nfive\n")
+	repo.Git("commit", "-q", "-am", "side")
+	repo.Git("checkout", "-q", "main")
+	repo.Write("a.txt", "one\ntwo\nthree\nfour\nFIVE\n")
+	repo.Git("commit", "-q", "-am", "main")
+	repo.Git("merge", "-q", "--no-edit", "side")
+	// Resolve the merge by hand so it differs from both parents
+	repo.Write("a.txt", "one\nTWO\nthree\nfour\nFIVE\nsix\n")
+	repo.Git("commit", "-q", "--amend", "-a", "--no-edit")
+
+	first, err := s.GetCommitDetail(repo.Dir, "HEAD", 0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(first.Parents) != 2 || first.DiffBase != first.Parents[0] {
+		t.Fatalf("merge parents: %+v", first)
+	}
+	files := make(map[string]CommitFile)
+	for _, f := range first.Files {
+		files[f.Path] = f
+	}
+	if f := files["data.bin"]; f.Status != "R" || f.OldPath != "bin.dat" {
+		t.Errorf("rename against first parent: %+v", f)
+	}
+	if f := files["a.txt"]; f.Additions != 2 || f.Deletions != 1 || !strings.Contains(f.Patch, "+TWO") {
+		t.Errorf("a.txt against first parent: %+v", f)
+	}
+
+	second, err := s.GetCommitDetail(repo.Dir, "HEAD", 2, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.DiffBase != first.Parents[1] || len(second.Files) != 1 || second.Files[0].Additions != 2 {
+		t.Errorf("against second parent: %+v", second.Files)
+	}
+
+	combined, err := s.GetCommitDetail(repo.Dir, "HEAD", 0, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if combined.DiffBase != "combined" || len(combined.Files) != 1 {
+		t.Fatalf("combined: %+v", combined.Files)
+	}
+	if f := combined.Files[0]; f.Path != "a.txt" || f.Status != "MM" || !strings.HasPrefix(f.Patch, "diff --cc a.txt") || f.Additions != 2 || f.Deletions != 1 {
+		t.Errorf("combined a.txt: %+v", f)
+	}
+
+	if _, err := s.GetCommitDetail(repo.Dir, "HEAD", 3, false); !errors.Is(err, ErrInvalidParent) {
+		t.Errorf("expected ErrInvalidParent, got %v", err)
+	}
+	if _, err := s.GetCommitDetail(repo.Dir, "nope", 0, false); !errors.Is(err, ErrRefNotFound) {
+		t.Errorf("expected ErrRefNotFound, got %v", err)
+	}
+}
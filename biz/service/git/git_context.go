@@ -0,0 +1,154 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	conf "github.com/yi-nology/git-manage-service/pkg/configs"
+)
+
+// Operation classes, each with its own configurable timeout (git.timeouts)
+const (
+	OpLocal = "local"
+	OpFetch = "fetch"
+	OpPush  = "push"
+	OpClone = "clone"
+	// OpMaintenance covers fsck, gc, repack and commit-graph, which may take long on
+	// big repositories
+	OpMaintenance = "maintenance"
+)
+
+var defaultTimeouts = map[string]time.Duration{
+	OpLocal:       5 * time.Minute,
+	OpFetch:       10 * time.Minute,
+	OpPush:        10 * time.Minute,
+	OpClone:       30 * time.Minute,
+	OpMaintenance: time.Hour,
+}
+
+// baseCtx is the parent of all git work, cancelled by Shutdown
+var baseCtx, cancelBase = context.WithCancel(context.Background())
+
+// Shutdown stops all running git commands and fails new ones
+func Shutdown() {
+	cancelBase()
+}
+
+// WithContext returns a copy of the service whose git work is stopped once ctx is done,
+// e.g. when the client of the request disconnects
+func (s *GitService) WithContext(ctx context.Context) *GitService {
+	bound := *s
+	bound.ctx = ctx
+	return &bound
+}
+
+// Timeout returns the configured timeout of an operation class, 0 means none
+func Timeout(class string) time.Duration {
+	var raw string
+	switch class {
+	case OpFetch:
+		raw = conf.GlobalConfig.Git.Timeouts.Fetch
+	case OpPush:
+		raw = conf.GlobalConfig.Git.Timeouts.Push
+	case OpClone:
+		raw = conf.GlobalConfig.Git.Timeouts.Clone
+	case OpMaintenance:
+		raw = conf.GlobalConfig.Git.Timeouts.Maintenance
+	default:
+		class, raw = OpLocal, conf.GlobalConfig.Git.Timeouts.Local
+	}
+	if raw == "" {
+		return defaultTimeouts[class]
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil || d < 0 {
+		return defaultTimeouts[class]
+	}
+	return d
+}
+
+// opContext derives the context of one operation from the service context, the
+// shutdown context and the class timeout
+func (s *GitService) opContext(class string) (context.Context, context.CancelFunc) {
+	parent := s.ctx
+	if parent == nil {
+		parent = baseCtx
+	}
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if d := Timeout(class); d > 0 {
+		ctx, cancel = context.WithTimeout(parent, d)
+	} else {
+		ctx, cancel = context.WithCancel(parent)
+	}
+	if parent == baseCtx {
+		return ctx, cancel
+	}
+	stop := context.AfterFunc(baseCtx, cancel)
+	return ctx, func() {
+		stop()
+		cancel()
+	}
+}
+
+// commandClass maps a git invocation onto its operation class by its subcommand
+func commandClass(args []string) string {
+	i := 0
+	for i < len(args) && strings.HasPrefix(args[i], "-") {
+		if args[i] == "-c" || args[i] == "-C" {
+			i++ // skip the option value
+		}
+		i++
+	}
+	if i >= len(args) {
+		return OpLocal
+	}
+	switch args[i] {
+	case "fetch", "pull", "ls-remote":
+		return OpFetch
+	case "remote":
+		if i+1 < len(args) && args[i+1] == "update" {
+			return OpFetch
+		}
+	case "push":
+		return OpPush
+	case "clone":
+		return OpClone
+	case "fsck", "gc", "repack", "prune", "commit-graph", "multi-pack-index", "maintenance":
+		return OpMaintenance
+	}
+	return OpLocal
+}
+
+// command prepares a git command bound to the operation context of its class. The
+// returned cancel must be called once the command finished.
+func (s *GitService) command(dir string, env []string, args ...string) (*exec.Cmd, context.Context, context.CancelFunc) {
+	ctx, cancel := s.opContext(commandClass(args))
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	// Prevent password prompts and force English output
+	cmd.Env = append(append(os.Environ(), "GIT_TERMINAL_PROMPT=0", "LC_ALL=C"), env...)
+	// Helpers like ssh may keep the output pipes open after git was killed
+	cmd.WaitDelay = 5 * time.Second
+	return cmd, ctx, cancel
+}
+
+// ctxErr explains a command failure caused by a timeout or cancellation
+func ctxErr(ctx context.Context, args []string, err error) error {
+	if err == nil || ctx.Err() == nil {
+		return err
+	}
+	name := "command"
+	if len(args) > 0 {
+		name = args[0]
+	}
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("git %s timed out: %w", name, ctx.Err())
+	}
+	return fmt.Errorf("git %s cancelled: %w", name, ctx.Err())
+}
@@ -0,0 +1,139 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/yi-nology/git-manage-service/biz/service/git/gittest"
+	conf "github.com/yi-nology/git-manage-service/pkg/configs"
+)
+
+func TestCommandClass(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"fetch", "origin"}, OpFetch},
+		{[]string{"-c", "credential.helper=", "push", "--porcelain", "origin"}, OpPush},
+		{[]string{"-C", "/tmp/repo", "ls-remote", "origin"}, OpFetch},
+		{[]string{"remote", "update"}, OpFetch},
+		{[]string{"remote", "add", "origin", "url"}, OpLocal},
+		{[]string{"clone", "url", "dir"}, OpClone},
+		{[]string{"log", "--oneline"}, OpLocal},
+		{[]string{"--version"}, OpLocal},
+	}
+	for _, tt := range tests {
+		if got := commandClass(tt.args); got != tt.want {
+			t.Errorf("commandClass(%v) = %s, want %s", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	saved := conf.GlobalConfig.Git
+	defer func() { conf.GlobalConfig.Git = saved }()
+
+	conf.GlobalConfig.Git.Timeouts = conf.GitTimeouts{Fetch: "30s", Push: "0", Clone: "bogus"}
+	if got := Timeout(OpFetch); got != 30*time.Second {
+		t.Errorf("fetch = %s", got)
+	}
+	if got := Timeout(OpPush); got != 0 {
+		t.Errorf("push = %s, want disabled", got)
+	}
+	if got := Timeout(OpClone); got != defaultTimeouts[OpClone] {
+		t.Errorf("invalid clone timeout = %s, want default", got)
+	}
+	if got := Timeout(OpLocal); got != defaultTimeouts[OpLocal] {
+		t.Errorf("unset local timeout = %s, want default", got)
+	}
+}
+
+func TestRunCommandContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := NewGitService().WithContext(ctx).RunCommand(t.TempDir(), "version"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("cancelled context: got %v", err)
+	}
+
+	saved := conf.GlobalConfig.Git
+	defer func() { conf.GlobalConfig.Git = saved }()
+	conf.GlobalConfig.Git.Timeouts.Local = "1ns"
+	if _, err := NewGitService().RunCommand(t.TempDir(), "version"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("timeout: got %v", err)
+	}
+
+	conf.GlobalConfig.Git.Timeouts.Local = "1m"
+	if out, err := NewGitService().WithContext(context.Background()).RunCommand(t.TempDir(), "version"); err != nil {
+		t.Fatalf("git version: %v, %s", err, out)
+	}
+}
+
+func TestRunCommandStreamTimeout(t *testing.T) {
+	saved := conf.GlobalConfig.Git
+	defer func() { conf.GlobalConfig.Git = saved }()
+	conf.GlobalConfig.Git.Timeouts.Local = "1ns"
+
+	// Start may already fail, otherwise closing reports the timeout
+	stream, err := NewGitService().RunCommandStream(t.TempDir(), "version")
+	if err == nil {
+		_, _ = io.Copy(io.Discard, stream)
+		err = stream.Close()
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("timeout: got %v", err)
+	}
+}
+
+func TestPushTimeout(t *testing.T) {
+	// A remote that accepts connections but never answers
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+	url := "http://" + ln.Addr().String() + "/repo.git"
+
+	repo := gittest.New(t)
+	repo.Commit("a", "")
+	repo.Git("remote", "add", "origin", url)
+	dir := repo.Dir
+
+	saved := conf.GlobalConfig.Git
+	defer func() { conf.GlobalConfig.Git = saved }()
+	conf.GlobalConfig.Git.Timeouts.Push = "300ms"
+
+	s := NewGitService()
+	head, err := s.ResolveRevision(dir, "HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, push := range map[string]func() error{
+		"Push":         func() error { return s.Push(dir, "origin", head, "main", nil, os.Stderr) },
+		"PushWithAuth": func() error { return s.PushWithAuth(dir, url, head, "main", "", "", "", nil, os.Stderr) },
+	} {
+		done := make(chan error, 1)
+		go func() { done <- push() }()
+		select {
+		case err := <-done:
+			if !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("%s: expected deadline exceeded, got %v", name, err)
+			}
+		case <-time.After(10 * time.Second):
+			t.Fatalf("%s did not return after its timeout", name)
+		}
+	}
+}
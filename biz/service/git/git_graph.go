@@ -0,0 +1,324 @@
+package git
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	DefaultGraphLimit = 100
+	MaxGraphLimit     = 500
+)
+
+var ErrInvalidGraphQuery = errors.New("invalid graph query")
+
+type GraphOptions struct {
+	Refs   []string // branches, tags or revisions to start from; all branches and tags when empty
+	Path   string   // only commits touching this path, parents are rewritten accordingly
+	Author string   // regexp matched against author name and email
+	Cursor string   // NextCursor of the previous page
+	Limit  int
+}
+
+type GraphRef struct {
+	Name string `json:"name"`
+	Type string `json:"type"` // branch, remote, tag, head
+}
+
+type GraphNode struct {
+	Hash        string     `json:"hash"`
+	Parents     []string   `json:"parents"`
+	Lane        int        `json:"lane"`
+	ParentLanes []int      `json:"parent_lanes"` // lane the edge to each parent is drawn in
+	Refs        []GraphRef `json:"refs,omitempty"`
+	AuthorName  string     `json:"author_name"`
+	AuthorEmail string     `json:"author_email"`
+	Timestamp   int64      `json:"timestamp"`
+	Subject     string     `json:"subject"`
+}
+
+type GraphPage struct {
+	Nodes      []GraphNode `json:"nodes"`
+	LaneCount  int         `json:"lane_count"`
+	NextCursor string      `json:"next_cursor,omitempty"`
+}
+
+// graphCursor records where the next page resumes, by commit hashes so that moving refs
+// between pages neither duplicate nor skip rows, and carries the lane layout so that a
+// page continues the lanes of the previous one instead of starting a fresh layout.
+//
+// Without filters Tips is the frontier of the walk: the commits the lanes expect next
+// plus the start commits not shown yet. Everything not shown is reachable from it and
+// nothing shown is, so a page walks only its own rows. Author and path filters hide
+// commits or rewrite parents, which breaks the frontier; those cursors keep the tips of
+// the first page and After, and each page walks again from the tips and skips the rows
+// up to After. That scan grows with the depth of the page and is bounded by the local
+// operation timeout.
+type graphCursor struct {
+	Tips  []string `json:"tips"`
+	After string   `json:"after,omitempty"` // last commit of the previous page, filtered walks only
+	Lanes []string `json:"lanes"`           // commit expected next in each lane, "" for a free lane
+}
+
+// CommitGraph returns one page of history in topological order, with lane assignments
+// for drawing the graph.
+func (s *GitService) CommitGraph(path string, opts GraphOptions) (*GraphPage, error) {
+	if opts.Limit <= 0 {
+		opts.Limit = DefaultGraphLimit
+	}
+	if opts.Limit > MaxGraphLimit {
+		opts.Limit = MaxGraphLimit
+	}
+	filtered := opts.Author != "" || opts.Path != ""
+	var cur graphCursor
+	if opts.Cursor != "" {
+		raw, err := base64.RawURLEncoding.DecodeString(opts.Cursor)
+		if err != nil || json.Unmarshal(raw, &cur) != nil || len(cur.Tips) == 0 {
+			return nil, fmt.Errorf("%w: bad cursor", ErrInvalidGraphQuery)
+		}
+		if (filtered || cur.After != "") && (len(cur.After) != 40 || !isHex(cur.After)) {
+			return nil, fmt.Errorf("%w: bad cursor", ErrInvalidGraphQuery)
+		}
+		for _, tip := range cur.Tips {
+			if len(tip) != 40 || !isHex(tip) {
+				return nil, fmt.Errorf("%w: bad cursor", ErrInvalidGraphQuery)
+			}
+		}
+	} else {
+		tips, err := s.graphTips(path, opts.Refs)
+		if err != nil {
+			return nil, err
+		}
+		cur.Tips = tips
+	}
+
+	page := &GraphPage{Nodes: []GraphNode{}}
+	if len(cur.Tips) == 0 {
+		return page, nil // no branches or tags yet
+	}
+
+	args := []string{"log", "--topo-order", "--parents", "-z",
+		"--format=%H%x1f%P%x1f%an%x1f%ae%x1f%at%x1f%s"}
+	if opts.Author != "" {
+		args = append(args, "--author="+opts.Author)
+	}
+	args = append(args, cur.Tips...)
+	args = append(args, "--")
+	if opts.Path != "" {
+		args = append(args, opts.Path)
+	}
+
+	refs, err := s.refsByCommit(path)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := s.opContext(OpLocal)
+	defer cancel()
+	stream, err := s.RunCommandStreamContext(ctx, path, args...)
+	if err != nil {
+		return nil, err
+	}
+	reader := bufio.NewReader(stream)
+
+	lanes := cur.Lanes
+	resumed := cur.After == ""
+	pending := make(map[string]bool, len(cur.Tips)) // start commits not shown yet
+	for _, tip := range cur.Tips {
+		pending[tip] = true
+	}
+	for {
+		record, readErr := reader.ReadString(0)
+		fields := strings.Split(strings.TrimPrefix(strings.TrimSuffix(record, "\x00"), "\n"), "\x1f")
+		if len(fields) == 6 {
+			if !resumed {
+				resumed = fields[0] == cur.After
+				continue
+			}
+			if len(page.Nodes) == opts.Limit {
+				nextCur := graphCursor{Tips: cur.Tips, After: page.Nodes[len(page.Nodes)-1].Hash, Lanes: lanes}
+				if !filtered {
+					nextCur.Tips, nextCur.After = graphFrontier(lanes, cur.Tips, pending), ""
+				}
+				next, _ := json.Marshal(nextCur)
+				page.NextCursor = base64.RawURLEncoding.EncodeToString(next)
+				// Stop git early, the rest of the walk belongs to later pages
+				_ = stream.Close()
+				return page, nil
+			}
+			ts, _ := strconv.ParseInt(fields[4], 10, 64)
+			node := GraphNode{
+				Hash:        fields[0],
+				Parents:     strings.Fields(fields[1]),
+				Refs:        refs[fields[0]],
+				AuthorName:  fields[2],
+				AuthorEmail: fields[3],
+				Timestamp:   ts,
+				Subject:     fields[5],
+			}
+			lanes = assignLanes(&node, lanes)
+			delete(pending, node.Hash)
+			if len(lanes) > page.LaneCount {
+				page.LaneCount = len(lanes)
+			}
+			page.Nodes = append(page.Nodes, node)
+		}
+		if readErr != nil {
+			break
+		}
+	}
+	if err := stream.Close(); err != nil {
+		return nil, fmt.Errorf("git log failed: %w", ctxErr(ctx, args, err))
+	}
+	if !resumed {
+		// Pinned commits were pruned or the filters changed between pages
+		return nil, fmt.Errorf("%w: cursor does not match the history", ErrInvalidGraphQuery)
+	}
+	return page, nil
+}
+
+// graphTips resolves the refs a graph starts from to commit hashes, dropping commits
+// reachable from another tip since they add nothing to the walk
+func (s *GitService) graphTips(path string, refs []string) ([]string, error) {
+	args := []string{"rev-list", "--no-walk=unsorted"}
+	if len(refs) == 0 {
+		args = append(args, "--branches", "--tags")
+	} else {
+		for _, ref := range refs {
+			if strings.HasPrefix(ref, "-") {
+				return nil, fmt.Errorf("%w: bad ref %s", ErrInvalidGraphQuery, ref)
+			}
+			args = append(args, ref)
+		}
+	}
+	out, err := s.RunCommand(path, append(args, "--")...)
+	if err != nil {
+		return nil, err
+	}
+	tips := strings.Fields(out)
+	if len(tips) < 2 {
+		return tips, nil
+	}
+	out, err = s.RunCommand(path, append([]string{"merge-base", "--independent"}, tips...)...)
+	if err != nil {
+		return nil, err
+	}
+	return strings.Fields(out), nil
+}
+
+// graphFrontier returns the commits the next page of an unfiltered walk starts from:
+// those expected by a lane and the start commits not shown yet
+func graphFrontier(lanes, tips []string, pending map[string]bool) []string {
+	seen := make(map[string]bool)
+	var frontier []string
+	for _, h := range lanes {
+		if h != "" && !seen[h] {
+			seen[h] = true
+			frontier = append(frontier, h)
+		}
+	}
+	for _, tip := range tips {
+		if pending[tip] && !seen[tip] {
+			seen[tip] = true
+			frontier = append(frontier, tip)
+		}
+	}
+	return frontier
+}
+
+// assignLanes places the node in the lane that expects it (or a free one), hands that
+// lane to its first parent and opens lanes for the other parents.
+func assignLanes(node *GraphNode, lanes []string) []string {
+	lane := -1
+	for i, h := range lanes {
+		if h == node.Hash {
+			if lane == -1 {
+				lane = i
+			}
+			lanes[i] = "" // other lanes expecting this commit converge here
+		}
+	}
+	if lane == -1 {
+		lane = freeLane(&lanes)
+	}
+	node.Lane = lane
+
+	node.ParentLanes = make([]int, len(node.Parents))
+	for i, p := range node.Parents {
+		if i == 0 {
+			lanes[lane] = p
+			node.ParentLanes[i] = lane
+			continue
+		}
+		pl := -1
+		for j, h := range lanes {
+			if h == p {
+				pl = j
+				break
+			}
+		}
+		if pl == -1 {
+			pl = freeLane(&lanes)
+			lanes[pl] = p
+		}
+		node.ParentLanes[i] = pl
+	}
+
+	// Trim free lanes at the right edge
+	for len(lanes) > 0 && lanes[len(lanes)-1] == "" {
+		lanes = lanes[:len(lanes)-1]
+	}
+	return lanes
+}
+
+func freeLane(lanes *[]string) int {
+	for i, h := range *lanes {
+		if h == "" {
+			return i
+		}
+	}
+	*lanes = append(*lanes, "")
+	return len(*lanes) - 1
+}
+
+// refsByCommit maps commit hashes to the branches, remote branches and tags pointing at them
+func (s *GitService) refsByCommit(path string) (map[string][]GraphRef, error) {
+	out, err := s.RunCommand(path, "for-each-ref", "--format=%(objectname) %(*objectname) %(refname)",
+		"refs/heads", "refs/remotes", "refs/tags")
+	if err != nil {
+		return nil, err
+	}
+	refs := make(map[string][]GraphRef)
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		hash, name := fields[0], fields[len(fields)-1]
+		if len(fields) == 3 {
+			hash = fields[1] // annotated tag, use the peeled commit
+		}
+		var ref GraphRef
+		switch {
+		case strings.HasPrefix(name, "refs/heads/"):
+			ref = GraphRef{Name: strings.TrimPrefix(name, "refs/heads/"), Type: "branch"}
+		case strings.HasPrefix(name, "refs/remotes/"):
+			if strings.HasSuffix(name, "/HEAD") {
+				continue
+			}
+			ref = GraphRef{Name: strings.TrimPrefix(name, "refs/remotes/"), Type: "remote"}
+		default:
+			ref = GraphRef{Name: strings.TrimPrefix(name, "refs/tags/"), Type: "tag"}
+		}
+		refs[hash] = append(refs[hash], ref)
+	}
+	if head, err := s.RunCommand(path, "rev-parse", "-q", "--verify", "HEAD"); err == nil {
+		refs[head] = append([]GraphRef{{Name: "HEAD", Type: "head"}}, refs[head]...)
+	}
+	return refs, nil
+}
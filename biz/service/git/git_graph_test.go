@@ -0,0 +1,143 @@
+package git
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/yi-nology/git-manage-service/biz/service/git/gittest"
+)
+
+func TestCommitGraph(t *testing.T) {
+	repo := gittest.New(t)
+	root := repo.CommitFileAs("a.txt", "1\n", "alice <alice@example.com>")
+	repo.Git("checkout", "-q", "-b", "feature")
+	f1 := repo.CommitFileAs("f.txt", "1\n", "bob <bob@example.com>")
+	repo.Git("checkout", "-q", "main")
+	m1 := repo.CommitFileAs("a.txt", "2\n", "alice <alice@example.com>")
+	repo.Git("merge", "-q", "--no-ff", "-m", "merge feature", "feature")
+	merge := repo.Git("rev-parse", "HEAD")
+	repo.Git("tag", "-a", "v1", "-m", "v1")
+
+	s := NewGitService()
+	page, err := s.CommitGraph(repo.Dir, GraphOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var hashes []string
+	for _, n := range page.Nodes {
+		hashes = append(hashes, n.Hash)
+	}
+	if len(hashes) != 4 || hashes[0] != merge || hashes[3] != root {
+		t.Fatalf("unexpected order %v", hashes)
+	}
+	top := page.Nodes[0]
+	if top.Lane != 0 || !reflect.DeepEqual(top.Parents, []string{m1, f1}) || !reflect.DeepEqual(top.ParentLanes, []int{0, 1}) {
+		t.Errorf("unexpected merge node %+v", top)
+	}
+	refNames := map[string]bool{}
+	for _, r := range top.Refs {
+		refNames[r.Type+":"+r.Name] = true
+	}
+	if !refNames["head:HEAD"] || !refNames["branch:main"] || !refNames["tag:v1"] {
+		t.Errorf("unexpected refs %+v", top.Refs)
+	}
+	if page.LaneCount != 2 || page.NextCursor != "" {
+		t.Errorf("unexpected page %d %q", page.LaneCount, page.NextCursor)
+	}
+
+	// Paging continues the lane layout of the previous page
+	checkGraphPages(t, s, repo.Dir, GraphOptions{Limit: 1}, page.Nodes)
+	checkGraphPages(t, s, repo.Dir, GraphOptions{Limit: 1, Author: "alice"}, nil)
+
+	// Path filter rewrites parents to the commits touching the path
+	page, err = s.CommitGraph(repo.Dir, GraphOptions{Refs: []string{"main"}, Path: "a.txt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Nodes) != 2 || page.Nodes[0].Hash != m1 || !reflect.DeepEqual(page.Nodes[0].Parents, []string{root}) {
+		t.Errorf("unexpected path-filtered graph %+v", page.Nodes)
+	}
+
+	page, err = s.CommitGraph(repo.Dir, GraphOptions{Author: "bob"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Nodes) != 1 || page.Nodes[0].Hash != f1 {
+		t.Errorf("unexpected author-filtered graph %+v", page.Nodes)
+	}
+
+	// A ref moving between pages neither duplicates nor skips rows
+	page, err = s.CommitGraph(repo.Dir, GraphOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, err := s.CommitGraph(repo.Dir, GraphOptions{Limit: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo.Git("checkout", "-q", "feature")
+	repo.CommitFileAs("g.txt", "1\n", "bob <bob@example.com>")
+	repo.Git("checkout", "-q", "main")
+	rest, err := s.CommitGraph(repo.Dir, GraphOptions{Limit: 10, Cursor: first.NextCursor})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if moved := append(first.Nodes, rest.Nodes...); !reflect.DeepEqual(graphHashes(moved), graphHashes(page.Nodes)) {
+		t.Errorf("graph changed after ref moved:\n%+v\n%+v", moved, page.Nodes)
+	}
+
+	if _, err := s.CommitGraph(repo.Dir, GraphOptions{Cursor: "not a cursor"}); err == nil {
+		t.Error("expected invalid cursor error")
+	}
+}
+
+// checkGraphPages pages through the graph and checks that every commit of want (when
+// given) shows up once, children before parents, each in the lane an edge led into it
+func checkGraphPages(t *testing.T, s *GitService, dir string, opts GraphOptions, want []GraphNode) {
+	t.Helper()
+	var paged []GraphNode
+	for {
+		p, err := s.CommitGraph(dir, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		paged = append(paged, p.Nodes...)
+		if opts.Cursor = p.NextCursor; opts.Cursor == "" {
+			break
+		}
+	}
+	if want != nil && !reflect.DeepEqual(graphHashes(paged), graphHashes(want)) {
+		t.Fatalf("paged graph differs:\n%+v\n%+v", paged, want)
+	}
+	edges := map[string][]int{} // lanes of the edges into a commit not shown yet
+	for _, n := range paged {
+		if lanes, ok := edges[n.Hash]; ok {
+			found := false
+			for _, l := range lanes {
+				found = found || l == n.Lane
+			}
+			if !found {
+				t.Errorf("%s in lane %d, edges lead into lanes %v", n.Hash, n.Lane, lanes)
+			}
+			delete(edges, n.Hash)
+		}
+		for i, p := range n.Parents {
+			edges[p] = append(edges[p], n.ParentLanes[i])
+		}
+	}
+	if want != nil && len(edges) != 0 {
+		t.Errorf("parents never shown: %v", edges)
+	}
+}
+
+// graphHashes returns the set of commits of a graph
+func graphHashes(nodes []GraphNode) map[string]bool {
+	hashes := make(map[string]bool, len(nodes))
+	for _, n := range nodes {
+		if hashes[n.Hash] {
+			hashes[n.Hash+" (duplicate)"] = true
+		}
+		hashes[n.Hash] = true
+	}
+	return hashes
+}
@@ -0,0 +1,71 @@
+package git
+
+import (
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// BlobData is a blob introduced by a commit range, with the path it was first seen at
+type BlobData struct {
+	Hash    string
+	Path    string
+	Content []byte
+}
+
+// ListNewBlobs returns the blobs of at most maxSize bytes that are reachable from `to`
+// but not from `from` (i.e. the blobs a push of from..to would transfer).
+// An empty `from` considers the whole history of `to`.
+func (s *GitService) ListNewBlobs(path, from, to string, maxSize int64) ([]BlobData, error) {
+	args := []string{"rev-list", "--objects", to}
+	if from != "" {
+		args = append(args, "^"+from)
+	}
+	out, err := s.RunCommand(path, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := s.openRepo(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var blobs []BlobData
+	for _, line := range strings.Split(out, "\n") {
+		// Commits are listed without a path, trees and blobs as "<hash> <path>"
+		parts := strings.SplitN(line, " ", 2)
+		if len(parts) != 2 || parts[1] == "" {
+			continue
+		}
+		obj, err := r.Storer.EncodedObject(plumbing.BlobObject, plumbing.NewHash(parts[0]))
+		if err != nil || obj.Size() > maxSize {
+			continue
+		}
+		reader, err := obj.Reader()
+		if err != nil {
+			return nil, err
+		}
+		content, err := io.ReadAll(reader)
+		reader.Close()
+		if err != nil {
+			return nil, err
+		}
+		blobs = append(blobs, BlobData{Hash: parts[0], Path: parts[1], Content: content})
+	}
+	return blobs, nil
+}
+
+// GetGitDir returns the absolute path of the repository's common git directory
+func (s *GitService) GetGitDir(path string) (string, error) {
+	dir, err := s.RunCommand(path, "rev-parse", "--git-common-dir")
+	if err != nil {
+		return "", err
+	}
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(path, dir)
+	}
+	return dir, nil
+}
@@ -0,0 +1,192 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/storage/memory"
+
+	"github.com/yi-nology/git-manage-service/biz/service/hostkey"
+)
+
+// RemoteRef is a ref from the advertisement of a remote
+type RemoteRef struct {
+	Name   string `json:"name"`
+	Hash   string `json:"hash,omitempty"`
+	Target string `json:"target,omitempty"` // of a symbolic ref, e.g. HEAD
+	Peeled string `json:"peeled,omitempty"` // commit an annotated tag points to
+}
+
+// Comparison states of an advertised ref and its local counterpart
+const (
+	RemoteRefUpToDate   = "up_to_date"
+	RemoteRefOutdated   = "outdated"    // local ref points elsewhere, the remote moved
+	RemoteRefNotFetched = "not_fetched" // no local ref yet
+	RemoteRefStale      = "stale"       // local ref whose remote ref is gone
+	RemoteRefUnmapped   = "unmapped"    // not covered by the fetch refspecs
+)
+
+// RemoteRefComparison pairs an advertised ref with the local ref it is fetched into
+type RemoteRefComparison struct {
+	Name       string `json:"name"` // on the remote
+	RemoteHash string `json:"remote_hash,omitempty"`
+	LocalRef   string `json:"local_ref,omitempty"`
+	LocalHash  string `json:"local_hash,omitempty"`
+	Status     string `json:"status"`
+}
+
+// LsRemote lists the refs a remote URL advertises without fetching anything. Without
+// credentials the stored credentials for the URL are used.
+func (s *GitService) LsRemote(url, authType, authKey, authSecret string) ([]RemoteRef, error) {
+	auth, err := s.getAuth(authType, authKey, authSecret)
+	if err != nil {
+		return nil, err
+	}
+	if auth == nil {
+		auth = s.authForURL(url)
+	}
+
+	remote := git.NewRemote(memory.NewStorage(), &config.RemoteConfig{
+		Name: "anonymous",
+		URLs: []string{url},
+	})
+	ctx, cancel := s.opContext(OpFetch)
+	defer cancel()
+	refs, err := remote.ListContext(ctx, &git.ListOptions{Auth: auth, PeelingOption: git.AppendPeeled})
+	if errors.Is(err, transport.ErrEmptyRemoteRepository) {
+		return []RemoteRef{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	byName := make(map[string]*RemoteRef, len(refs))
+	var peeled []*plumbing.Reference
+	for _, ref := range refs {
+		name := ref.Name().String()
+		if strings.HasSuffix(name, "^{}") {
+			peeled = append(peeled, ref)
+			continue
+		}
+		r := &RemoteRef{Name: name}
+		if ref.Type() == plumbing.SymbolicReference {
+			r.Target = ref.Target().String()
+		} else {
+			r.Hash = ref.Hash().String()
+		}
+		byName[name] = r
+	}
+	for _, ref := range peeled {
+		if r, ok := byName[strings.TrimSuffix(ref.Name().String(), "^{}")]; ok {
+			r.Peeled = ref.Hash().String()
+		}
+	}
+
+	list := make([]RemoteRef, 0, len(byName))
+	for _, r := range byName {
+		list = append(list, *r)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list, nil
+}
+
+// CompareRemoteRefs maps advertised refs onto local refs through the fetch refspecs of
+// the configured remote (tags onto refs/tags) and reports which are out of date.
+// Local remote-tracking refs the remote no longer has are reported as stale.
+func (s *GitService) CompareRemoteRefs(path, remoteName string, refs []RemoteRef) ([]RemoteRefComparison, error) {
+	r, err := s.openRepo(path)
+	if err != nil {
+		return nil, err
+	}
+	rem, err := r.Remote(remoteName)
+	if err != nil {
+		return nil, err
+	}
+	specs := rem.Config().Fetch
+
+	local := make(map[string]string)
+	out, err := s.RunCommand(path, "for-each-ref", "--format=%(refname) %(objectname)")
+	if err != nil {
+		return nil, err
+	}
+	for _, line := range strings.Split(out, "\n") {
+		if name, hash, ok := strings.Cut(line, " "); ok {
+			local[name] = hash
+		}
+	}
+
+	result := make([]RemoteRefComparison, 0, len(refs))
+	seen := make(map[string]bool)
+	for _, ref := range refs {
+		if ref.Hash == "" {
+			continue // symbolic
+		}
+		cmp := RemoteRefComparison{Name: ref.Name, RemoteHash: ref.Hash}
+		cmp.LocalRef = localRefFor(specs, ref.Name)
+		switch hash, ok := local[cmp.LocalRef]; {
+		case cmp.LocalRef == "":
+			cmp.Status = RemoteRefUnmapped
+		case !ok:
+			cmp.Status = RemoteRefNotFetched
+		case hash == ref.Hash:
+			cmp.LocalHash, cmp.Status = hash, RemoteRefUpToDate
+		default:
+			cmp.LocalHash, cmp.Status = hash, RemoteRefOutdated
+		}
+		seen[cmp.LocalRef] = true
+		result = append(result, cmp)
+	}
+
+	// Tracking refs left behind by branches deleted on the remote
+	prefix := "refs/remotes/" + remoteName + "/"
+	for name, hash := range local {
+		if strings.HasPrefix(name, prefix) && name != prefix+"HEAD" && !seen[name] {
+			result = append(result, RemoteRefComparison{LocalRef: name, LocalHash: hash, Status: RemoteRefStale})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Name != result[j].Name {
+			return result[i].Name < result[j].Name
+		}
+		return result[i].LocalRef < result[j].LocalRef
+	})
+	return result, nil
+}
+
+func localRefFor(specs []config.RefSpec, name string) string {
+	ref := plumbing.ReferenceName(name)
+	for _, spec := range specs {
+		if spec.Match(ref) {
+			return spec.Dst(ref).String()
+		}
+	}
+	if ref.IsTag() {
+		return name
+	}
+	return ""
+}
+
+// RemoteErrorReason classifies a failed remote access for display: auth, host_key,
+// not_found, timeout or error
+func RemoteErrorReason(err error) string {
+	switch {
+	case errors.Is(err, transport.ErrAuthenticationRequired), errors.Is(err, transport.ErrAuthorizationFailed):
+		return "auth"
+	case errors.Is(err, hostkey.ErrHostKeyUntrusted), errors.Is(err, hostkey.ErrHostKeyChanged):
+		return "host_key"
+	case errors.Is(err, transport.ErrRepositoryNotFound):
+		return "not_found"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case strings.Contains(err.Error(), "unable to authenticate"):
+		// ssh handshake failures are plain errors
+		return "auth"
+	}
+	return "error"
+}
@@ -0,0 +1,83 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/yi-nology/git-manage-service/biz/service/git/gittest"
+)
+
+func TestLsRemoteCompare(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "git-test-ls-remote")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	remote := filepath.Join(tmpDir, "remote.git")
+	upstream := filepath.Join(tmpDir, "upstream")
+	local := filepath.Join(tmpDir, "local")
+
+	s := NewGitService()
+	gittest.Run(t, tmpDir, nil, "init", "-q", "--bare", "-b", "main", remote)
+	refs, err := s.LsRemote(remote, "", "", "")
+	if err != nil || len(refs) != 0 {
+		t.Fatalf("empty remote: %v %v", refs, err)
+	}
+
+	gittest.Run(t, tmpDir, nil, "init", "-q", "-b", "main", upstream)
+	gittest.Run(t, upstream, nil, "commit", "-q", "--allow-empty", "-m", "a")
+	gittest.Run(t, upstream, nil, "branch", "old")
+	gittest.Run(t, upstream, nil, "push", "-q", remote, "main", "old")
+	gittest.Run(t, tmpDir, nil, "clone", "-q", remote, local)
+
+	// The remote moves on after the clone
+	gittest.Run(t, upstream, nil, "commit", "-q", "--allow-empty", "-m", "b")
+	gittest.Run(t, upstream, nil, "tag", "-a", "v1", "-m", "v1")
+	gittest.Run(t, upstream, nil, "branch", "feature")
+	gittest.Run(t, upstream, nil, "push", "-q", remote, "main", "feature", "v1", ":old")
+
+	refs, err = s.LsRemote(remote, "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	byName := make(map[string]RemoteRef)
+	for _, r := range refs {
+		byName[r.Name] = r
+	}
+	if byName["HEAD"].Target != "refs/heads/main" {
+		t.Errorf("HEAD target: %+v", byName["HEAD"])
+	}
+	if tag := byName["refs/tags/v1"]; tag.Peeled != byName["refs/heads/main"].Hash || tag.Hash == tag.Peeled {
+		t.Errorf("annotated tag not peeled: %+v", tag)
+	}
+
+	compared, err := s.CompareRemoteRefs(local, "origin", refs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	status := make(map[string]string)
+	for _, c := range compared {
+		status[c.LocalRef] = c.Status
+	}
+	want := map[string]string{
+		"refs/remotes/origin/main":    RemoteRefOutdated,
+		"refs/remotes/origin/feature": RemoteRefNotFetched,
+		"refs/remotes/origin/old":     RemoteRefStale,
+		"refs/tags/v1":                RemoteRefNotFetched,
+	}
+	for ref, st := range want {
+		if status[ref] != st {
+			t.Errorf("%s: expected %s, got %q", ref, st, status[ref])
+		}
+	}
+
+	gittest.Run(t, local, nil, "fetch", "-q", "--prune", "--tags")
+	compared, _ = s.CompareRemoteRefs(local, "origin", refs)
+	for _, c := range compared {
+		if c.Status != RemoteRefUpToDate {
+			t.Errorf("after fetch %s is %s", c.LocalRef, c.Status)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Maintenance tasks
+const (
+	MaintenanceFsck        = "fsck"
+	MaintenanceGC          = "gc"
+	MaintenanceRepack      = "repack"
+	MaintenanceCommitGraph = "commit-graph"
+)
+
+var maintenanceArgs = map[string][]string{
+	MaintenanceGC:          {"gc", "--quiet"},
+	MaintenanceRepack:      {"repack", "-a", "-d", "-q"},
+	MaintenanceCommitGraph: {"commit-graph", "write", "--reachable"},
+}
+
+// IsMaintenanceTask reports whether task is one of the Maintenance* tasks
+func IsMaintenanceTask(task string) bool {
+	_, ok := maintenanceArgs[task]
+	return ok || task == MaintenanceFsck
+}
+
+// ObjectStats is the object database usage reported by `git count-objects -v`.
+// Sizes are in bytes.
+type ObjectStats struct {
+	LooseObjects  int   `json:"loose_objects"`
+	LooseSize     int64 `json:"loose_size"`
+	PackedObjects int   `json:"packed_objects"`
+	Packs         int   `json:"packs"`
+	PackSize      int64 `json:"pack_size"`
+	PrunePackable int   `json:"prune_packable"` // loose objects also present in packs
+	Garbage       int   `json:"garbage"`        // files in the object directory that are neither objects nor packs
+	GarbageSize   int64 `json:"garbage_size"`
+}
+
+// CountObjects reports object counts and pack sizes of the repository
+func (s *GitService) CountObjects(path string) (*ObjectStats, error) {
+	out, err := s.RunCommand(path, "count-objects", "-v")
+	if err != nil {
+		return nil, err
+	}
+	return parseCountObjects(out), nil
+}
+
+func parseCountObjects(out string) *ObjectStats {
+	stats := &ObjectStats{}
+	for _, line := range strings.Split(out, "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		n, _ := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+		switch key {
+		case "count":
+			stats.LooseObjects = int(n)
+		case "size":
+			stats.LooseSize = n * 1024
+		case "in-pack":
+			stats.PackedObjects = int(n)
+		case "packs":
+			stats.Packs = int(n)
+		case "size-pack":
+			stats.PackSize = n * 1024
+		case "prune-packable":
+			stats.PrunePackable = int(n)
+		case "garbage":
+			stats.Garbage = int(n)
+		case "size-garbage":
+			stats.GarbageSize = n * 1024
+		}
+	}
+	return stats
+}
+
+// FsckResult lists what `git fsck` found. Problems are corruption (missing or broken
+// objects, bad links), warnings are findings git does not consider fatal.
+type FsckResult struct {
+	OK       bool     `json:"ok"`
+	Problems []string `json:"problems"`
+	Warnings []string `json:"warnings"`
+}
+
+// Fsck verifies connectivity and validity of all objects. An error is only returned
+// when fsck could not complete, corruption is reported in the result.
+func (s *GitService) Fsck(path string) (*FsckResult, error) {
+	out, err := s.RunCommand(path, "fsck", "--no-progress", "--no-dangling")
+	if err != nil && (errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled)) {
+		return nil, err
+	}
+	result := parseFsck(out)
+	if err != nil && len(result.Problems) == 0 {
+		// Failed without naming an object, e.g. an unreadable pack index
+		result.Problems = append(result.Problems, strings.TrimSpace(out))
+	}
+	result.OK = len(result.Problems) == 0
+	return result, nil
+}
+
+func parseFsck(out string) *FsckResult {
+	result := &FsckResult{Problems: []string{}, Warnings: []string{}}
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "", strings.HasPrefix(line, "Checking "), strings.HasPrefix(line, "notice:"):
+		case strings.HasPrefix(line, "warning"):
+			result.Warnings = append(result.Warnings, line)
+		default:
+			result.Problems = append(result.Problems, line)
+		}
+	}
+	return result
+}
+
+// Maintain runs one of the gc, repack or commit-graph maintenance tasks and returns
+// the output of git
+func (s *GitService) Maintain(path, task string) (string, error) {
+	args, ok := maintenanceArgs[task]
+	if !ok {
+		return "", fmt.Errorf("unknown maintenance task: %s", task)
+	}
+	return s.RunCommand(path, args...)
+}
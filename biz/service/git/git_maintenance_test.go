@@ -0,0 +1,23 @@
+package git
+
+import "testing"
+
+func TestParseCountObjects(t *testing.T) {
+	out := "count: 12\nsize: 48\nin-pack: 300\npacks: 2\nsize-pack: 1024\nprune-packable: 3\ngarbage: 1\nsize-garbage: 4"
+	got := *parseCountObjects(out)
+	want := ObjectStats{LooseObjects: 12, LooseSize: 48 * 1024, PackedObjects: 300, Packs: 2, PackSize: 1024 * 1024,
+		PrunePackable: 3, Garbage: 1, GarbageSize: 4 * 1024}
+	if got != want {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestParseFsck(t *testing.T) {
+	out := "Checking object directories\nnotice: HEAD points to an unborn branch (main)\n" +
+		"warning in tag 0123: missingTaggerEntry: invalid format - expected 'tagger' line\n" +
+		"broken link from    tree 4567\n              to    blob 89ab\nmissing blob 89ab"
+	got := parseFsck(out)
+	if len(got.Warnings) != 1 || len(got.Problems) != 3 {
+		t.Fatalf("warnings %q, problems %q", got.Warnings, got.Problems)
+	}
+}
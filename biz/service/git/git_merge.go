@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/format/diff"
 )
 
@@ -152,9 +153,13 @@ type MergeResult struct {
 	Conflicts []string `json:"conflicts"` // List of conflicting files
 	Output    string   `json:"output"`
 	MergeID   string   `json:"merge_id"` // Transaction ID if needed
+
+	ConflictDetails []MergeConflict `json:"conflict_details"`
+	AutoMerged      []string        `json:"auto_merged"` // files changed on both sides that merged cleanly
 }
 
-// MergeDryRun checks for conflicts without committing
+// MergeDryRun simulates a three-way merge of source into target without touching
+// the worktree and reports the real conflicts, including their line ranges.
 func (s *GitService) MergeDryRun(path, source, target string) (*MergeResult, error) {
 	r, err := s.openRepo(path)
 	if err != nil {
@@ -170,74 +175,52 @@ func (s *GitService) MergeDryRun(path, source, target string) (*MergeResult, err
 	if err != nil || len(bases) == 0 {
 		return nil, fmt.Errorf("no merge base found")
 	}
-	cBase := bases[0]
 
-	patchSource, err := cBase.Patch(cSource)
+	treeID, conflicts, autoMerged, err := s.mergeTree(path, cTarget.Hash.String(), cSource.Hash.String())
 	if err != nil {
 		return nil, err
 	}
 
-	patchTarget, err := cBase.Patch(cTarget)
+	tree, err := r.TreeObject(plumbing.NewHash(treeID))
 	if err != nil {
 		return nil, err
 	}
 
-	// Check overlap
-	changedSource := make(map[string]bool)
-	for _, fp := range patchSource.FilePatches() {
-		from, to := fp.Files()
-		if from != nil {
-			changedSource[from.Path()] = true
+	paths := make([]string, 0, len(conflicts))
+	for i := range conflicts {
+		c := &conflicts[i]
+		paths = append(paths, c.Path)
+		if c.Type != "content" && c.Type != "add/add" {
+			continue
 		}
-		if to != nil {
-			changedSource[to.Path()] = true
-		}
-	}
-
-	var conflicts []string
-	for _, fp := range patchTarget.FilePatches() {
-		from, to := fp.Files()
-		p := ""
-		if from != nil {
-			p = from.Path()
-		} else if to != nil {
-			p = to.Path()
+		// The merged tree holds the file with conflict markers
+		f, err := tree.File(c.Path)
+		if err != nil {
+			continue
 		}
-
-		if p != "" && changedSource[p] {
-			// Potential conflict
-			conflicts = append(conflicts, p)
+		if content, err := f.Contents(); err == nil {
+			c.Hunks = parseConflictHunks([]byte(content), s.readBlob(path, c.stages[2]), s.readBlob(path, c.stages[3]))
 		}
 	}
 
 	return &MergeResult{
-		Success:   len(conflicts) == 0,
-		Conflicts: conflicts,
+		Success:         len(conflicts) == 0,
+		Conflicts:       paths,
+		ConflictDetails: conflicts,
+		AutoMerged:      autoMerged,
 	}, nil
 }
 
-// Merge performs the actual merge
+// Merge merges source into the target branch with the default strategy.
+// The merge runs in an isolated workspace, so the repository's own checkout is left alone.
 func (s *GitService) Merge(path, source, target, message string) error {
-	// 1. Checkout target
-	if err := s.CheckoutBranch(path, target); err != nil {
-		return fmt.Errorf("checkout target failed: %v", err)
-	}
-
-	// 2. Merge source
-	// We use RunCommand because go-git does not support full merge logic yet
-	args := []string{"merge", source}
-	if message != "" {
-		args = append(args, "-m", message)
-	}
-
-	out, err := s.RunCommand(path, args...)
+	outcome, err := s.MergeWithOptions(path, MergeOptions{Source: source, Target: target, Message: message})
 	if err != nil {
-		// If failed, it's likely a conflict (since we did checkout).
-		// We should abort to restore state
-		s.RunCommand(path, "merge", "--abort")
-		return fmt.Errorf("merge failed (aborted): %v. Output: %s", err, out)
+		return err
+	}
+	if !outcome.Success {
+		return fmt.Errorf("merge failed: %d conflicting file(s)", len(outcome.Conflicts))
 	}
-
 	return nil
 }
 
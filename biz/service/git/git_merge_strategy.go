@@ -0,0 +1,175 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Merge strategies supported by MergeWithOptions
+const (
+	MergeStrategyMerge  = "merge"   // fast-forward when possible, merge commit otherwise
+	MergeStrategyFFOnly = "ff-only" // refuse unless the target can be fast-forwarded
+	MergeStrategyNoFF   = "no-ff"   // always create a merge commit
+	MergeStrategySquash = "squash"  // a single commit with the combined changes
+	MergeStrategyRebase = "rebase"  // replay source commits onto target, then fast-forward
+)
+
+var (
+	ErrNotFastForward      = errors.New("target cannot be fast-forwarded to source")
+	ErrInvalidMergeOptions = errors.New("invalid merge options")
+)
+
+type MergeOptions struct {
+	Source   string
+	Target   string // branch name
+	Strategy string
+	// Message is used for the commit created by merge, no-ff and squash. It is not
+	// supported by ff-only, which creates no commit, and rebase, whose commits keep
+	// their own messages.
+	Message string
+	// AuthorName and AuthorEmail, given together, are the author and committer of the
+	// created commits, including every rebased commit. Not supported by ff-only.
+	AuthorName  string
+	AuthorEmail string
+}
+
+// Validate rejects options the strategy cannot honour instead of ignoring them
+func (o *MergeOptions) Validate() error {
+	strategy := o.Strategy
+	if strategy == "" {
+		strategy = MergeStrategyMerge
+	}
+	if !IsValidMergeStrategy(strategy) {
+		return fmt.Errorf("%w: unknown merge strategy: %s", ErrInvalidMergeOptions, strategy)
+	}
+	if (o.AuthorName == "") != (o.AuthorEmail == "") {
+		return fmt.Errorf("%w: author name and email must be given together", ErrInvalidMergeOptions)
+	}
+	switch {
+	case strategy == MergeStrategyFFOnly && (o.Message != "" || o.AuthorName != ""):
+		return fmt.Errorf("%w: ff-only creates no commit, message and author are not supported", ErrInvalidMergeOptions)
+	case strategy == MergeStrategyRebase && o.Message != "":
+		return fmt.Errorf("%w: rebased commits keep their own messages, message is not supported", ErrInvalidMergeOptions)
+	}
+	return nil
+}
+
+type MergeOutcome struct {
+	Success      bool            `json:"success"`
+	Strategy     string          `json:"strategy"`
+	Head         string          `json:"head"`          // target branch after the operation
+	PreviousHead string          `json:"previous_head"` // target branch before the operation
+	UpToDate     bool            `json:"up_to_date"`
+	Conflicts    []MergeConflict `json:"conflicts,omitempty"`
+	FailedCommit string          `json:"failed_commit,omitempty"` // rebase: commit that did not apply
+}
+
+func IsValidMergeStrategy(strategy string) bool {
+	switch strategy {
+	case "", MergeStrategyMerge, MergeStrategyFFOnly, MergeStrategyNoFF, MergeStrategySquash, MergeStrategyRebase:
+		return true
+	}
+	return false
+}
+
+// MergeWithOptions integrates source into the target branch using the given strategy.
+// It runs in an isolated workspace. Conflicts are not an error: the outcome then has
+// Success=false and lists them, and the target branch is left unchanged.
+func (s *GitService) MergeWithOptions(path string, opts MergeOptions) (*MergeOutcome, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	if opts.Strategy == "" {
+		opts.Strategy = MergeStrategyMerge
+	}
+
+	oldHash, err := s.ResolveRevision(path, "refs/heads/"+opts.Target)
+	if err != nil {
+		return nil, fmt.Errorf("resolve target failed: %v", err)
+	}
+	sourceHash, err := s.RunCommand(path, "rev-parse", "--verify", opts.Source+"^{commit}")
+	if err != nil {
+		return nil, fmt.Errorf("resolve source failed: %v", err)
+	}
+
+	outcome := &MergeOutcome{Strategy: opts.Strategy, PreviousHead: oldHash, Head: oldHash}
+	var env []string
+	if opts.AuthorName != "" {
+		env = []string{
+			"GIT_AUTHOR_NAME=" + opts.AuthorName, "GIT_AUTHOR_EMAIL=" + opts.AuthorEmail,
+			"GIT_COMMITTER_NAME=" + opts.AuthorName, "GIT_COMMITTER_EMAIL=" + opts.AuthorEmail,
+		}
+	}
+
+	err = s.WithWorkspace(path, oldHash, func(ws *Workspace) error {
+		var err error
+		switch opts.Strategy {
+		case MergeStrategyFFOnly:
+			var out string
+			out, err = ws.RunEnv(env, "merge", "--ff-only", sourceHash)
+			if err != nil && strings.Contains(out, "Not possible to fast-forward") {
+				return ErrNotFastForward
+			}
+		case MergeStrategyMerge, MergeStrategyNoFF:
+			args := []string{"merge", "--no-edit"}
+			if opts.Strategy == MergeStrategyNoFF {
+				args = append(args, "--no-ff")
+			}
+			if opts.Message != "" {
+				args = append(args, "-m", opts.Message)
+			}
+			_, err = ws.RunEnv(env, append(args, sourceHash)...)
+		case MergeStrategySquash:
+			_, err = ws.RunEnv(env, "merge", "--squash", sourceHash)
+			if err == nil {
+				if _, diffErr := ws.Run("diff", "--cached", "--quiet"); diffErr == nil {
+					break // nothing to squash
+				}
+				args := []string{"commit", "--no-edit"}
+				if opts.Message != "" {
+					args = []string{"commit", "-m", opts.Message}
+				}
+				_, err = ws.RunEnv(env, args...)
+			}
+		case MergeStrategyRebase:
+			args := []string{"rebase"}
+			if env != nil {
+				// Rebased commits keep their authors unless an identity was given
+				args = append(args, "--exec", "git commit --amend --no-edit --no-verify --reset-author")
+			}
+			_, err = ws.RunEnv(env, append(args, oldHash, sourceHash)...)
+			if err != nil {
+				outcome.FailedCommit, _ = ws.Run("rev-parse", "--verify", "-q", "REBASE_HEAD")
+			}
+		}
+
+		if err != nil {
+			conflicts, cErr := ws.Conflicts()
+			if cErr == nil && len(conflicts) > 0 {
+				outcome.Conflicts = conflicts
+				return nil
+			}
+			return fmt.Errorf("%s failed: %v", opts.Strategy, err)
+		}
+
+		newHash, err := ws.Head()
+		if err != nil {
+			return err
+		}
+		outcome.Success = true
+		if newHash == oldHash {
+			outcome.UpToDate = true
+			return nil
+		}
+		if err := ws.UpdateBranch(opts.Target, newHash, oldHash); err != nil {
+			return err
+		}
+		outcome.Head = newHash
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return outcome, nil
+}
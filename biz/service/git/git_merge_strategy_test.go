@@ -0,0 +1,118 @@
+package git
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yi-nology/git-manage-service/biz/service/git/gittest"
+	conf "github.com/yi-nology/git-manage-service/pkg/configs"
+)
+
+func TestMergeStrategies(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "git-test-strategy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	conf.GlobalConfig.Workspace.Root = filepath.Join(tmpDir, "workspaces")
+	defer func() { conf.GlobalConfig.Workspace.Root = "" }()
+
+	repo := gittest.Init(t, filepath.Join(tmpDir, "repo"))
+	repo.CommitFile("base.txt", "base\n")
+	repo.Git("checkout", "-q", "-b", "feature")
+	repo.CommitFile("f1.txt", "1\n")
+	repo.CommitFile("f2.txt", "2\n")
+	repo.Git("checkout", "-q", "main")
+	repo.CommitFile("main.txt", "main\n")
+	base := repo.Git("rev-parse", "main")
+	// Keep main free to be moved around by the test
+	repo.Git("checkout", "-q", "--detach")
+
+	reset := func() { repo.Git("update-ref", "refs/heads/main", base) }
+	s := NewGitService()
+	merge := func(strategy string) *MergeOutcome {
+		reset()
+		opts := MergeOptions{
+			Source: "feature", Target: "main", Strategy: strategy,
+			Message: "custom message", AuthorName: "Alice", AuthorEmail: "alice@example.com",
+		}
+		if strategy == MergeStrategyRebase {
+			opts.Message = ""
+		}
+		out, err := s.MergeWithOptions(repo.Dir, opts)
+		if err != nil {
+			t.Fatalf("%s: %v", strategy, err)
+		}
+		if !out.Success || out.PreviousHead != base || out.Head != repo.Git("rev-parse", "main") {
+			t.Fatalf("%s: unexpected outcome %+v", strategy, out)
+		}
+		return out
+	}
+
+	// ff-only refuses diverged branches
+	if _, err := s.MergeWithOptions(repo.Dir, MergeOptions{Source: "feature", Target: "main", Strategy: MergeStrategyFFOnly}); err != ErrNotFastForward {
+		t.Errorf("expected ErrNotFastForward, got %v", err)
+	}
+
+	merge(MergeStrategyNoFF)
+	if got := repo.Git("log", "-1", "--format=%P|%s|%an", "main"); len(strings.Fields(strings.Split(got, "|")[0])) != 2 ||
+		!strings.HasSuffix(got, "|custom message|Alice") {
+		t.Errorf("no-ff: unexpected merge commit %q", got)
+	}
+
+	merge(MergeStrategySquash)
+	if got := repo.Git("log", "-1", "--format=%P|%s|%an", "main"); got != base+"|custom message|Alice" {
+		t.Errorf("squash: unexpected commit %q", got)
+	}
+
+	merge(MergeStrategyRebase)
+	if got := repo.Git("rev-list", "--count", base+"..main"); got != "2" {
+		t.Errorf("rebase: expected 2 replayed commits, got %s", got)
+	}
+	if got := repo.Git("log", "--format=%an|%cn|%s", base+"..main"); got != "Alice|Alice|change f2.txt\nAlice|Alice|change f1.txt" {
+		t.Errorf("rebase: expected Alice as author and committer of each commit, got %q", got)
+	}
+
+	// Options a strategy cannot honour are rejected rather than dropped
+	for _, opts := range []MergeOptions{
+		{Strategy: MergeStrategyRebase, Message: "m"},
+		{Strategy: MergeStrategyFFOnly, Message: "m"},
+		{Strategy: MergeStrategyFFOnly, AuthorName: "Alice", AuthorEmail: "alice@example.com"},
+		{Strategy: MergeStrategyNoFF, AuthorName: "Alice"},
+		{Strategy: "octopus"},
+	} {
+		opts.Source, opts.Target = "feature", "main"
+		if _, err := s.MergeWithOptions(repo.Dir, opts); !errors.Is(err, ErrInvalidMergeOptions) {
+			t.Errorf("%+v: expected ErrInvalidMergeOptions, got %v", opts, err)
+		}
+	}
+
+	// ff-only succeeds once main is behind feature
+	repo.Git("update-ref", "refs/heads/main", repo.Git("rev-parse", "feature~1"))
+	out, err := s.MergeWithOptions(repo.Dir, MergeOptions{Source: "feature", Target: "main", Strategy: MergeStrategyFFOnly})
+	if err != nil || !out.Success || repo.Git("rev-parse", "main") != repo.Git("rev-parse", "feature") {
+		t.Errorf("ff-only: %+v, %v", out, err)
+	}
+
+	// Conflicts are reported as a list and leave the target untouched
+	reset()
+	repo.Git("checkout", "-q", "feature")
+	repo.CommitFile("main.txt", "feature side\n")
+	repo.Git("checkout", "-q", "--detach", base)
+	for _, strategy := range []string{MergeStrategyMerge, MergeStrategyRebase} {
+		out, err := s.MergeWithOptions(repo.Dir, MergeOptions{Source: "feature", Target: "main", Strategy: strategy})
+		if err != nil {
+			t.Fatalf("%s: %v", strategy, err)
+		}
+		if out.Success || len(out.Conflicts) != 1 || out.Conflicts[0].Path != "main.txt" || out.Conflicts[0].Type != "add/add" {
+			t.Errorf("%s: expected add/add conflict on main.txt, got %+v", strategy, out)
+		}
+		if repo.Git("rev-parse", "main") != base {
+			t.Errorf("%s: target moved despite the conflict", strategy)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package git
+
+import (
+	"testing"
+
+	"github.com/yi-nology/git-manage-service/biz/service/git/gittest"
+)
+
+func TestMergeDryRun(t *testing.T) {
+	repo := gittest.New(t)
+
+	repo.Write("conflict.txt", "1\n2\n3\n4\n5\n6\n7\n8\n9\n")
+	repo.Write("busy.txt", "a\nb\nc\nd\ne\nf\ng\nh\n")
+	repo.Write("renamed.txt", "keep\n")
+	repo.Write("shifted.txt", "1\n2\n3\n4\n5\n6\n7\n8\n9\n")
+	repo.Commit("base", "")
+
+	repo.Git("checkout", "-q", "-b", "feature")
+	repo.Write("conflict.txt", "1\ntwo-feature\n3\n4\n5\n6\n7\n8\n9\n")
+	repo.Write("busy.txt", "a\nB\nc\nd\ne\nf\ng\nh\n")
+	repo.Git("mv", "renamed.txt", "moved.txt")
+	repo.Write("shifted.txt", "1\n2\nnew1\nnew2\n3\n4\n5\n6\nseven-f\n8\n9\n")
+	repo.Git("commit", "-q", "-am", "feature")
+
+	repo.Git("checkout", "-q", "main")
+	repo.Write("conflict.txt", "1\ntwo-main\n3\n4\n5\n6\n7\neight\n9\n")
+	repo.Write("busy.txt", "a\nb\nc\nd\ne\nf\nG\nh\n")
+	repo.Git("rm", "-q", "renamed.txt")
+	repo.Write("shifted.txt", "1\n2\n3\n5\n6\nseven-m\n8\n9\n")
+	repo.Git("commit", "-q", "-am", "main")
+
+	s := NewGitService()
+	res, err := s.MergeDryRun(repo.Dir, "feature", "main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Success {
+		t.Fatal("expected conflicts")
+	}
+
+	// busy.txt was changed on both sides in different places and must not be reported
+	if len(res.AutoMerged) != 1 || res.AutoMerged[0] != "busy.txt" {
+		t.Errorf("expected busy.txt to auto-merge, got %v", res.AutoMerged)
+	}
+
+	byPath := map[string]MergeConflict{}
+	for _, c := range res.ConflictDetails {
+		byPath[c.Path] = c
+	}
+	if len(byPath) != 3 {
+		t.Fatalf("expected 3 conflicts, got %+v", res.ConflictDetails)
+	}
+
+	content := byPath["conflict.txt"]
+	if content.Type != "content" || len(content.Hunks) != 1 {
+		t.Fatalf("unexpected content conflict: %+v", content)
+	}
+	want := ConflictHunk{StartLine: 2, EndLine: 6, OursStart: 2, OursEnd: 2, TheirsStart: 2, TheirsEnd: 2}
+	if content.Hunks[0] != want {
+		t.Errorf("expected hunk %+v, got %+v", want, content.Hunks[0])
+	}
+
+	// Clean changes before the conflict shift it differently in each side's version
+	shifted := byPath["shifted.txt"]
+	if len(shifted.Hunks) != 1 {
+		t.Fatalf("unexpected shifted conflict: %+v", shifted)
+	}
+	want = ConflictHunk{StartLine: 8, EndLine: 12, OursStart: 6, OursEnd: 6, TheirsStart: 9, TheirsEnd: 9}
+	if shifted.Hunks[0] != want {
+		t.Errorf("expected hunk %+v, got %+v", want, shifted.Hunks[0])
+	}
+
+	if c := byPath["moved.txt"]; c.Type != "rename/delete" {
+		t.Errorf("expected rename/delete conflict on moved.txt, got %+v", c)
+	}
+
+	// A clean merge still reports the files merged from both sides
+	repo.Git("checkout", "-q", "-b", "clean-a", "main~1")
+	repo.Write("busy.txt", "A\nb\nc\nd\ne\nf\ng\nh\n")
+	repo.Git("commit", "-q", "-am", "clean-a")
+	repo.Git("checkout", "-q", "-b", "clean-b", "main~1")
+	repo.Write("busy.txt", "a\nb\nc\nd\ne\nf\ng\nH\n")
+	repo.Git("commit", "-q", "-am", "clean-b")
+	res, err = s.MergeDryRun(repo.Dir, "clean-a", "clean-b")
+	if err != nil || !res.Success {
+		t.Fatalf("expected clean merge, got %+v, %v", res, err)
+	}
+	if len(res.AutoMerged) != 1 || res.AutoMerged[0] != "busy.txt" {
+		t.Errorf("expected busy.txt to auto-merge cleanly, got %v", res.AutoMerged)
+	}
+
+	// No conflicts when merging a branch into itself
+	res, err = s.MergeDryRun(repo.Dir, "feature", "feature")
+	if err != nil || !res.Success {
+		t.Errorf("expected clean merge, got %+v, %v", res, err)
+	}
+}
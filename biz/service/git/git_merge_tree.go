@@ -0,0 +1,274 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/go-git/go-git/v5/utils/diff"
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+// MergeConflict is a single conflict reported by a three-way merge simulation
+type MergeConflict struct {
+	Path    string         `json:"path"`
+	Type    string         `json:"type"`            // content, modify/delete, rename/delete, add/add, ...
+	Paths   []string       `json:"paths,omitempty"` // all paths involved, e.g. both names of a rename
+	Message string         `json:"message"`
+	Hunks   []ConflictHunk `json:"hunks,omitempty"`
+
+	stages [4]string // blob ids by stage: 1 = base, 2 = ours, 3 = theirs
+}
+
+// ConflictHunk locates one conflicting region. Start/End are 1-based, inclusive line numbers
+// of the marker block in the merged file; Ours*/Theirs* are the lines of the region in
+// each side's version of the file (End = Start-1 when that side has no lines there).
+type ConflictHunk struct {
+	StartLine   int `json:"start_line"`
+	EndLine     int `json:"end_line"`
+	OursStart   int `json:"ours_start"`
+	OursEnd     int `json:"ours_end"`
+	TheirsStart int `json:"theirs_start"`
+	TheirsEnd   int `json:"theirs_end"`
+}
+
+// parseStages parses NUL separated "<mode> <oid> <stage>\t<path>" entries, as printed
+// by `ls-files -u -z` and `merge-tree -z`, into blob ids by path in order of appearance
+func parseStages(entries []string) (map[string][4]string, []string) {
+	stages := make(map[string][4]string)
+	var order []string
+	for _, entry := range entries {
+		meta, p, ok := strings.Cut(entry, "\t")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(meta)
+		if len(fields) != 3 {
+			continue
+		}
+		n, err := strconv.Atoi(fields[2])
+		if err != nil || n < 1 || n > 3 {
+			continue
+		}
+		st, ok := stages[p]
+		if !ok {
+			order = append(order, p)
+		}
+		st[n] = fields[1]
+		stages[p] = st
+	}
+	return stages, order
+}
+
+// mergeTree runs `git merge-tree --write-tree` (git >= 2.38), merging theirs into ours
+// without touching the index or worktree. It returns the resulting tree, which contains
+// conflict markers for conflicted files.
+func (s *GitService) mergeTree(path, ours, theirs string) (tree string, conflicts []MergeConflict, autoMerged []string, err error) {
+	args := []string{"merge-tree", "--write-tree", "-z", "--messages", ours, theirs}
+	cmd, ctx, cancel := s.command(path, nil, args...)
+	defer cancel()
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, runErr := cmd.Output()
+
+	// Exit status 1 with a tree on stdout means "merged with conflicts"
+	var exitErr *exec.ExitError
+	if runErr != nil && !(errors.As(runErr, &exitErr) && exitErr.ExitCode() == 1 && len(out) > 0) {
+		return "", nil, nil, fmt.Errorf("git merge-tree failed: %w, output: %s", ctxErr(ctx, args, runErr), strings.TrimSpace(stderr.String()))
+	}
+
+	fields := strings.Split(string(out), "\x00")
+	tree = fields[0]
+	// Conflicted file info ("<mode> <oid> <stage>\t<path>") follows the tree and ends
+	// with an empty field, which is also printed when there are no conflicts; the
+	// informational messages come after it.
+	i := 1
+	for i < len(fields) && fields[i] != "" {
+		i++
+	}
+	stages, _ := parseStages(fields[1:min(i, len(fields))])
+	i++
+
+	conflictAt := make(map[string]bool)
+	var merged []string
+	for i < len(fields) && fields[i] != "" {
+		n, convErr := strconv.Atoi(fields[i])
+		if convErr != nil || i+n+2 >= len(fields) {
+			break
+		}
+		paths := fields[i+1 : i+1+n]
+		kind := fields[i+1+n]
+		msg := strings.TrimSpace(fields[i+2+n])
+		i += n + 3
+
+		if kind == "Auto-merging" {
+			merged = append(merged, paths[0])
+			continue
+		}
+		if !strings.HasPrefix(kind, "CONFLICT") {
+			continue
+		}
+		c := MergeConflict{
+			Path:    paths[0],
+			Type:    conflictType(kind),
+			Paths:   paths,
+			Message: msg,
+		}
+		for _, p := range paths {
+			if st, ok := stages[p]; ok && p == c.Path {
+				c.stages = st
+			}
+		}
+		conflicts = append(conflicts, c)
+		for _, p := range paths {
+			conflictAt[p] = true
+		}
+	}
+
+	for _, p := range merged {
+		if !conflictAt[p] {
+			autoMerged = append(autoMerged, p)
+		}
+	}
+	return tree, conflicts, autoMerged, nil
+}
+
+// conflictType turns "CONFLICT (rename/delete)" into "rename/delete"; git calls content conflicts "contents"
+func conflictType(kind string) string {
+	t := strings.TrimSuffix(strings.TrimPrefix(kind, "CONFLICT ("), ")")
+	if t == "contents" {
+		return "content"
+	}
+	return t
+}
+
+// parseConflictHunks scans a merged file for conflict marker blocks (both merge and
+// diff3/zdiff3 styles) and returns their line ranges. The ranges on each side are
+// located in that side's version of the file, ours and theirs, which may differ from
+// the merged file outside the conflicts; a nil version leaves its ranges zero.
+func parseConflictHunks(content, ours, theirs []byte) []ConflictHunk {
+	const (
+		outside = iota
+		inOurs
+		inBase
+		inTheirs
+	)
+
+	var hunks []ConflictHunk
+	var cur ConflictHunk
+	// The merged file resolved to either side, with the index range of each hunk in it
+	var oursLines, theirsLines []string
+	var oursRanges, theirsRanges [][2]int
+	state := outside
+	line := 0
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		line++
+		text := scanner.Text()
+		switch {
+		case state == outside && strings.HasPrefix(text, "<<<<<<<"):
+			state = inOurs
+			cur = ConflictHunk{StartLine: line}
+			oursRanges = append(oursRanges, [2]int{len(oursLines), 0})
+			theirsRanges = append(theirsRanges, [2]int{len(theirsLines), 0})
+		case state == inOurs && strings.HasPrefix(text, "|||||||"):
+			state = inBase
+		case (state == inOurs || state == inBase) && strings.HasPrefix(text, "======="):
+			state = inTheirs
+		case state == inTheirs && strings.HasPrefix(text, ">>>>>>>"):
+			state = outside
+			cur.EndLine = line
+			hunks = append(hunks, cur)
+			oursRanges[len(oursRanges)-1][1] = len(oursLines)
+			theirsRanges[len(theirsRanges)-1][1] = len(theirsLines)
+		case state == inOurs:
+			oursLines = append(oursLines, text)
+		case state == inTheirs:
+			theirsLines = append(theirsLines, text)
+		case state == outside:
+			oursLines = append(oursLines, text)
+			theirsLines = append(theirsLines, text)
+		}
+	}
+	// Drop an unterminated block
+	oursRanges, theirsRanges = oursRanges[:len(hunks)], theirsRanges[:len(hunks)]
+
+	if ours != nil {
+		pos := linePositions(oursLines, ours)
+		for i, r := range oursRanges {
+			hunks[i].OursStart, hunks[i].OursEnd = sideRange(pos, r)
+		}
+	}
+	if theirs != nil {
+		pos := linePositions(theirsLines, theirs)
+		for i, r := range theirsRanges {
+			hunks[i].TheirsStart, hunks[i].TheirsEnd = sideRange(pos, r)
+		}
+	}
+	return hunks
+}
+
+// linePositions diffs the lines of a resolved merge against the blob of that side and
+// returns for each resolved line (and one past the end) the 0-based index it has, or
+// would be inserted at, in the blob
+func linePositions(resolved []string, blob []byte) []int {
+	text := string(blob)
+	if text != "" && !strings.HasSuffix(text, "\n") {
+		text += "\n"
+	}
+	src := ""
+	if len(resolved) > 0 {
+		src = strings.Join(resolved, "\n") + "\n"
+	}
+
+	pos := make([]int, 0, len(resolved)+1)
+	j := 0
+	for _, d := range diff.Do(src, text) {
+		n := strings.Count(d.Text, "\n")
+		switch d.Type {
+		case diffmatchpatch.DiffEqual:
+			for k := 0; k < n; k++ {
+				pos = append(pos, j)
+				j++
+			}
+		case diffmatchpatch.DiffDelete:
+			for k := 0; k < n; k++ {
+				pos = append(pos, j)
+			}
+		case diffmatchpatch.DiffInsert:
+			j += n
+		}
+	}
+	for len(pos) <= len(resolved) {
+		pos = append(pos, j)
+	}
+	return pos
+}
+
+// sideRange turns the index range [from, to) of a hunk in the resolved lines into
+// 1-based, inclusive line numbers of the side's blob
+func sideRange(pos []int, r [2]int) (int, int) {
+	start := pos[r[0]] + 1
+	if r[1] == r[0] {
+		return start, start - 1
+	}
+	return start, pos[r[1]-1] + 1
+}
+
+// readBlob returns the content of a blob, nil if id is empty or cannot be read
+func (s *GitService) readBlob(path, id string) []byte {
+	if id == "" {
+		return nil
+	}
+	out, err := s.RunCommandRaw(path, "cat-file", "blob", id)
+	if err != nil {
+		return nil
+	}
+	return []byte(out)
+}
@@ -0,0 +1,166 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+
+	"github.com/yi-nology/git-manage-service/biz/service/credential"
+	"github.com/yi-nology/git-manage-service/biz/service/hostkey"
+	"github.com/yi-nology/git-manage-service/biz/service/sshkey"
+	conf "github.com/yi-nology/git-manage-service/pkg/configs"
+)
+
+// ErrStaleLease is returned when the remote ref no longer has the expected value
+var ErrStaleLease = errors.New("remote ref was updated since the expected commit (stale lease)")
+
+// PushWithLease force-pushes hash to refs/heads/<branch> on remote (a remote name or URL),
+// but only if the remote branch still points at expected. The git CLI is used because
+// go-git does not apply leases to hash refspecs.
+func (s *GitService) PushWithLease(path, remote, hash, branch, expected, authType, authKey, authSecret string) (string, error) {
+	ref := "refs/heads/" + branch
+	output, err := s.runPush(path, remote, authType, authKey, authSecret,
+		fmt.Sprintf("--force-with-lease=%s:%s", ref, expected),
+		fmt.Sprintf("%s:%s", hash, ref))
+	if err != nil {
+		if strings.Contains(output, "stale info") {
+			return output, ErrStaleLease
+		}
+		return output, fmt.Errorf("git push failed: %s, output: %s", err, output)
+	}
+	return output, nil
+}
+
+// runPush runs `git push --porcelain <remote> <args...>` with the given credentials and
+// returns the combined output
+func (s *GitService) runPush(path, remote, authType, authKey, authSecret string, args ...string) (string, error) {
+	if authKey == "" && credential.CredentialSvc != nil {
+		url := remote
+		if out, err := s.RunCommand(path, "remote", "get-url", "--push", remote); err == nil {
+			url = out
+		}
+		if info, ok := credential.CredentialSvc.Resolve(url); ok {
+			authType, authKey, authSecret = info.Type, info.Key, info.Secret
+		}
+	}
+	if authType == "ssh" && authKey != "" {
+		if err := s.checkSSHHost(path, remote); err != nil {
+			return "", err
+		}
+	}
+	authArgs, env, cleanup, err := gitAuthArgs(authType, authKey, authSecret)
+	if err != nil {
+		return "", err
+	}
+	defer cleanup()
+	// Options must precede the remote, refspecs follow it
+	var opts, refspecs []string
+	for _, a := range args {
+		if strings.HasPrefix(a, "--") {
+			opts = append(opts, a)
+		} else {
+			refspecs = append(refspecs, a)
+		}
+	}
+	pushArgs := append(append([]string{"push", "--porcelain"}, opts...), remote)
+	pushArgs = append(pushArgs, refspecs...)
+
+	if conf.DebugMode {
+		log.Printf("[DEBUG] Executing in %s: git %s", path, strings.Join(pushArgs, " "))
+	}
+	cmd, ctx, cancel := s.command(path, env, append(authArgs, pushArgs...)...)
+	defer cancel()
+	out, err := cmd.CombinedOutput()
+	return strings.TrimSpace(string(out)), ctxErr(ctx, pushArgs, err)
+}
+
+// gitAuthArgs maps the stored auth settings onto git CLI config arguments and environment.
+// HTTP credentials are handed over through the environment so they never show up in argv.
+func gitAuthArgs(authType, authKey, authSecret string) ([]string, []string, func(), error) {
+	noop := func() {}
+	switch {
+	case authType == "http" && authKey != "":
+		helper := `!f() { test "$1" = get && echo "username=$GIT_MANAGE_USER" && echo "password=$GIT_MANAGE_PASS"; }; f`
+		return []string{"-c", "credential.helper=", "-c", "credential.helper=" + helper},
+			[]string{"GIT_MANAGE_USER=" + authKey, "GIT_MANAGE_PASS=" + authSecret}, noop, nil
+	case authType == "ssh" && authKey != "":
+		// Only keys from the managed known hosts are accepted, see checkSSHHost
+		knownHosts := os.DevNull
+		if hostkey.HostKeySvc != nil {
+			knownHosts = hostkey.HostKeySvc.KnownHostsFile()
+		}
+		keyFile, cleanup, err := sshIdentityFile(authKey, authSecret)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		sshCmd := fmt.Sprintf("ssh -i %s -o IdentitiesOnly=yes -o BatchMode=yes -o StrictHostKeyChecking=yes -o UserKnownHostsFile=%s",
+			shellQuote(keyFile), shellQuote(knownHosts))
+		return nil, []string{"GIT_SSH_COMMAND=" + sshCmd}, cleanup, nil
+	}
+	return nil, nil, noop, nil
+}
+
+// sshIdentityFile returns a key file ssh can use without prompting. Managed and
+// passphrase protected keys are written decrypted to a private temp file that the
+// returned cleanup removes.
+func sshIdentityFile(authKey, authSecret string) (string, func(), error) {
+	if _, managed := sshkey.ParseRef(authKey); !managed && authSecret == "" {
+		return authKey, func() {}, nil
+	}
+	data, passphrase, err := loadSSHKey(authKey, authSecret)
+	if err != nil {
+		return "", nil, err
+	}
+	plain, err := sshkey.DecryptedPEM(data, passphrase)
+	if err != nil {
+		return "", nil, err
+	}
+	f, err := os.CreateTemp("", "git-manage-key-*")
+	if err != nil {
+		return "", nil, err
+	}
+	cleanup := func() { os.Remove(f.Name()) }
+	// CreateTemp already uses mode 0600
+	if _, err := f.Write(plain); err != nil {
+		f.Close()
+		cleanup()
+		return "", nil, err
+	}
+	if err := f.Close(); err != nil {
+		cleanup()
+		return "", nil, err
+	}
+	return f.Name(), cleanup, nil
+}
+
+// checkSSHHost runs the host key check of the go-git transports for a remote (name or
+// URL) before the git CLI connects to it. ssh itself then only reads the generated
+// known_hosts file, so a first-use key has to be trusted here.
+func (s *GitService) checkSSHHost(path, remote string) error {
+	url := remote
+	if out, err := s.RunCommand(path, "remote", "get-url", "--push", remote); err == nil {
+		url = out
+	}
+	ep, err := transport.NewEndpoint(url)
+	if err != nil || ep.Protocol != "ssh" {
+		return nil
+	}
+	if hostkey.HostKeySvc == nil {
+		return fmt.Errorf("%w: host key store is not initialized", hostkey.ErrHostKeyUntrusted)
+	}
+	port := ep.Port
+	if port == 0 {
+		port = 22
+	}
+	return hostkey.HostKeySvc.Verify(net.JoinHostPort(ep.Host, strconv.Itoa(port)))
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
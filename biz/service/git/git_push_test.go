@@ -0,0 +1,61 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/yi-nology/git-manage-service/biz/service/git/gittest"
+)
+
+func TestPushWithLease(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "git-test-lease")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	remote := filepath.Join(tmpDir, "remote.git")
+	work := filepath.Join(tmpDir, "work")
+	gittest.Run(t, tmpDir, nil, "init", "-q", "--bare", remote)
+	gittest.Run(t, tmpDir, nil, "init", "-q", work)
+
+	s := NewGitService()
+	commit := func(msg string) string {
+		gittest.Run(t, work, nil, "commit", "-q", "--allow-empty", "-m", msg)
+		h, err := s.ResolveRevision(work, "HEAD")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return h
+	}
+	remoteHead := func() string {
+		h, err := s.ResolveRevision(remote, "refs/heads/main")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return h
+	}
+
+	a := commit("a")
+	b := commit("b")
+	gittest.Run(t, work, nil, "push", "-q", remote, b+":refs/heads/main")
+
+	// Lease holds: main is still at b, so it can be moved back to a
+	if _, err := s.PushWithLease(work, remote, a, "main", b, "", "", ""); err != nil {
+		t.Fatal(err)
+	}
+	if remoteHead() != a {
+		t.Fatalf("expected main at %s, got %s", a, remoteHead())
+	}
+
+	// Someone pushed c in the meantime: the lease on b must refuse to clobber it
+	c := commit("c")
+	gittest.Run(t, work, nil, "push", "-q", remote, c+":refs/heads/main")
+	if _, err := s.PushWithLease(work, remote, a, "main", b, "", "", ""); err != ErrStaleLease {
+		t.Fatalf("expected ErrStaleLease, got %v", err)
+	}
+	if remoteHead() != c {
+		t.Fatalf("main should stay at %s, got %s", c, remoteHead())
+	}
+}
@@ -0,0 +1,110 @@
+package git
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/yi-nology/git-manage-service/biz/model/domain"
+)
+
+// rangeLogFormat separates fields with the ASCII unit separator so subjects may contain any text
+const rangeLogFormat = "--format=%H%x1f%an%x1f%ae%x1f%at%x1f%s"
+
+// GetCommitLag returns how many commits are reachable from `to` but not from `from`,
+// together with the commit time of the oldest of them.
+// An empty `from` counts the whole history of `to`.
+func (s *GitService) GetCommitLag(path, from, to string) (int, time.Time, error) {
+	rev := to
+	if from != "" {
+		rev = from + ".." + to
+	}
+
+	out, err := s.RunCommand(path, "log", "--format=%ct", rev)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	if out == "" {
+		return 0, time.Time{}, nil
+	}
+
+	lines := strings.Split(out, "\n")
+	// git log lists newest first, so the last line is the oldest missing commit
+	ts, err := strconv.ParseInt(strings.TrimSpace(lines[len(lines)-1]), 10, 64)
+	if err != nil {
+		return 0, time.Time{}, fmt.Errorf("parse commit time: %w", err)
+	}
+	return len(lines), time.Unix(ts, 0), nil
+}
+
+// ListCommitRange lists the commits reachable from `to` but not from `from`, newest first.
+// An empty `from` lists the whole history of `to`; limit <= 0 means no limit.
+func (s *GitService) ListCommitRange(path, from, to string, limit int) ([]domain.Commit, error) {
+	rev := to
+	if from != "" {
+		rev = from + ".." + to
+	}
+
+	args := []string{"log", rangeLogFormat}
+	if limit > 0 {
+		args = append(args, "-n", strconv.Itoa(limit))
+	}
+	args = append(args, rev)
+
+	out, err := s.RunCommand(path, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var commits []domain.Commit
+	for _, line := range strings.Split(out, "\n") {
+		parts := strings.SplitN(line, "\x1f", 5)
+		if len(parts) < 5 {
+			continue
+		}
+		ts, _ := strconv.ParseInt(parts[3], 10, 64)
+		commits = append(commits, domain.Commit{
+			Hash:      parts[0],
+			Author:    parts[1],
+			Email:     parts[2],
+			Date:      time.Unix(ts, 0),
+			Timestamp: ts,
+			Message:   parts[4],
+		})
+	}
+	return commits, nil
+}
+
+// ForEachCommitInRange calls fn for every commit reachable from `to` but not from `from`,
+// newest first. An empty `from` walks the whole history of `to`.
+func (s *GitService) ForEachCommitInRange(path, from, to string, fn func(c *object.Commit) error) error {
+	args := []string{"rev-list", to}
+	if from != "" {
+		args = append(args, "^"+from)
+	}
+	out, err := s.RunCommand(path, args...)
+	if err != nil {
+		return err
+	}
+	if out == "" {
+		return nil
+	}
+
+	r, err := s.openRepo(path)
+	if err != nil {
+		return err
+	}
+	for _, h := range strings.Split(out, "\n") {
+		c, err := r.CommitObject(plumbing.NewHash(strings.TrimSpace(h)))
+		if err != nil {
+			return err
+		}
+		if err := fn(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrInvalidRevert = errors.New("invalid revert request")
+
+type RevertOptions struct {
+	Commit string
+	Branch string
+	// Mainline is the 1-based parent to keep when reverting a merge commit (git revert -m).
+	// It is required for merge commits and must be 0 otherwise.
+	Mainline    int
+	Message     string // overrides the default "Revert ..." message
+	AuthorName  string
+	AuthorEmail string
+}
+
+type RevertOutcome struct {
+	Success      bool            `json:"success"`
+	Commit       string          `json:"commit"` // the reverted commit
+	Head         string          `json:"head"`
+	PreviousHead string          `json:"previous_head"`
+	UpToDate     bool            `json:"up_to_date"` // the revert produced no change
+	Conflicts    []MergeConflict `json:"conflicts,omitempty"`
+}
+
+// Revert creates a commit on the branch that undoes the given commit. Like merges it runs
+// in an isolated workspace; on conflict the branch is left unchanged and the outcome lists
+// the conflicting files.
+func (s *GitService) Revert(path string, opts RevertOptions) (*RevertOutcome, error) {
+	r, err := s.openRepo(path)
+	if err != nil {
+		return nil, err
+	}
+	c, err := s.resolveCommit(r, opts.Commit)
+	if err != nil {
+		return nil, fmt.Errorf("%w: resolve commit %s failed: %v", ErrInvalidRevert, opts.Commit, err)
+	}
+	switch {
+	case c.NumParents() > 1 && opts.Mainline == 0:
+		return nil, fmt.Errorf("%w: commit %s is a merge, mainline is required", ErrInvalidRevert, opts.Commit)
+	case c.NumParents() <= 1 && opts.Mainline != 0:
+		return nil, fmt.Errorf("%w: mainline is only valid for merge commits", ErrInvalidRevert)
+	case opts.Mainline < 0 || opts.Mainline > c.NumParents():
+		return nil, fmt.Errorf("%w: mainline must be between 1 and %d", ErrInvalidRevert, c.NumParents())
+	}
+	hash := c.Hash.String()
+
+	oldHash, err := s.ResolveRevision(path, "refs/heads/"+opts.Branch)
+	if err != nil {
+		return nil, fmt.Errorf("%w: resolve branch failed: %v", ErrInvalidRevert, err)
+	}
+	if _, err := s.RunCommand(path, "merge-base", "--is-ancestor", hash, oldHash); err != nil {
+		return nil, fmt.Errorf("%w: commit %s is not on branch %s", ErrInvalidRevert, opts.Commit, opts.Branch)
+	}
+
+	outcome := &RevertOutcome{Commit: hash, PreviousHead: oldHash, Head: oldHash}
+	var env []string
+	if opts.AuthorName != "" && opts.AuthorEmail != "" {
+		env = []string{
+			"GIT_AUTHOR_NAME=" + opts.AuthorName, "GIT_AUTHOR_EMAIL=" + opts.AuthorEmail,
+			"GIT_COMMITTER_NAME=" + opts.AuthorName, "GIT_COMMITTER_EMAIL=" + opts.AuthorEmail,
+		}
+	}
+
+	err = s.WithWorkspace(path, oldHash, func(ws *Workspace) error {
+		args := []string{"revert", "--no-commit"}
+		if opts.Mainline > 0 {
+			args = append(args, "-m", fmt.Sprint(opts.Mainline))
+		}
+		if _, err := ws.RunEnv(env, append(args, hash)...); err != nil {
+			conflicts, cErr := ws.Conflicts()
+			if cErr == nil && len(conflicts) > 0 {
+				outcome.Conflicts = conflicts
+				return nil
+			}
+			return fmt.Errorf("revert failed: %v", err)
+		}
+
+		outcome.Success = true
+		if _, err := ws.Run("diff", "--cached", "--quiet"); err == nil {
+			outcome.UpToDate = true
+			return nil
+		}
+		commitArgs := []string{"commit", "--no-edit"}
+		if opts.Message != "" {
+			commitArgs = []string{"commit", "-m", opts.Message}
+		}
+		if _, err := ws.RunEnv(env, commitArgs...); err != nil {
+			return fmt.Errorf("commit failed: %v", err)
+		}
+
+		newHash, err := ws.Head()
+		if err != nil {
+			return err
+		}
+		if err := ws.UpdateBranch(opts.Branch, newHash, oldHash); err != nil {
+			return err
+		}
+		outcome.Head = newHash
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return outcome, nil
+}
@@ -0,0 +1,81 @@
+package git
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yi-nology/git-manage-service/biz/service/git/gittest"
+	conf "github.com/yi-nology/git-manage-service/pkg/configs"
+)
+
+func TestRevert(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "git-test-revert")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	conf.GlobalConfig.Workspace.Root = filepath.Join(tmpDir, "workspaces")
+	defer func() { conf.GlobalConfig.Workspace.Root = "" }()
+
+	repo := gittest.Init(t, filepath.Join(tmpDir, "repo"))
+	repo.CommitFile("a.txt", "1\n")
+	repo.Git("checkout", "-q", "-b", "feature")
+	repo.CommitFile("f.txt", "feature\n")
+	repo.Git("checkout", "-q", "main")
+	bad := repo.CommitFile("b.txt", "bad\n")
+	repo.Git("merge", "-q", "--no-ff", "-m", "merge feature", "feature")
+	mergeCommit := repo.Git("rev-parse", "HEAD")
+	edited := repo.CommitFile("a.txt", "2\n")
+	repo.Git("checkout", "-q", "--detach")
+
+	s := NewGitService()
+
+	out, err := s.Revert(repo.Dir, RevertOptions{Commit: bad[:8], Branch: "main", AuthorName: "Alice", AuthorEmail: "alice@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !out.Success || out.Head != repo.Git("rev-parse", "main") || out.PreviousHead != edited {
+		t.Fatalf("unexpected outcome %+v", out)
+	}
+	if msg := repo.Git("log", "-1", "--format=%an|%B", "main"); !strings.HasPrefix(msg, "Alice|Revert \"change b.txt\"") || !strings.Contains(msg, bad) {
+		t.Errorf("unexpected revert commit %q", msg)
+	}
+	if _, err := os.Stat(filepath.Join(repo.Dir, "b.txt")); err != nil {
+		t.Error("working tree of the repository must not be touched")
+	}
+
+	// Merge commits need a valid mainline
+	if _, err := s.Revert(repo.Dir, RevertOptions{Commit: mergeCommit, Branch: "main"}); !errors.Is(err, ErrInvalidRevert) {
+		t.Errorf("expected ErrInvalidRevert without mainline, got %v", err)
+	}
+	if _, err := s.Revert(repo.Dir, RevertOptions{Commit: mergeCommit, Branch: "main", Mainline: 3}); !errors.Is(err, ErrInvalidRevert) {
+		t.Errorf("expected ErrInvalidRevert for mainline 3, got %v", err)
+	}
+	out, err = s.Revert(repo.Dir, RevertOptions{Commit: mergeCommit, Branch: "main", Mainline: 1})
+	if err != nil || !out.Success {
+		t.Fatalf("merge revert failed: %v %+v", err, out)
+	}
+	if files := repo.Git("ls-tree", "--name-only", "main"); strings.Contains(files, "f.txt") {
+		t.Errorf("feature changes still present after revert: %q", files)
+	}
+
+	// Conflicting revert leaves the branch unchanged
+	commitOnMain := func(content string) {
+		repo.Git("checkout", "-q", "main")
+		repo.CommitFile("a.txt", content)
+		repo.Git("checkout", "-q", "--detach")
+	}
+	commitOnMain("3\n")
+	head := repo.Git("rev-parse", "main")
+	out, err = s.Revert(repo.Dir, RevertOptions{Commit: edited, Branch: "main"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.Success || len(out.Conflicts) != 1 || out.Conflicts[0].Path != "a.txt" || repo.Git("rev-parse", "main") != head {
+		t.Errorf("expected conflict on a.txt, got %+v", out)
+	}
+}
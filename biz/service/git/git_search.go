@@ -0,0 +1,192 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// maxMatchText bounds the length of a returned line (minified files have huge lines)
+const maxMatchText = 500
+
+type GrepOptions struct {
+	Pattern    string
+	Literal    bool // fixed string instead of an extended regular expression
+	IgnoreCase bool
+	Paths      []string // pathspecs limiting the search
+	Context    int      // lines of context around each match
+	MaxMatches int
+}
+
+type GrepMatch struct {
+	Path   string   `json:"path"`
+	Line   int      `json:"line"`
+	Text   string   `json:"text"`
+	Before []string `json:"before,omitempty"`
+	After  []string `json:"after,omitempty"`
+}
+
+type CommitSearchOptions struct {
+	Message    string // pattern matched against the commit message
+	Author     string // pattern matched against author name and email
+	Literal    bool
+	IgnoreCase bool
+	Since      string // passed to git log --since, e.g. "2024-01-01"
+	Until      string
+	Limit      int
+}
+
+// Grep searches the files of ref for the pattern without checking it out. It stops after
+// MaxMatches matches and reports whether more were left; ctx bounds the search time.
+func (s *GitService) Grep(ctx context.Context, repoPath, ref string, opts GrepOptions) ([]GrepMatch, bool, error) {
+	commit, err := s.resolveRefCommit(repoPath, ref)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// -I skips binary files, -z keeps paths with ':' unambiguous
+	args := []string{"grep", "-I", "-n", "-z", "--full-name", "--no-color"}
+	if opts.Literal {
+		args = append(args, "-F")
+	} else {
+		args = append(args, "-E")
+	}
+	if opts.IgnoreCase {
+		args = append(args, "-i")
+	}
+	args = append(args, "-e", opts.Pattern, commit, "--")
+	args = append(args, opts.Paths...)
+
+	stream, err := s.RunCommandStreamContext(ctx, repoPath, args...)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var matches []GrepMatch
+	truncated := false
+	prefix := commit + ":"
+	scanner := bufio.NewScanner(stream)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		// "<commit>:<path>\0<line>\0<text>"
+		parts := bytes.SplitN(scanner.Bytes(), []byte{0}, 3)
+		if len(parts) != 3 {
+			continue
+		}
+		if len(matches) == opts.MaxMatches {
+			truncated = true
+			break
+		}
+		line, _ := strconv.Atoi(string(parts[1]))
+		matches = append(matches, GrepMatch{
+			Path: strings.TrimPrefix(string(parts[0]), prefix),
+			Line: line,
+			Text: clipLine(string(parts[2])),
+		})
+	}
+	scanErr := scanner.Err()
+	closeErr := stream.Close()
+	if ctx.Err() != nil {
+		return nil, false, ctx.Err()
+	}
+	if scanErr != nil {
+		return nil, false, scanErr
+	}
+	// Exit status 1 only means nothing matched; stopping early kills git on purpose
+	var exitErr *exec.ExitError
+	if closeErr != nil && !truncated && !(errors.As(closeErr, &exitErr) && exitErr.ExitCode() == 1) {
+		return nil, false, closeErr
+	}
+
+	if opts.Context > 0 {
+		s.addGrepContext(repoPath, commit, matches, opts.Context)
+	}
+	return matches, truncated, nil
+}
+
+// addGrepContext fills in the lines around each match from the matched files' content
+func (s *GitService) addGrepContext(repoPath, commit string, matches []GrepMatch, n int) {
+	files := make(map[string][]string)
+	for i := range matches {
+		m := &matches[i]
+		lines, ok := files[m.Path]
+		if !ok {
+			if blob, err := s.GetBlob(repoPath, commit, m.Path, 0); err == nil && blob.Content != "" {
+				lines = strings.Split(blob.Content, "\n")
+			}
+			files[m.Path] = lines
+		}
+		if m.Line < 1 || m.Line > len(lines) {
+			continue
+		}
+		for j := max(m.Line-1-n, 0); j < m.Line-1; j++ {
+			m.Before = append(m.Before, clipLine(lines[j]))
+		}
+		for j := m.Line; j < min(m.Line+n, len(lines)); j++ {
+			m.After = append(m.After, clipLine(lines[j]))
+		}
+	}
+}
+
+func clipLine(s string) string {
+	s = strings.TrimSuffix(s, "\r")
+	if len(s) > maxMatchText {
+		return s[:maxMatchText] + "…"
+	}
+	return s
+}
+
+// SearchCommits lists commits reachable from ref whose message and author match
+func (s *GitService) SearchCommits(ctx context.Context, repoPath, ref string, opts CommitSearchOptions) ([]CommitSummary, error) {
+	commit, err := s.resolveRefCommit(repoPath, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	args := []string{"log", "--format=" + summaryFormat, "-n", strconv.Itoa(opts.Limit)}
+	if opts.Literal {
+		args = append(args, "--fixed-strings")
+	} else {
+		args = append(args, "--extended-regexp")
+	}
+	if opts.IgnoreCase {
+		args = append(args, "--regexp-ignore-case")
+	}
+	if opts.Message != "" {
+		args = append(args, "--grep="+opts.Message)
+	}
+	if opts.Author != "" {
+		args = append(args, "--author="+opts.Author)
+	}
+	if opts.Since != "" {
+		args = append(args, "--since="+opts.Since)
+	}
+	if opts.Until != "" {
+		args = append(args, "--until="+opts.Until)
+	}
+	args = append(args, commit, "--")
+
+	stream, err := s.RunCommandStreamContext(ctx, repoPath, args...)
+	if err != nil {
+		return nil, err
+	}
+	commits := []CommitSummary{}
+	scanner := bufio.NewScanner(stream)
+	for scanner.Scan() {
+		if c, ok := parseSummary(scanner.Text()); ok {
+			commits = append(commits, *c)
+		}
+	}
+	closeErr := stream.Close()
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	if closeErr != nil {
+		return nil, closeErr
+	}
+	return commits, nil
+}
@@ -0,0 +1,65 @@
+package git
+
+import (
+	"context"
+	"testing"
+
+	"github.com/yi-nology/git-manage-service/biz/service/git/gittest"
+)
+
+func TestSearch(t *testing.T) {
+	repo := gittest.New(t)
+	repo.Write("src/a.go", "package a\n\n// TODO: fix\nfunc A() {}\n")
+	repo.Commit("add a", "Alice <alice@example.com>")
+	repo.Write("docs/x:y.md", "todo list\nnothing here\n")
+	repo.Commit("add docs", "Bob <bob@example.com>")
+	repo.Write("bin.dat", "TODO\x00binary")
+	repo.Commit("fix binary (1+1)", "Alice <alice@example.com>")
+
+	s := NewGitService()
+	ctx := context.Background()
+
+	matches, truncated, err := s.Grep(ctx, repo.Dir, "main", GrepOptions{Pattern: "todo", IgnoreCase: true, Context: 1, MaxMatches: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if truncated || len(matches) != 2 {
+		t.Fatalf("unexpected matches %+v truncated=%v", matches, truncated)
+	}
+	if matches[0].Path != "docs/x:y.md" || matches[0].Line != 1 || matches[0].Text != "todo list" {
+		t.Fatalf("unexpected first match %+v", matches[0])
+	}
+	if m := matches[1]; m.Path != "src/a.go" || m.Line != 3 || len(m.Before) != 1 || m.Before[0] != "" || len(m.After) != 1 || m.After[0] != "func A() {}" {
+		t.Fatalf("unexpected context %+v", m)
+	}
+
+	matches, truncated, err = s.Grep(ctx, repo.Dir, "main", GrepOptions{Pattern: "TODO", Paths: []string{"src"}, MaxMatches: 10})
+	if err != nil || truncated || len(matches) != 1 || matches[0].Path != "src/a.go" {
+		t.Fatalf("path filter: %+v %v %v", matches, truncated, err)
+	}
+
+	matches, truncated, err = s.Grep(ctx, repo.Dir, "main", GrepOptions{Pattern: "o", MaxMatches: 1})
+	if err != nil || !truncated || len(matches) != 1 {
+		t.Fatalf("limit: %+v %v %v", matches, truncated, err)
+	}
+
+	matches, _, err = s.Grep(ctx, repo.Dir, "main", GrepOptions{Pattern: "no such text", MaxMatches: 10})
+	if err != nil || len(matches) != 0 {
+		t.Fatalf("no match: %+v %v", matches, err)
+	}
+
+	commits, err := s.SearchCommits(ctx, repo.Dir, "main", CommitSearchOptions{Message: "(1+1)", Literal: true, Limit: 10})
+	if err != nil || len(commits) != 1 || commits[0].Subject != "fix binary (1+1)" {
+		t.Fatalf("literal message: %+v %v", commits, err)
+	}
+	commits, err = s.SearchCommits(ctx, repo.Dir, "main", CommitSearchOptions{Message: "^add", Author: "alice", IgnoreCase: true, Limit: 10})
+	if err != nil || len(commits) != 1 || commits[0].Subject != "add a" {
+		t.Fatalf("message and author: %+v %v", commits, err)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, _, err := s.Grep(cancelled, repo.Dir, "main", GrepOptions{Pattern: "TODO", MaxMatches: 10}); err == nil {
+		t.Fatal("expected error for cancelled context")
+	}
+}
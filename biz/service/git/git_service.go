@@ -1,9 +1,12 @@
 package git
 
 import (
+	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"log"
+	"net"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -20,11 +23,19 @@ import (
 	"github.com/go-git/go-git/v5/plumbing/transport/ssh"
 	ssh2 "golang.org/x/crypto/ssh"
 
+	"github.com/yi-nology/git-manage-service/biz/service/credential"
+	"github.com/yi-nology/git-manage-service/biz/service/hostkey"
+	"github.com/yi-nology/git-manage-service/biz/service/sshkey"
+
 	"github.com/yi-nology/git-manage-service/biz/model/domain"
 	conf "github.com/yi-nology/git-manage-service/pkg/configs"
 )
 
-type GitService struct{}
+// GitService runs git operations. Remote operations use go-git or the git CLI; every
+// one of them is bound to the service context (see WithContext) and a timeout.
+type GitService struct {
+	ctx context.Context
+}
 
 func NewGitService() *GitService {
 	return &GitService{}
@@ -36,17 +47,32 @@ func (s *GitService) RunCommand(dir string, args ...string) (string, error) {
 	if conf.DebugMode {
 		log.Printf("[DEBUG] Executing in %s: git %s", dir, strings.Join(args, " "))
 	}
-	cmd := exec.Command("git", args...)
-	cmd.Dir = dir
-	// Prevent password prompts and force English output
-	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0", "LC_ALL=C")
+	cmd, ctx, cancel := s.command(dir, nil, args...)
+	defer cancel()
 	out, err := cmd.CombinedOutput()
 	if err != nil {
-		return string(out), fmt.Errorf("git command failed: %s, output: %s", err, string(out))
+		return string(out), fmt.Errorf("git command failed: %w, output: %s", ctxErr(ctx, args, err), string(out))
 	}
 	return strings.TrimSpace(string(out)), nil
 }
 
+// RunCommandRaw is like RunCommand but keeps stdout byte-exact and leaves stderr out
+// of it. On failure the returned string is stderr.
+func (s *GitService) RunCommandRaw(dir string, args ...string) (string, error) {
+	if conf.DebugMode {
+		log.Printf("[DEBUG] Executing in %s: git %s", dir, strings.Join(args, " "))
+	}
+	cmd, ctx, cancel := s.command(dir, nil, args...)
+	defer cancel()
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return stderr.String(), fmt.Errorf("git command failed: %w, output: %s", ctxErr(ctx, args, err), stderr.String())
+	}
+	return stdout.String(), nil
+}
+
 func (s *GitService) getAuth(authType, authKey, authSecret string) (transport.AuthMethod, error) {
 	if authType == "http" && authKey != "" {
 		return &http.BasicAuth{
@@ -54,16 +80,58 @@ func (s *GitService) getAuth(authType, authKey, authSecret string) (transport.Au
 			Password: authSecret,
 		}, nil
 	} else if authType == "ssh" && authKey != "" {
-		publicKeys, err := ssh.NewPublicKeysFromFile("git", authKey, "")
+		pemBytes, passphrase, err := loadSSHKey(authKey, authSecret)
+		if err != nil {
+			return nil, err
+		}
+		publicKeys, err := ssh.NewPublicKeys("git", pemBytes, passphrase)
 		if err != nil {
 			return nil, err
 		}
-		publicKeys.HostKeyCallback = ssh2.InsecureIgnoreHostKey()
+		publicKeys.HostKeyCallback = hostKeyCallback()
 		return publicKeys, nil
 	}
 	return nil, nil
 }
 
+// loadSSHKey resolves an SSH AuthKey (managed key reference or key file path) to the
+// private key and its passphrase
+func loadSSHKey(authKey, authSecret string) ([]byte, string, error) {
+	if sshkey.SSHKeySvc != nil {
+		return sshkey.SSHKeySvc.LoadPrivateKey(authKey, authSecret)
+	}
+	if _, ok := sshkey.ParseRef(authKey); ok {
+		return nil, "", fmt.Errorf("%w: key store is not initialized", sshkey.ErrKeyNotFound)
+	}
+	data, err := os.ReadFile(authKey)
+	return data, authSecret, err
+}
+
+// authForURL returns the auth of the stored credential bound to the URL, falling back
+// to an SSH key found in the home directory
+func (s *GitService) authForURL(urlStr string) transport.AuthMethod {
+	if credential.CredentialSvc != nil {
+		if info, ok := credential.CredentialSvc.Resolve(urlStr); ok {
+			auth, err := s.getAuth(info.Type, info.Key, info.Secret)
+			if err == nil && auth != nil {
+				return auth
+			}
+			log.Printf("Failed to use stored credential for %s: %v", urlStr, err)
+		}
+	}
+	return s.detectSSHAuth(urlStr)
+}
+
+// hostKeyCallback verifies SSH server keys against the managed known hosts
+func hostKeyCallback() ssh2.HostKeyCallback {
+	if hostkey.HostKeySvc == nil {
+		return func(hostname string, remote net.Addr, key ssh2.PublicKey) error {
+			return fmt.Errorf("%w: host key store is not initialized", hostkey.ErrHostKeyUntrusted)
+		}
+	}
+	return hostkey.HostKeySvc.Callback()
+}
+
 func (s *GitService) detectSSHAuth(urlStr string) transport.AuthMethod {
 	// Simple check for SSH
 	// git@... or ssh://...
@@ -99,7 +167,7 @@ func (s *GitService) detectSSHAuth(urlStr string) transport.AuthMethod {
 				// Try to load with empty password
 				auth, err := ssh.NewPublicKeysFromFile(user, path, "")
 				if err == nil {
-					auth.HostKeyCallback = ssh2.InsecureIgnoreHostKey()
+					auth.HostKeyCallback = hostKeyCallback()
 					if conf.DebugMode {
 						log.Printf("[DEBUG] Using SSH Key: %s", path)
 					}
@@ -113,7 +181,7 @@ func (s *GitService) detectSSHAuth(urlStr string) transport.AuthMethod {
 
 	// 2. Try SSH Agent
 	if auth, err := ssh.NewSSHAgentAuth(user); err == nil {
-		auth.HostKeyCallback = ssh2.InsecureIgnoreHostKey()
+		auth.HostKeyCallback = hostKeyCallback()
 		if conf.DebugMode {
 			log.Printf("[DEBUG] Using SSH Agent Auth")
 		}
@@ -147,11 +215,13 @@ func (s *GitService) Fetch(path, remote string, progress io.Writer) error {
 	if err == nil {
 		urls := rem.Config().URLs
 		if len(urls) > 0 {
-			auth = s.detectSSHAuth(urls[0])
+			auth = s.authForURL(urls[0])
 		}
 	}
 
-	err = r.Fetch(&git.FetchOptions{
+	ctx, cancel := s.opContext(OpFetch)
+	defer cancel()
+	err = r.FetchContext(ctx, &git.FetchOptions{
 		RemoteName: remote,
 		Auth:       auth,
 		Progress:   progress,
@@ -172,6 +242,9 @@ func (s *GitService) FetchWithAuth(path, remoteURL, authType, authKey, authSecre
 	if err != nil {
 		return err
 	}
+	if auth == nil {
+		auth = s.authForURL(remoteURL)
+	}
 
 	// Create a temporary remote to fetch from the URL
 	remote := git.NewRemote(r.Storer, &config.RemoteConfig{
@@ -179,7 +252,9 @@ func (s *GitService) FetchWithAuth(path, remoteURL, authType, authKey, authSecre
 		URLs: []string{remoteURL},
 	})
 
-	err = remote.Fetch(&git.FetchOptions{
+	ctx, cancel := s.opContext(OpFetch)
+	defer cancel()
+	err = remote.FetchContext(ctx, &git.FetchOptions{
 		Auth:       auth,
 		RemoteName: "origin",
 		Progress:   progress,
@@ -201,7 +276,7 @@ func (s *GitService) CloneWithProgress(remoteURL, localPath, authType, authKey,
 	}
 
 	if auth == nil {
-		auth = s.detectSSHAuth(remoteURL)
+		auth = s.authForURL(remoteURL)
 	}
 
 	var progress io.Writer
@@ -209,7 +284,9 @@ func (s *GitService) CloneWithProgress(remoteURL, localPath, authType, authKey,
 		progress = &channelWriter{ch: progressChan}
 	}
 
-	_, err = git.PlainClone(localPath, false, &git.CloneOptions{
+	ctx, cancel := s.opContext(OpClone)
+	defer cancel()
+	_, err = git.PlainCloneContext(ctx, localPath, false, &git.CloneOptions{
 		URL:      remoteURL,
 		Auth:     auth,
 		Progress: progress,
@@ -305,7 +382,7 @@ func (s *GitService) Push(path, targetRemote, sourceHash, targetBranch string, o
 	if err == nil {
 		urls := rem.Config().URLs
 		if len(urls) > 0 {
-			auth = s.detectSSHAuth(urls[0])
+			auth = s.authForURL(urls[0])
 		}
 	}
 
@@ -315,7 +392,9 @@ func (s *GitService) Push(path, targetRemote, sourceHash, targetBranch string, o
 	pushOpts.Auth = auth
 	pushOpts.Progress = progress
 
-	err = r.Push(pushOpts)
+	ctx, cancel := s.opContext(OpPush)
+	defer cancel()
+	err = r.PushContext(ctx, pushOpts)
 	if err == git.NoErrAlreadyUpToDate {
 		return nil
 	}
@@ -517,6 +596,9 @@ func (s *GitService) PushWithAuth(path, targetRemoteURL, sourceHash, targetBranc
 	if err != nil {
 		return err
 	}
+	if auth == nil {
+		auth = s.authForURL(targetRemoteURL)
+	}
 
 	remote := git.NewRemote(r.Storer, &config.RemoteConfig{
 		Name: "anonymous",
@@ -526,11 +608,14 @@ func (s *GitService) PushWithAuth(path, targetRemoteURL, sourceHash, targetBranc
 	refSpec := config.RefSpec(fmt.Sprintf("%s:refs/heads/%s", sourceHash, targetBranch))
 
 	pushOpts := parsePushOptions(options)
+	pushOpts.RemoteName = remote.Config().Name
 	pushOpts.Auth = auth
 	pushOpts.RefSpecs = []config.RefSpec{refSpec}
 	pushOpts.Progress = progress
 
-	err = remote.Push(pushOpts)
+	ctx, cancel := s.opContext(OpPush)
+	defer cancel()
+	err = remote.PushContext(ctx, pushOpts)
 	if err == git.NoErrAlreadyUpToDate {
 		return nil
 	}
@@ -561,20 +646,6 @@ func (s *GitService) GetRepoFiles(path, branch string) ([]string, error) {
 	return files, nil
 }
 
-func (s *GitService) BlameFile(path, branch, file string) (*git.BlameResult, error) {
-	r, err := s.openRepo(path)
-	if err != nil {
-		return nil, err
-	}
-
-	commit, err := s.resolveCommit(r, branch)
-	if err != nil {
-		return nil, err
-	}
-
-	return git.Blame(commit, file)
-}
-
 func (s *GitService) TestRemoteConnection(url string) error {
 	// Create a temporary remote
 	// memory.NewStorage() would be better but nil is accepted for non-persistent remote
@@ -583,9 +654,11 @@ func (s *GitService) TestRemoteConnection(url string) error {
 		URLs: []string{url},
 	})
 
-	auth := s.detectSSHAuth(url)
+	auth := s.authForURL(url)
 
-	_, err := remote.List(&git.ListOptions{
+	ctx, cancel := s.opContext(OpFetch)
+	defer cancel()
+	_, err := remote.ListContext(ctx, &git.ListOptions{
 		Auth: auth,
 	})
 	return err
@@ -741,11 +814,13 @@ func (s *GitService) PushCurrent(path string) error {
 	if err == nil {
 		urls := rem.Config().URLs
 		if len(urls) > 0 {
-			auth = s.detectSSHAuth(urls[0])
+			auth = s.authForURL(urls[0])
 		}
 	}
 
-	err = r.Push(&git.PushOptions{
+	ctx, cancel := s.opContext(OpPush)
+	defer cancel()
+	err = r.PushContext(ctx, &git.PushOptions{
 		Auth: auth,
 	})
 	if err == git.NoErrAlreadyUpToDate {
@@ -785,29 +860,58 @@ func (s *GitService) GetLogStats(path, branch string) (string, error) {
 
 func (s *GitService) GetLogStatsStream(path, branch string) (io.ReadCloser, error) {
 	// git log --numstat --no-merges --pretty=format:"COMMIT|%H|%aN|%aE|%at" <branch>
-	cmd := exec.Command("git", "log", "--numstat", "--no-merges", "--pretty=format:COMMIT|%H|%aN|%aE|%at", branch)
-	cmd.Dir = path
+	return s.RunCommandStream(path, "log", "--numstat", "--no-merges", "--pretty=format:COMMIT|%H|%aN|%aE|%at", branch)
+}
+
+// RunCommandStream starts git and returns its stdout, bound to the timeout of its
+// operation class like RunCommand. Closing the stream waits for the process and releases
+// the timeout; closing it before EOF stops git early.
+func (s *GitService) RunCommandStream(dir string, args ...string) (io.ReadCloser, error) {
+	if conf.DebugMode {
+		log.Printf("[DEBUG] Executing in %s: git %s", dir, strings.Join(args, " "))
+	}
+	cmd, ctx, cancel := s.command(dir, nil, args...)
+	return startStream(ctx, cancel, cmd)
+}
+
+// RunCommandStreamContext is RunCommandStream with git killed once ctx is done instead,
+// for streams read after the operation that started them returned
+func (s *GitService) RunCommandStreamContext(ctx context.Context, dir string, args ...string) (io.ReadCloser, error) {
+	if conf.DebugMode {
+		log.Printf("[DEBUG] Executing in %s: git %s", dir, strings.Join(args, " "))
+	}
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
 	// Prevent password prompts and force English output
 	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0", "LC_ALL=C")
+	return startStream(ctx, func() {}, cmd)
+}
 
+func startStream(ctx context.Context, cancel context.CancelFunc, cmd *exec.Cmd) (io.ReadCloser, error) {
 	stdout, err := cmd.StdoutPipe()
 	if err != nil {
+		cancel()
 		return nil, err
 	}
 
 	if err := cmd.Start(); err != nil {
+		cancel()
 		return nil, err
 	}
 
 	return &cmdStream{
 		cmd:    cmd,
 		stdout: stdout,
+		ctx:    ctx,
+		cancel: cancel,
 	}, nil
 }
 
 type cmdStream struct {
 	cmd    *exec.Cmd
 	stdout io.ReadCloser
+	ctx    context.Context
+	cancel context.CancelFunc
 }
 
 func (c *cmdStream) Read(p []byte) (n int, err error) {
@@ -816,7 +920,9 @@ func (c *cmdStream) Read(p []byte) (n int, err error) {
 
 func (c *cmdStream) Close() error {
 	_ = c.stdout.Close()
-	return c.cmd.Wait()
+	err := ctxErr(c.ctx, c.cmd.Args[1:], c.cmd.Wait())
+	c.cancel()
+	return err
 }
 
 func (s *GitService) GetCommit(path, hashStr string) (*object.Commit, error) {
@@ -997,14 +1103,16 @@ func (s *GitService) PushTag(path, remoteName, tagName, authType, authKey, authS
 		if err == nil {
 			urls := rem.Config().URLs
 			if len(urls) > 0 {
-				auth = s.detectSSHAuth(urls[0])
+				auth = s.authForURL(urls[0])
 			}
 		}
 	}
 
 	refSpec := config.RefSpec(fmt.Sprintf("refs/tags/%s:refs/tags/%s", tagName, tagName))
 
-	err = r.Push(&git.PushOptions{
+	ctx, cancel := s.opContext(OpPush)
+	defer cancel()
+	err = r.PushContext(ctx, &git.PushOptions{
 		RemoteName: remoteName,
 		RefSpecs:   []config.RefSpec{refSpec},
 		Auth:       auth,
@@ -0,0 +1,315 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// DefaultMaxBlobSize is the largest blob whose content GetBlob returns inline
+const DefaultMaxBlobSize = 1 << 20
+
+var (
+	ErrRefNotFound  = errors.New("ref not found")
+	ErrPathNotFound = errors.New("path not found")
+	ErrNotAFile     = errors.New("path is not a file")
+	ErrInvalidPath  = errors.New("invalid path")
+)
+
+type CommitSummary struct {
+	Hash        string `json:"hash"`
+	AuthorName  string `json:"author_name"`
+	AuthorEmail string `json:"author_email"`
+	Timestamp   int64  `json:"timestamp"`
+	Subject     string `json:"subject"`
+}
+
+type TreeEntry struct {
+	Name       string         `json:"name"`
+	Path       string         `json:"path"`
+	Type       string         `json:"type"` // blob, tree, commit (submodule)
+	Mode       string         `json:"mode"`
+	Hash       string         `json:"hash"`
+	Size       int64          `json:"size"` // blobs only
+	LastCommit *CommitSummary `json:"last_commit,omitempty"`
+}
+
+type BlobContent struct {
+	Path     string `json:"path"`
+	Hash     string `json:"hash"`
+	Mode     string `json:"mode"`
+	Size     int64  `json:"size"`
+	Binary   bool   `json:"binary"`
+	TooLarge bool   `json:"too_large"`
+	Content  string `json:"content,omitempty"` // only for text blobs within the size limit
+}
+
+// summaryFormat is parsed by parseSummary
+const summaryFormat = "%H%x1f%an%x1f%ae%x1f%at%x1f%s"
+
+func parseSummary(line string) (*CommitSummary, bool) {
+	f := strings.Split(line, "\x1f")
+	if len(f) != 5 {
+		return nil, false
+	}
+	ts, _ := strconv.ParseInt(f[3], 10, 64)
+	return &CommitSummary{Hash: f[0], AuthorName: f[1], AuthorEmail: f[2], Timestamp: ts, Subject: f[4]}, true
+}
+
+func cleanTreePath(p string) (string, error) {
+	p = strings.Trim(p, "/")
+	if p == "" {
+		return "", nil
+	}
+	if cleaned := path.Clean(p); cleaned != p || strings.HasPrefix(p, "../") || p == ".." {
+		return "", fmt.Errorf("%w: %s", ErrInvalidPath, p)
+	}
+	return p, nil
+}
+
+// resolveRefCommit resolves a branch, tag or commit to a full commit hash
+func (s *GitService) resolveRefCommit(repoPath, ref string) (string, error) {
+	if ref == "" {
+		ref = "HEAD"
+	}
+	if strings.HasPrefix(ref, "-") {
+		return "", fmt.Errorf("%w: %s", ErrRefNotFound, ref)
+	}
+	hash, err := s.RunCommand(repoPath, "rev-parse", "--verify", "-q", ref+"^{commit}")
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", ErrRefNotFound, ref)
+	}
+	return hash, nil
+}
+
+// ListTree lists the directory dir at ref. With withLastCommit each entry also carries
+// the most recent commit that touched it.
+func (s *GitService) ListTree(repoPath, ref, dir string, withLastCommit bool) ([]TreeEntry, error) {
+	dir, err := cleanTreePath(dir)
+	if err != nil {
+		return nil, err
+	}
+	commit, err := s.resolveRefCommit(repoPath, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	treeish := commit + "^{tree}"
+	if dir != "" {
+		treeish = commit + ":" + dir
+		if t, err := s.RunCommand(repoPath, "cat-file", "-t", treeish); err != nil {
+			return nil, ErrPathNotFound
+		} else if t != "tree" {
+			return nil, fmt.Errorf("%w: %s is not a directory", ErrInvalidPath, dir)
+		}
+	}
+	out, err := s.RunCommand(repoPath, "ls-tree", "-l", "-z", treeish)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []TreeEntry{}
+	for _, rec := range strings.Split(out, "\x00") {
+		// "<mode> <type> <hash> <size>\t<name>"
+		meta, name, ok := strings.Cut(rec, "\t")
+		if !ok {
+			continue
+		}
+		f := strings.Fields(meta)
+		if len(f) != 4 {
+			continue
+		}
+		size, _ := strconv.ParseInt(f[3], 10, 64)
+		entries = append(entries, TreeEntry{
+			Name: name,
+			Path: path.Join(dir, name),
+			Type: f[1],
+			Mode: f[0],
+			Hash: f[2],
+			Size: size,
+		})
+	}
+	// Directories first, then files, both by name
+	sortTreeEntries(entries)
+
+	if withLastCommit && len(entries) > 0 {
+		if err := s.fillLastCommits(repoPath, commit, dir, entries); err != nil {
+			return nil, err
+		}
+	}
+	return entries, nil
+}
+
+func sortTreeEntries(entries []TreeEntry) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		if (entries[i].Type == "tree") != (entries[j].Type == "tree") {
+			return entries[i].Type == "tree"
+		}
+		return entries[i].Name < entries[j].Name
+	})
+}
+
+// fillLastCommits walks the history of dir once and stops as soon as every entry has
+// been attributed, instead of running one `git log` per entry.
+func (s *GitService) fillLastCommits(repoPath, commit, dir string, entries []TreeEntry) error {
+	index := make(map[string]int, len(entries))
+	for i, e := range entries {
+		index[e.Name] = i
+	}
+	args := []string{"-c", "core.quotePath=false", "log", "--format=\x1e" + summaryFormat, "--name-only", commit, "--"}
+	if dir != "" {
+		args = append(args, dir)
+	}
+	ctx, cancel := s.opContext(OpLocal)
+	defer cancel()
+	stream, err := s.RunCommandStreamContext(ctx, repoPath, args...)
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+
+	remaining := len(entries)
+	var current *CommitSummary
+	scanner := bufio.NewScanner(stream)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for remaining > 0 && scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "\x1e") {
+			current, _ = parseSummary(line[1:])
+			continue
+		}
+		if line == "" || current == nil {
+			continue
+		}
+		rel := line
+		if dir != "" {
+			rel = strings.TrimPrefix(line, dir+"/")
+		}
+		name, _, _ := strings.Cut(rel, "/")
+		if i, ok := index[name]; ok && entries[i].LastCommit == nil {
+			entries[i].LastCommit = current
+			remaining--
+		}
+	}
+	return nil
+}
+
+// OpenBlob opens the file at ref for reading, along with its size
+func (s *GitService) OpenBlob(repoPath, ref, file string) (io.ReadCloser, int64, error) {
+	f, err := s.treeFile(repoPath, ref, file)
+	if err != nil {
+		return nil, 0, err
+	}
+	reader, err := f.Reader()
+	if err != nil {
+		return nil, 0, err
+	}
+	return reader, f.Size, nil
+}
+
+// GetBlob returns the file at ref. Content is omitted for binary files and for files
+// larger than maxSize.
+func (s *GitService) GetBlob(repoPath, ref, file string, maxSize int64) (*BlobContent, error) {
+	if maxSize <= 0 {
+		maxSize = DefaultMaxBlobSize
+	}
+	f, err := s.treeFile(repoPath, ref, file)
+	if err != nil {
+		return nil, err
+	}
+	blob := &BlobContent{Path: f.Name, Hash: f.Hash.String(), Mode: f.Mode.String(), Size: f.Size}
+
+	reader, err := f.Reader()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	// Same heuristic as git: a NUL byte in the first 8000 bytes means binary
+	head := make([]byte, 8000)
+	n, err := io.ReadFull(reader, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, err
+	}
+	head = head[:n]
+	blob.Binary = bytes.IndexByte(head, 0) >= 0
+	blob.TooLarge = f.Size > maxSize
+	if blob.Binary || blob.TooLarge {
+		return blob, nil
+	}
+	rest, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	blob.Content = string(append(head, rest...))
+	return blob, nil
+}
+
+func (s *GitService) treeFile(repoPath, ref, file string) (*object.File, error) {
+	file, err := cleanTreePath(file)
+	if err != nil {
+		return nil, err
+	}
+	if file == "" {
+		return nil, ErrNotAFile
+	}
+	commitHash, err := s.resolveRefCommit(repoPath, ref)
+	if err != nil {
+		return nil, err
+	}
+	r, err := s.openRepo(repoPath)
+	if err != nil {
+		return nil, err
+	}
+	commit, err := s.resolveCommit(r, commitHash)
+	if err != nil {
+		return nil, err
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, err
+	}
+	entry, err := tree.FindEntry(file)
+	if err != nil {
+		return nil, ErrPathNotFound
+	}
+	if !entry.Mode.IsFile() {
+		return nil, ErrNotAFile
+	}
+	return tree.File(file)
+}
+
+// FileHistory lists the commits touching file (following renames) reachable from ref,
+// newest first.
+func (s *GitService) FileHistory(repoPath, ref, file string, skip, limit int) ([]CommitSummary, error) {
+	file, err := cleanTreePath(file)
+	if err != nil {
+		return nil, err
+	}
+	if file == "" {
+		return nil, ErrNotAFile
+	}
+	commit, err := s.resolveRefCommit(repoPath, ref)
+	if err != nil {
+		return nil, err
+	}
+	out, err := s.RunCommand(repoPath, "log", "--follow", "--format="+summaryFormat,
+		"--skip="+strconv.Itoa(skip), "-n", strconv.Itoa(limit), commit, "--", file)
+	if err != nil {
+		return nil, err
+	}
+	commits := []CommitSummary{}
+	for _, line := range strings.Split(out, "\n") {
+		if c, ok := parseSummary(line); ok {
+			commits = append(commits, *c)
+		}
+	}
+	return commits, nil
+}
@@ -0,0 +1,85 @@
+package git
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/yi-nology/git-manage-service/biz/service/git/gittest"
+)
+
+func TestFileBrowser(t *testing.T) {
+	repo := gittest.New(t)
+	repo.Write("README.md", "hello\n")
+	repo.Write("src/a.go", "package a\n\nfunc A() int {\n\treturn 1\n}\n")
+	repo.Write("bin.dat", "a\x00b")
+	c1 := repo.Commit("initial", "")
+	repo.Git("tag", "v1")
+	repo.Git("mv", "src/a.go", "src/b.go")
+	repo.Write("src/b.go", "package a\n\nfunc A() int {\n\treturn 2\n}\n")
+	c2 := repo.Commit("rename and edit", "")
+
+	s := NewGitService()
+	entries, err := s.ListTree(repo.Dir, "main", "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name)
+	}
+	if strings.Join(names, ",") != "src,README.md,bin.dat" {
+		t.Fatalf("unexpected entries %v", names)
+	}
+	if entries[0].Type != "tree" || entries[0].LastCommit == nil || entries[0].LastCommit.Hash != c2 {
+		t.Errorf("unexpected src entry %+v", entries[0])
+	}
+	if entries[1].Size != 6 || entries[1].Mode != "100644" || entries[1].LastCommit.Hash != c1 {
+		t.Errorf("unexpected README entry %+v", entries[1])
+	}
+
+	entries, err = s.ListTree(repo.Dir, "v1", "src", false)
+	if err != nil || len(entries) != 1 || entries[0].Path != "src/a.go" {
+		t.Errorf("unexpected tree at v1: %+v %v", entries, err)
+	}
+	if _, err := s.ListTree(repo.Dir, "main", "missing", false); err != ErrPathNotFound {
+		t.Errorf("expected ErrPathNotFound, got %v", err)
+	}
+	if _, err := s.ListTree(repo.Dir, "main", "../etc", false); err == nil {
+		t.Error("expected invalid path error")
+	}
+
+	blob, err := s.GetBlob(repo.Dir, "main", "README.md", 0)
+	if err != nil || blob.Binary || blob.Content != "hello\n" {
+		t.Errorf("unexpected text blob %+v %v", blob, err)
+	}
+	blob, err = s.GetBlob(repo.Dir, c1, "bin.dat", 0)
+	if err != nil || !blob.Binary || blob.Content != "" || blob.Size != 3 {
+		t.Errorf("unexpected binary blob %+v %v", blob, err)
+	}
+	blob, err = s.GetBlob(repo.Dir, "main", "src/b.go", 4)
+	if err != nil || !blob.TooLarge || blob.Content != "" {
+		t.Errorf("unexpected large blob %+v %v", blob, err)
+	}
+	if _, err := s.GetBlob(repo.Dir, "main", "src", 0); err != ErrNotAFile {
+		t.Errorf("expected ErrNotAFile, got %v", err)
+	}
+
+	reader, size, err := s.OpenBlob(repo.Dir, "v1", "bin.dat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, _ := io.ReadAll(reader)
+	reader.Close()
+	if size != 3 || string(raw) != "a\x00b" {
+		t.Errorf("unexpected raw content %q", raw)
+	}
+
+	history, err := s.FileHistory(repo.Dir, "main", "src/b.go", 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 2 || history[0].Hash != c2 || history[1].Hash != c1 || history[1].Subject != "initial" {
+		t.Errorf("unexpected history %+v", history)
+	}
+}
@@ -0,0 +1,229 @@
+package git
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	conf "github.com/yi-nology/git-manage-service/pkg/configs"
+)
+
+// Workspace is an ephemeral, detached `git worktree` of a registered repository.
+// Operations that need a working tree (merge, cherry-pick, rebase, ...) run there
+// so the user's own checkout is never touched.
+type Workspace struct {
+	Path     string // worktree directory
+	RepoPath string // repository the worktree belongs to
+	svc      *GitService
+}
+
+// repoLocks serializes workspace operations per repository (keyed by common git dir)
+var repoLocks sync.Map // map[string]*sync.Mutex
+
+func repoLock(gitDir string) *sync.Mutex {
+	l, _ := repoLocks.LoadOrStore(gitDir, &sync.Mutex{})
+	return l.(*sync.Mutex)
+}
+
+func workspaceRoot() string {
+	root := conf.GlobalConfig.Workspace.Root
+	if root == "" {
+		root = filepath.Join(os.TempDir(), "git-manage-workspaces")
+	}
+	if abs, err := filepath.Abs(root); err == nil {
+		root = abs
+	}
+	return root
+}
+
+// InitWorkspaces removes workspaces left behind by a previous crash and prunes
+// their worktree registrations. Every workspace is ephemeral, so at startup none is in use.
+func InitWorkspaces() {
+	root := workspaceRoot()
+	if err := os.MkdirAll(root, 0755); err != nil {
+		log.Printf("[Workspace] Failed to create workspace root %s: %v", root, err)
+		return
+	}
+
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		log.Printf("[Workspace] Failed to read workspace root %s: %v", root, err)
+		return
+	}
+	svc := NewGitService()
+	for _, e := range entries {
+		dir := filepath.Join(root, e.Name())
+		repoGitDir := worktreeOwner(dir)
+		if err := os.RemoveAll(dir); err != nil {
+			log.Printf("[Workspace] Failed to remove stale workspace %s: %v", dir, err)
+			continue
+		}
+		if repoGitDir != "" {
+			svc.RunCommand(repoGitDir, "worktree", "prune")
+		}
+		log.Printf("[Workspace] Removed stale workspace %s", dir)
+	}
+}
+
+// worktreeOwner reads the `.git` file of a worktree ("gitdir: <repo>/.git/worktrees/<name>")
+// and returns the owning repository's git dir.
+func worktreeOwner(dir string) string {
+	data, err := os.ReadFile(filepath.Join(dir, ".git"))
+	if err != nil {
+		return ""
+	}
+	gitdir := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(string(data)), "gitdir:"))
+	if filepath.Base(filepath.Dir(gitdir)) != "worktrees" {
+		return ""
+	}
+	return filepath.Dir(filepath.Dir(gitdir))
+}
+
+// WithWorkspace checks out rev (detached) in a fresh worktree of the repository at
+// repoPath, runs fn in it and removes the worktree afterwards. Calls for the same
+// repository are serialized.
+func (s *GitService) WithWorkspace(repoPath, rev string, fn func(ws *Workspace) error) error {
+	gitDir, err := s.GetGitDir(repoPath)
+	if err != nil {
+		return err
+	}
+	lock := repoLock(gitDir)
+	lock.Lock()
+	defer lock.Unlock()
+
+	root := workspaceRoot()
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return err
+	}
+	suffix := make([]byte, 6)
+	rand.Read(suffix)
+	dir := filepath.Join(root, fmt.Sprintf("%s-%s", filepath.Base(repoPath), hex.EncodeToString(suffix)))
+
+	if _, err := s.RunCommand(repoPath, "worktree", "add", "--detach", dir, rev); err != nil {
+		os.RemoveAll(dir)
+		return fmt.Errorf("create workspace failed: %v", err)
+	}
+	defer s.removeWorkspace(repoPath, dir)
+
+	return fn(&Workspace{Path: dir, RepoPath: repoPath, svc: s})
+}
+
+func (s *GitService) removeWorkspace(repoPath, dir string) {
+	if _, err := s.RunCommand(repoPath, "worktree", "remove", "--force", dir); err != nil {
+		log.Printf("[Workspace] worktree remove failed for %s: %v", dir, err)
+		os.RemoveAll(dir)
+		s.RunCommand(repoPath, "worktree", "prune")
+	}
+}
+
+// Run executes a git command inside the workspace
+func (ws *Workspace) Run(args ...string) (string, error) {
+	return ws.svc.RunCommand(ws.Path, args...)
+}
+
+// RunEnv is Run with extra environment variables (e.g. GIT_AUTHOR_NAME)
+func (ws *Workspace) RunEnv(env []string, args ...string) (string, error) {
+	if conf.DebugMode {
+		log.Printf("[DEBUG] Executing in %s: git %s", ws.Path, strings.Join(args, " "))
+	}
+	cmd, ctx, cancel := ws.svc.command(ws.Path, env, args...)
+	defer cancel()
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return string(out), fmt.Errorf("git command failed: %w, output: %s", ctxErr(ctx, args, err), string(out))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// Conflicts lists the unmerged paths left by a failed merge, cherry-pick or rebase step
+func (ws *Workspace) Conflicts() ([]MergeConflict, error) {
+	out, err := ws.Run("ls-files", "-u", "-z")
+	if err != nil {
+		return nil, err
+	}
+
+	stages, order := parseStages(strings.Split(out, "\x00"))
+
+	conflicts := make([]MergeConflict, 0, len(order))
+	for _, p := range order {
+		st := stages[p]
+		c := MergeConflict{Path: p, Paths: []string{p}, stages: st}
+		switch {
+		case st[2] != "" && st[3] != "" && st[1] == "":
+			c.Type = "add/add"
+		case st[2] != "" && st[3] != "":
+			c.Type = "content"
+		default:
+			c.Type = "modify/delete"
+		}
+		c.Message = fmt.Sprintf("CONFLICT (%s): %s", c.Type, p)
+		if c.Type != "modify/delete" {
+			if data, err := os.ReadFile(filepath.Join(ws.Path, p)); err == nil {
+				c.Hunks = parseConflictHunks(data, ws.svc.readBlob(ws.Path, st[2]), ws.svc.readBlob(ws.Path, st[3]))
+			}
+		}
+		conflicts = append(conflicts, c)
+	}
+	return conflicts, nil
+}
+
+// Head returns the commit currently checked out in the workspace
+func (ws *Workspace) Head() (string, error) {
+	return ws.Run("rev-parse", "HEAD")
+}
+
+// UpdateBranch moves refs/heads/<branch> of the repository from oldHash to newHash.
+// If the branch is checked out in one of the repository's worktrees, that worktree is
+// moved along with `reset --keep`, which refuses to discard local changes.
+func (ws *Workspace) UpdateBranch(branch, newHash, oldHash string) error {
+	return ws.svc.updateBranchRef(ws.RepoPath, branch, newHash, oldHash)
+}
+
+// updateBranchRef is UpdateBranch for callers without a workspace. An empty oldHash
+// requires the branch not to exist yet.
+func (s *GitService) updateBranchRef(repoPath, branch, newHash, oldHash string) error {
+	ref := "refs/heads/" + branch
+
+	checkedOutAt, err := s.branchWorktree(repoPath, ref)
+	if err != nil {
+		return err
+	}
+	if checkedOutAt == "" || oldHash == "" {
+		_, err := s.RunCommand(repoPath, "update-ref", "-m", "git-manage: update "+branch, ref, newHash, oldHash)
+		return err
+	}
+
+	head, err := s.RunCommand(checkedOutAt, "rev-parse", "HEAD")
+	if err != nil {
+		return err
+	}
+	if head != oldHash {
+		return fmt.Errorf("branch %s moved during the operation (now at %s)", branch, head)
+	}
+	if _, err := s.RunCommand(checkedOutAt, "reset", "--keep", newHash); err != nil {
+		return fmt.Errorf("update checked out branch %s failed: %v", branch, err)
+	}
+	return nil
+}
+
+// branchWorktree returns the path of the worktree that has ref checked out, or ""
+func (s *GitService) branchWorktree(repoPath, ref string) (string, error) {
+	out, err := s.RunCommand(repoPath, "worktree", "list", "--porcelain")
+	if err != nil {
+		return "", err
+	}
+	var current string
+	for _, line := range strings.Split(out, "\n") {
+		if p, ok := strings.CutPrefix(line, "worktree "); ok {
+			current = p
+		} else if line == "branch "+ref {
+			return current, nil
+		}
+	}
+	return "", nil
+}
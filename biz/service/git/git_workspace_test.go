@@ -0,0 +1,79 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yi-nology/git-manage-service/biz/service/git/gittest"
+	conf "github.com/yi-nology/git-manage-service/pkg/configs"
+)
+
+func TestMergeInWorkspace(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "git-test-workspace")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	conf.GlobalConfig.Workspace.Root = filepath.Join(tmpDir, "workspaces")
+	defer func() { conf.GlobalConfig.Workspace.Root = "" }()
+
+	repo := gittest.Init(t, filepath.Join(tmpDir, "repo"))
+	repo.Write("a.txt", "a\n")
+	repo.Write("notes.txt", "notes\n")
+	repo.Commit("base", "")
+	repo.Git("branch", "release")
+	repo.Git("checkout", "-q", "-b", "feature")
+	repo.Write("b.txt", "b\n")
+	repo.Commit("feature", "")
+	repo.Git("checkout", "-q", "main")
+
+	// Uncommitted work in the user's checkout must survive every merge
+	repo.Write("notes.txt", "work in progress\n")
+
+	s := NewGitService()
+
+	// Merging into a branch that is not checked out leaves the checkout alone
+	if err := s.Merge(repo.Dir, "feature", "release", "merge feature"); err != nil {
+		t.Fatal(err)
+	}
+	if repo.Git("rev-parse", "--abbrev-ref", "HEAD") != "main" {
+		t.Error("checkout was switched away from main")
+	}
+	if repo.Git("rev-parse", "release") != repo.Git("rev-parse", "feature") {
+		t.Error("release was not fast-forwarded to feature")
+	}
+
+	// Merging into the checked out branch moves the checkout and keeps local changes
+	if err := s.Merge(repo.Dir, "feature", "main", ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(repo.Dir, "b.txt")); err != nil {
+		t.Error("checked out main was not updated")
+	}
+	if data, _ := os.ReadFile(filepath.Join(repo.Dir, "notes.txt")); string(data) != "work in progress\n" {
+		t.Error("local changes were lost")
+	}
+
+	// Workspaces are removed afterwards
+	entries, _ := os.ReadDir(conf.GlobalConfig.Workspace.Root)
+	if len(entries) != 0 {
+		t.Errorf("expected no leftover workspaces, got %d", len(entries))
+	}
+	if out := repo.Git("worktree", "list", "--porcelain"); strings.Count(out, "worktree ") != 1 {
+		t.Errorf("expected only the main worktree, got:\n%s", out)
+	}
+
+	// Crash recovery: a leftover workspace is removed and its registration pruned
+	repo.Git("worktree", "add", "-q", "--detach", filepath.Join(conf.GlobalConfig.Workspace.Root, "repo-stale"), "main")
+	InitWorkspaces()
+	entries, _ = os.ReadDir(conf.GlobalConfig.Workspace.Root)
+	if len(entries) != 0 {
+		t.Errorf("stale workspace not removed")
+	}
+	if out := repo.Git("worktree", "list", "--porcelain"); strings.Count(out, "worktree ") != 1 {
+		t.Errorf("stale worktree not pruned:\n%s", out)
+	}
+}
@@ -0,0 +1,92 @@
+// Package gittest provides scratch git repositories for tests.
+package gittest
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Repo is a scratch repository. Its config carries an identity so that commits made
+// by the code under test work too.
+type Repo struct {
+	t   testing.TB
+	Dir string
+}
+
+// New creates a repository on branch main in a temporary directory
+func New(t testing.TB) *Repo {
+	t.Helper()
+	return Init(t, t.TempDir())
+}
+
+// Init creates a repository on branch main in dir
+func Init(t testing.TB, dir string) *Repo {
+	t.Helper()
+	Run(t, "", nil, "init", "-q", "-b", "main", dir)
+	r := &Repo{t: t, Dir: dir}
+	r.Git("config", "user.name", "t")
+	r.Git("config", "user.email", "t@t")
+	return r
+}
+
+// Run runs git in dir with a fixed identity plus env and returns its trimmed output,
+// failing the test on error
+func Run(t testing.TB, dir string, env []string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(append(os.Environ(), "GIT_AUTHOR_NAME=t", "GIT_AUTHOR_EMAIL=t@t",
+		"GIT_COMMITTER_NAME=t", "GIT_COMMITTER_EMAIL=t@t"), env...)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// Git runs git in the repository
+func (r *Repo) Git(args ...string) string {
+	r.t.Helper()
+	return Run(r.t, r.Dir, nil, args...)
+}
+
+// Write creates or overwrites a file of the work tree, creating its directories
+func (r *Repo) Write(name, content string) {
+	r.t.Helper()
+	p := filepath.Join(r.Dir, name)
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		r.t.Fatal(err)
+	}
+	if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+		r.t.Fatal(err)
+	}
+}
+
+// Commit stages all changes, commits them and returns the commit hash. author is
+// "Name <email>", empty for the default identity.
+func (r *Repo) Commit(msg, author string) string {
+	r.t.Helper()
+	r.Git("add", "-A")
+	args := []string{"commit", "-q", "--allow-empty", "-m", msg}
+	if author != "" {
+		args = append(args, "--author", author)
+	}
+	r.Git(args...)
+	return r.Git("rev-parse", "HEAD")
+}
+
+// CommitFile writes a file and commits it as "change <name>"
+func (r *Repo) CommitFile(name, content string) string {
+	r.t.Helper()
+	return r.CommitFileAs(name, content, "")
+}
+
+// CommitFileAs is CommitFile with an author ("Name <email>")
+func (r *Repo) CommitFileAs(name, content, author string) string {
+	r.t.Helper()
+	r.Write(name, content)
+	return r.Commit("change "+name, author)
+}
@@ -0,0 +1,364 @@
+package hostkey
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+
+	"github.com/yi-nology/git-manage-service/biz/dal/db"
+	"github.com/yi-nology/git-manage-service/biz/model/po"
+	"github.com/yi-nology/git-manage-service/biz/service/audit"
+	"github.com/yi-nology/git-manage-service/biz/service/notify"
+	conf "github.com/yi-nology/git-manage-service/pkg/configs"
+)
+
+const (
+	// ModeTOFU trusts the first key seen for a host (trust on first use)
+	ModeTOFU = "tofu"
+	// ModeApprove records unknown keys as pending and rejects them until approved
+	ModeApprove = "approve"
+)
+
+const (
+	StatusTrusted = "trusted"
+	StatusPending = "pending"
+
+	SourceTOFU   = "tofu"
+	SourceManual = "manual"
+	SourceScan   = "scan"
+)
+
+var (
+	ErrHostKeyChanged   = errors.New("ssh host key changed")
+	ErrHostKeyUntrusted = errors.New("ssh host key not trusted")
+	ErrInvalidHostKey   = errors.New("invalid host key")
+)
+
+// errScanned aborts the handshake once the host key has been captured
+var errScanned = errors.New("host key captured")
+
+type HostKeyService struct {
+	dao  *db.KnownHostDAO
+	mode string
+	file string
+	mu   sync.Mutex // serializes check-and-trust so a host cannot be trusted twice
+}
+
+var HostKeySvc *HostKeyService
+
+func InitHostKeyService() {
+	cfg := conf.GlobalConfig.SSH
+	mode := strings.ToLower(cfg.HostKeyMode)
+	if mode != ModeTOFU && mode != ModeApprove {
+		log.Printf("[HostKey] Unknown host_key_mode %q, using %s", cfg.HostKeyMode, ModeTOFU)
+		mode = ModeTOFU
+	}
+	file := cfg.KnownHostsFile
+	if file == "" {
+		file = "data/known_hosts"
+	}
+	if abs, err := filepath.Abs(file); err == nil {
+		file = abs
+	}
+	HostKeySvc = &HostKeyService{dao: db.NewKnownHostDAO(), mode: mode, file: file}
+	if err := HostKeySvc.writeKnownHosts(); err != nil {
+		log.Printf("[HostKey] Failed to write %s: %v", file, err)
+	}
+}
+
+// Mode returns the configured host key mode
+func (s *HostKeyService) Mode() string {
+	return s.mode
+}
+
+// KnownHostsFile returns the known_hosts file holding the trusted keys, for the git CLI
+func (s *HostKeyService) KnownHostsFile() string {
+	return s.file
+}
+
+// Callback returns a HostKeyCallback checking server keys against the store
+func (s *HostKeyService) Callback() ssh.HostKeyCallback {
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		return s.Check(hostname, key)
+	}
+}
+
+// Check accepts key if it is trusted for the host. A host without trusted keys is
+// trusted on first use or left pending for approval, depending on the mode; a host
+// presenting a key other than its trusted ones is always rejected.
+func (s *HostKeyService) Check(address string, key ssh.PublicKey) error {
+	host := knownhosts.Normalize(address)
+	fp := ssh.FingerprintSHA256(key)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries, err := s.dao.FindByHost(host)
+	if err != nil {
+		return fmt.Errorf("load known hosts failed: %v", err)
+	}
+	var seen, trusted *po.KnownHost
+	for i := range entries {
+		e := &entries[i]
+		if e.Fingerprint == fp {
+			seen = e
+		}
+		if e.Status == StatusTrusted && trusted == nil {
+			trusted = e
+		}
+	}
+
+	if seen != nil && seen.Status == StatusTrusted {
+		s.dao.TouchLastSeen(seen.ID)
+		return nil
+	}
+	if trusted != nil {
+		err := fmt.Errorf("%w: %s presented %s key %s but %s is trusted; if the server key was rotated, delete the old key from the known hosts list and approve the new one",
+			ErrHostKeyChanged, host, key.Type(), fp, trusted.Fingerprint)
+		if notify.NotifySvc != nil {
+			notify.NotifySvc.Notify("HOST_KEY_CHANGED", "host:"+host, err.Error(), map[string]string{
+				"host":      host,
+				"presented": fp,
+				"trusted":   trusted.Fingerprint,
+			})
+		}
+		// Keep the presented key around for review without trusting it
+		if seen == nil {
+			s.record(host, key, StatusPending, SourceScan)
+		}
+		return err
+	}
+
+	if s.mode == ModeTOFU {
+		entry, err := s.trust(seen, host, key, SourceTOFU)
+		if err != nil {
+			return err
+		}
+		log.Printf("[HostKey] Trusted %s key %s for %s on first use", key.Type(), fp, host)
+		if audit.AuditSvc != nil {
+			audit.AuditSvc.Log(nil, "HOST_KEY_TRUSTED", "host:"+host, entry)
+		}
+		return nil
+	}
+
+	if seen == nil {
+		seen, _ = s.record(host, key, StatusPending, SourceScan)
+	}
+	id := uint(0)
+	if seen != nil {
+		id = seen.ID
+	}
+	return fmt.Errorf("%w: %s presented %s key %s, approve known host %d to connect",
+		ErrHostKeyUntrusted, host, key.Type(), fp, id)
+}
+
+// Scan connects to address (host or host:port) and returns the host key it presents
+// without authenticating
+func (s *HostKeyService) Scan(address string) (ssh.PublicKey, error) {
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		address = net.JoinHostPort(strings.Trim(address, "[]"), "22")
+	}
+	var captured ssh.PublicKey
+	client, err := ssh.Dial("tcp", address, &ssh.ClientConfig{
+		User: "git",
+		HostKeyCallback: func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+			captured = key
+			return errScanned
+		},
+		HostKeyAlgorithms: s.preferredAlgorithms(address),
+		Timeout:           10 * time.Second,
+	})
+	if client != nil {
+		client.Close()
+	}
+	if captured == nil {
+		return nil, fmt.Errorf("ssh handshake with %s failed: %v", address, err)
+	}
+	return captured, nil
+}
+
+// Verify scans the host and checks its key, so that the git CLI, which only reads the
+// known_hosts file, sees hosts trusted on first use too
+func (s *HostKeyService) Verify(address string) error {
+	key, err := s.Scan(address)
+	if err != nil {
+		return err
+	}
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		address = net.JoinHostPort(strings.Trim(address, "[]"), "22")
+	}
+	return s.Check(address, key)
+}
+
+// preferredAlgorithms makes a scan negotiate the type of an already trusted key, like
+// OpenSSH does, so a known host is not reported with a different key type
+func (s *HostKeyService) preferredAlgorithms(address string) []string {
+	entries, err := s.dao.FindByHost(knownhosts.Normalize(address))
+	if err != nil {
+		return nil
+	}
+	var algos []string
+	for _, e := range entries {
+		if e.Status != StatusTrusted {
+			continue
+		}
+		switch e.KeyType {
+		case ssh.KeyAlgoRSA:
+			algos = append(algos, ssh.KeyAlgoRSASHA512, ssh.KeyAlgoRSASHA256, ssh.KeyAlgoRSA)
+		default:
+			algos = append(algos, e.KeyType)
+		}
+	}
+	return algos
+}
+
+// Add trusts a key for host. keyData may be an authorized_keys or known_hosts line;
+// when it is empty the key is fetched from the host, i.e. the caller vouches for it.
+func (s *HostKeyService) Add(host, keyData string) (*po.KnownHost, error) {
+	source := SourceManual
+	var key ssh.PublicKey
+	keyData = strings.TrimSpace(keyData)
+	switch {
+	case keyData == "":
+		if host == "" {
+			return nil, fmt.Errorf("%w: host is required", ErrInvalidHostKey)
+		}
+		k, err := s.Scan(host)
+		if err != nil {
+			return nil, err
+		}
+		key, source = k, SourceScan
+	default:
+		if k, _, _, _, err := ssh.ParseAuthorizedKey([]byte(keyData)); err == nil {
+			key = k
+		} else if _, hosts, k, _, _, err := ssh.ParseKnownHosts([]byte(keyData)); err == nil {
+			key = k
+			if host == "" && len(hosts) > 0 {
+				host = hosts[0]
+			}
+		} else {
+			return nil, fmt.Errorf("%w: expected an authorized_keys or known_hosts line", ErrInvalidHostKey)
+		}
+	}
+	if host == "" || strings.HasPrefix(host, "|") {
+		return nil, fmt.Errorf("%w: host is required (hashed known_hosts entries are not supported)", ErrInvalidHostKey)
+	}
+	host = knownhosts.Normalize(host)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entries, err := s.dao.FindByHost(host)
+	if err != nil {
+		return nil, err
+	}
+	var seen *po.KnownHost
+	fp := ssh.FingerprintSHA256(key)
+	for i := range entries {
+		if entries[i].Fingerprint == fp {
+			seen = &entries[i]
+		}
+	}
+	return s.trust(seen, host, key, source)
+}
+
+// Approve trusts a pending key
+func (s *HostKeyService) Approve(id uint) (*po.KnownHost, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, err := s.dao.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	entry.Status = StatusTrusted
+	if err := s.dao.Save(entry); err != nil {
+		return nil, err
+	}
+	return entry, s.writeKnownHosts()
+}
+
+// Delete removes a key; deleting the trusted key of a host lets a new key be trusted
+func (s *HostKeyService) Delete(id uint) (*po.KnownHost, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, err := s.dao.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.dao.Delete(entry); err != nil {
+		return nil, err
+	}
+	return entry, s.writeKnownHosts()
+}
+
+func (s *HostKeyService) record(host string, key ssh.PublicKey, status, source string) (*po.KnownHost, error) {
+	now := time.Now()
+	entry := &po.KnownHost{
+		Host:        host,
+		KeyType:     key.Type(),
+		Fingerprint: ssh.FingerprintSHA256(key),
+		PublicKey:   strings.TrimSpace(string(ssh.MarshalAuthorizedKey(key))),
+		Status:      status,
+		Source:      source,
+		LastSeenAt:  &now,
+	}
+	if err := s.dao.Create(entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// trust marks a recorded key as trusted or records it as such; callers hold s.mu
+func (s *HostKeyService) trust(seen *po.KnownHost, host string, key ssh.PublicKey, source string) (*po.KnownHost, error) {
+	entry := seen
+	if entry == nil {
+		var err error
+		if entry, err = s.record(host, key, StatusTrusted, source); err != nil {
+			return nil, err
+		}
+	} else if entry.Status != StatusTrusted {
+		entry.Status = StatusTrusted
+		if err := s.dao.Save(entry); err != nil {
+			return nil, err
+		}
+	}
+	return entry, s.writeKnownHosts()
+}
+
+// writeKnownHosts regenerates the known_hosts file from the trusted keys
+func (s *HostKeyService) writeKnownHosts() error {
+	entries, err := s.dao.FindByStatus(StatusTrusted)
+	if err != nil {
+		return err
+	}
+	var sb strings.Builder
+	sb.WriteString("# Generated by git-manage-service from the known hosts store, do not edit\n")
+	for _, e := range entries {
+		sb.WriteString(e.Host + " " + e.PublicKey + "\n")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.file), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.file), ".known_hosts-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.WriteString(sb.String()); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.file)
+}
@@ -0,0 +1,171 @@
+package hostkey
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"github.com/yi-nology/git-manage-service/biz/dal/db"
+	"github.com/yi-nology/git-manage-service/biz/model/po"
+)
+
+func newTestService(t *testing.T, mode string) *HostKeyService {
+	var err error
+	db.DB, err = gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.DB.AutoMigrate(&po.KnownHost{}); err != nil {
+		t.Fatal(err)
+	}
+	return &HostKeyService{dao: db.NewKnownHostDAO(), mode: mode, file: filepath.Join(t.TempDir(), "known_hosts")}
+}
+
+func newKey(t *testing.T) (ssh.Signer, ssh.PublicKey) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signer, signer.PublicKey()
+}
+
+func TestCheckTOFU(t *testing.T) {
+	s := newTestService(t, ModeTOFU)
+	_, key1 := newKey(t)
+	_, key2 := newKey(t)
+
+	if err := s.Check("git.example.com:22", key1); err != nil {
+		t.Fatalf("first use: %v", err)
+	}
+	if err := s.Check("git.example.com:22", key1); err != nil {
+		t.Fatalf("known key: %v", err)
+	}
+	content, _ := os.ReadFile(s.file)
+	if !strings.Contains(string(content), "git.example.com "+strings.TrimSpace(string(ssh.MarshalAuthorizedKey(key1)))) {
+		t.Fatalf("known_hosts not written:\n%s", content)
+	}
+
+	err := s.Check("git.example.com:22", key2)
+	if !errors.Is(err, ErrHostKeyChanged) || !strings.Contains(err.Error(), ssh.FingerprintSHA256(key2)) {
+		t.Fatalf("expected host key changed, got %v", err)
+	}
+	// Another port is another host
+	if err := s.Check("git.example.com:2222", key2); err != nil {
+		t.Fatalf("other port: %v", err)
+	}
+
+	// After deleting the old key, the new (pending) one can be approved
+	hosts, _ := s.dao.FindByHost("git.example.com")
+	if len(hosts) != 2 || hosts[1].Status != StatusPending {
+		t.Fatalf("unexpected entries %+v", hosts)
+	}
+	if _, err := s.Delete(hosts[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Approve(hosts[1].ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Check("git.example.com:22", key2); err != nil {
+		t.Fatalf("approved key: %v", err)
+	}
+}
+
+func TestCheckApprove(t *testing.T) {
+	s := newTestService(t, ModeApprove)
+	_, key := newKey(t)
+
+	err := s.Check("[git.example.com]:2222", key)
+	if !errors.Is(err, ErrHostKeyUntrusted) {
+		t.Fatalf("expected untrusted, got %v", err)
+	}
+	if err := s.Check("git.example.com:2222", key); !errors.Is(err, ErrHostKeyUntrusted) {
+		t.Fatalf("pending key must stay untrusted, got %v", err)
+	}
+	hosts, _ := s.dao.FindAll()
+	if len(hosts) != 1 || hosts[0].Host != "[git.example.com]:2222" || hosts[0].Status != StatusPending {
+		t.Fatalf("unexpected entries %+v", hosts)
+	}
+	if _, err := s.Approve(hosts[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Check("git.example.com:2222", key); err != nil {
+		t.Fatalf("approved key: %v", err)
+	}
+
+	// Keys can be added from an authorized_keys line
+	_, other := newKey(t)
+	line := strings.TrimSpace(string(ssh.MarshalAuthorizedKey(other)))
+	if _, err := s.Add("other.example.com", line); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Check("other.example.com:22", other); err != nil {
+		t.Fatalf("added key: %v", err)
+	}
+	if _, err := s.Add("", line); !errors.Is(err, ErrInvalidHostKey) {
+		t.Fatalf("expected missing host error, got %v", err)
+	}
+}
+
+func TestScan(t *testing.T) {
+	s := newTestService(t, ModeApprove)
+	signer, key := newKey(t)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	cfg := &ssh.ServerConfig{
+		PasswordCallback: func(ssh.ConnMetadata, []byte) (*ssh.Permissions, error) {
+			return nil, errors.New("denied")
+		},
+	}
+	cfg.AddHostKey(signer)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				ssh.NewServerConn(conn, cfg)
+			}()
+		}
+	}()
+
+	got, err := s.Scan(ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ssh.FingerprintSHA256(got) != ssh.FingerprintSHA256(key) {
+		t.Fatal("scanned a different key")
+	}
+
+	if err := s.Verify(ln.Addr().String()); !errors.Is(err, ErrHostKeyUntrusted) {
+		t.Fatalf("expected untrusted, got %v", err)
+	}
+	entry, err := s.Add(ln.Addr().String(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry.Source != SourceScan || entry.Status != StatusTrusted {
+		t.Fatalf("unexpected entry %+v", entry)
+	}
+	if err := s.Verify(ln.Addr().String()); err != nil {
+		t.Fatalf("verify trusted host: %v", err)
+	}
+}
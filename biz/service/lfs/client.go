@@ -0,0 +1,157 @@
+package lfs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const mediaType = "application/vnd.git-lfs+json"
+
+// Client talks to one LFS server using the batch API
+// (https://github.com/git-lfs/git-lfs/blob/main/docs/api/batch.md)
+type Client struct {
+	Endpoint string
+	Username string
+	Password string
+	http     *http.Client
+}
+
+func NewClient(endpoint, username, password string) *Client {
+	return &Client{
+		Endpoint: strings.TrimSuffix(endpoint, "/"),
+		Username: username,
+		Password: password,
+		http:     &http.Client{Timeout: 30 * time.Minute},
+	}
+}
+
+type batchRequest struct {
+	Operation string    `json:"operation"`
+	Transfers []string  `json:"transfers"`
+	Objects   []Pointer `json:"objects"`
+}
+
+type batchResponse struct {
+	Objects []batchObject `json:"objects"`
+}
+
+type batchObject struct {
+	Oid     string                 `json:"oid"`
+	Size    int64                  `json:"size"`
+	Actions map[string]batchAction `json:"actions"`
+	Error   *batchError            `json:"error"`
+}
+
+type batchAction struct {
+	Href   string            `json:"href"`
+	Header map[string]string `json:"header"`
+}
+
+type batchError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Batch asks the server for transfer actions ("upload" or "download") for the given objects
+func (c *Client) Batch(operation string, objects []Pointer) ([]batchObject, error) {
+	body, err := json.Marshal(batchRequest{
+		Operation: operation,
+		Transfers: []string{"basic"},
+		Objects:   objects,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, c.Endpoint+"/objects/batch", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", mediaType)
+	req.Header.Set("Content-Type", mediaType)
+	if c.Username != "" {
+		req.SetBasicAuth(c.Username, c.Password)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("lfs batch %s failed: %s %s", operation, resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	var out batchResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("invalid lfs batch response: %v", err)
+	}
+	return out.Objects, nil
+}
+
+// do performs a basic transfer action; the action headers carry their own authorization
+func (c *Client) do(method string, action batchAction, body io.Reader, size int64) (*http.Response, error) {
+	req, err := http.NewRequest(method, action.Href, body)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.ContentLength = size
+	}
+	for k, v := range action.Header {
+		req.Header.Set(k, v)
+	}
+	if _, ok := action.Header["Authorization"]; !ok && c.Username != "" {
+		req.SetBasicAuth(c.Username, c.Password)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s %s: %s", method, action.Href, resp.Status)
+	}
+	return resp, nil
+}
+
+// Download fetches the content of an object through a download action
+func (c *Client) Download(action batchAction) (io.ReadCloser, error) {
+	resp, err := c.do(http.MethodGet, action, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// Upload sends an object through an upload action and, if requested, the verify action
+func (c *Client) Upload(obj batchObject, content io.Reader) error {
+	resp, err := c.do(http.MethodPut, obj.Actions["upload"], content, obj.Size)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	verify, ok := obj.Actions["verify"]
+	if !ok {
+		return nil
+	}
+	body, _ := json.Marshal(Pointer{Oid: obj.Oid, Size: obj.Size})
+	if verify.Header == nil {
+		verify.Header = map[string]string{}
+	}
+	verify.Header["Content-Type"] = mediaType
+	resp, err = c.do(http.MethodPost, verify, bytes.NewReader(body), int64(len(body)))
+	if err != nil {
+		return fmt.Errorf("verify failed: %v", err)
+	}
+	resp.Body.Close()
+	return nil
+}
@@ -0,0 +1,38 @@
+package lfs
+
+import (
+	"net/url"
+	"strings"
+)
+
+// EndpointForRemote derives the LFS server URL from a git remote URL,
+// following the git-lfs defaults (<repo>.git/info/lfs, SSH remotes map to HTTPS).
+// It returns "" for remotes without an HTTP endpoint (e.g. local paths).
+func EndpointForRemote(remoteURL string) string {
+	remoteURL = strings.TrimSpace(remoteURL)
+	var base string
+
+	switch {
+	case strings.HasPrefix(remoteURL, "http://") || strings.HasPrefix(remoteURL, "https://"):
+		base = remoteURL
+	case strings.HasPrefix(remoteURL, "ssh://"):
+		u, err := url.Parse(remoteURL)
+		if err != nil {
+			return ""
+		}
+		base = "https://" + u.Hostname() + u.Path
+	case strings.Contains(remoteURL, "@") && strings.Contains(remoteURL, ":"):
+		// scp-like syntax: git@host:group/repo.git
+		hostPart, repoPath, _ := strings.Cut(remoteURL, ":")
+		host := hostPart[strings.LastIndex(hostPart, "@")+1:]
+		base = "https://" + host + "/" + strings.TrimPrefix(repoPath, "/")
+	default:
+		return ""
+	}
+
+	base = strings.TrimSuffix(base, "/")
+	if !strings.HasSuffix(base, ".git") {
+		base += ".git"
+	}
+	return base + "/info/lfs"
+}
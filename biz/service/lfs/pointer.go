@@ -0,0 +1,67 @@
+package lfs
+
+import (
+	"bufio"
+	"bytes"
+	"strconv"
+	"strings"
+)
+
+// MaxPointerSize is the upper bound of a pointer file, larger blobs are never pointers
+const MaxPointerSize = 1024
+
+const pointerVersion = "version https://git-lfs.github.com/spec/v1"
+
+// Pointer references an object stored outside git in LFS storage
+type Pointer struct {
+	Oid  string `json:"oid"`  // hex sha256 of the content
+	Size int64  `json:"size"` // content size in bytes
+	Path string `json:"-"`    // path the pointer was found at (for reporting)
+}
+
+// ParsePointer parses a git-lfs pointer file; ok is false if data is not a pointer.
+func ParsePointer(data []byte) (p Pointer, ok bool) {
+	if len(data) > MaxPointerSize || !bytes.HasPrefix(data, []byte(pointerVersion)) {
+		return Pointer{}, false
+	}
+
+	hasSize := false
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		key, value, found := strings.Cut(scanner.Text(), " ")
+		if !found {
+			continue
+		}
+		switch key {
+		case "oid":
+			oid, isSHA := strings.CutPrefix(value, "sha256:")
+			if !isSHA || !isSHA256Hex(oid) {
+				return Pointer{}, false
+			}
+			p.Oid = oid
+		case "size":
+			size, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || size < 0 {
+				return Pointer{}, false
+			}
+			p.Size = size
+			hasSize = true
+		}
+	}
+	if p.Oid == "" || !hasSize {
+		return Pointer{}, false
+	}
+	return p, true
+}
+
+func isSHA256Hex(s string) bool {
+	if len(s) != 64 {
+		return false
+	}
+	for _, c := range s {
+		if !(c >= '0' && c <= '9') && !(c >= 'a' && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
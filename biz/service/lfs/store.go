@@ -0,0 +1,60 @@
+package lfs
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// LocalStore is the object directory git-lfs keeps inside a repository (<git-dir>/lfs/objects)
+type LocalStore struct {
+	root string
+}
+
+func NewLocalStore(gitDir string) *LocalStore {
+	return &LocalStore{root: filepath.Join(gitDir, "lfs", "objects")}
+}
+
+func (s *LocalStore) objectPath(oid string) string {
+	return filepath.Join(s.root, oid[0:2], oid[2:4], oid)
+}
+
+// Has reports whether the object exists locally with the expected size
+func (s *LocalStore) Has(p Pointer) bool {
+	fi, err := os.Stat(s.objectPath(p.Oid))
+	return err == nil && fi.Size() == p.Size
+}
+
+func (s *LocalStore) Open(p Pointer) (*os.File, error) {
+	return os.Open(s.objectPath(p.Oid))
+}
+
+// Put stores content read from r after checking its size and sha256
+func (s *LocalStore) Put(p Pointer, r io.Reader) error {
+	dst := s.objectPath(p.Oid)
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(dst), "incomplete-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	h := sha256.New()
+	n, err := io.Copy(io.MultiWriter(tmp, h), r)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	if n != p.Size || hex.EncodeToString(h.Sum(nil)) != p.Oid {
+		return fmt.Errorf("object %s is corrupt (size %d, expected %d)", p.Oid, n, p.Size)
+	}
+	return os.Rename(tmp.Name(), dst)
+}
@@ -0,0 +1,145 @@
+package lfs
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Result summarizes a Sync call
+type Result struct {
+	Pointers int // distinct objects referenced by the range
+	Uploaded int // objects the target did not have yet
+}
+
+// Sync makes sure the target LFS server has every object in pointers.
+// Objects are read from the local store, or downloaded from source when it is set.
+// Objects that cannot be found anywhere are reported together as an error.
+func Sync(pointers []Pointer, store *LocalStore, source, target *Client) (*Result, error) {
+	pointers = dedupe(pointers)
+	res := &Result{Pointers: len(pointers)}
+	if len(pointers) == 0 {
+		return res, nil
+	}
+
+	byOid := make(map[string]Pointer, len(pointers))
+	for _, p := range pointers {
+		byOid[p.Oid] = p
+	}
+
+	objects, err := target.Batch("upload", pointers)
+	if err != nil {
+		return res, err
+	}
+
+	// Objects without an upload action already exist on the target
+	var needed []batchObject
+	for _, obj := range objects {
+		if obj.Error != nil {
+			return res, fmt.Errorf("target rejected object %s: %s", obj.Oid, obj.Error.Message)
+		}
+		if _, ok := obj.Actions["upload"]; ok {
+			needed = append(needed, obj)
+		}
+	}
+	if len(needed) == 0 {
+		return res, nil
+	}
+
+	// Fill the local store from the source server for objects we don't have
+	var missing []string
+	var toDownload []Pointer
+	for _, obj := range needed {
+		if p := byOid[obj.Oid]; !store.Has(p) {
+			toDownload = append(toDownload, p)
+		}
+	}
+	if len(toDownload) > 0 {
+		if source == nil {
+			for _, p := range toDownload {
+				missing = append(missing, describe(p))
+			}
+		} else {
+			missing = fetch(toDownload, store, source)
+		}
+	}
+	if len(missing) > 0 {
+		return res, fmt.Errorf("%d lfs object(s) missing: %s", len(missing), strings.Join(missing, ", "))
+	}
+
+	for _, obj := range needed {
+		p := byOid[obj.Oid]
+		f, err := store.Open(p)
+		if err != nil {
+			return res, err
+		}
+		err = target.Upload(obj, f)
+		f.Close()
+		if err != nil {
+			return res, fmt.Errorf("upload %s failed: %v", describe(p), err)
+		}
+		res.Uploaded++
+	}
+	return res, nil
+}
+
+// fetch downloads objects into the store and returns those that could not be obtained
+func fetch(pointers []Pointer, store *LocalStore, source *Client) []string {
+	var missing []string
+
+	objects, err := source.Batch("download", pointers)
+	if err != nil {
+		for _, p := range pointers {
+			missing = append(missing, describe(p))
+		}
+		return missing
+	}
+
+	found := make(map[string]batchObject, len(objects))
+	for _, obj := range objects {
+		found[obj.Oid] = obj
+	}
+	for _, p := range pointers {
+		obj, ok := found[p.Oid]
+		action, hasAction := obj.Actions["download"]
+		if !ok || obj.Error != nil || !hasAction {
+			missing = append(missing, describe(p))
+			continue
+		}
+		if err := download(p, action, store, source); err != nil {
+			missing = append(missing, fmt.Sprintf("%s (%v)", describe(p), err))
+		}
+	}
+	return missing
+}
+
+func download(p Pointer, action batchAction, store *LocalStore, source *Client) error {
+	body, err := source.Download(action)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+	return store.Put(p, io.LimitReader(body, p.Size+1))
+}
+
+func describe(p Pointer) string {
+	if p.Path != "" {
+		return p.Oid + " (" + p.Path + ")"
+	}
+	return p.Oid
+}
+
+func dedupe(pointers []Pointer) []Pointer {
+	seen := make(map[string]bool, len(pointers))
+	var out []Pointer
+	for _, p := range pointers {
+		if seen[p.Oid] {
+			continue
+		}
+		seen[p.Oid] = true
+		out = append(out, p)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Oid < out[j].Oid })
+	return out
+}
@@ -0,0 +1,165 @@
+package lfs
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// testServer is a minimal in-memory LFS server implementing the batch API and basic transfers
+type testServer struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	srv     *httptest.Server
+}
+
+func newTestServer() *testServer {
+	ts := &testServer{objects: map[string][]byte{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repo.git/info/lfs/objects/batch", ts.batch)
+	mux.HandleFunc("/objects/", ts.object)
+	ts.srv = httptest.NewServer(mux)
+	return ts
+}
+
+func (ts *testServer) endpoint() string { return ts.srv.URL + "/repo.git/info/lfs" }
+
+func (ts *testServer) batch(w http.ResponseWriter, r *http.Request) {
+	var req batchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	var resp batchResponse
+	for _, p := range req.Objects {
+		obj := batchObject{Oid: p.Oid, Size: p.Size}
+		_, exists := ts.objects[p.Oid]
+		href := ts.srv.URL + "/objects/" + p.Oid
+		switch {
+		case req.Operation == "upload" && !exists:
+			obj.Actions = map[string]batchAction{"upload": {Href: href}, "verify": {Href: href + "/verify"}}
+		case req.Operation == "download" && exists:
+			obj.Actions = map[string]batchAction{"download": {Href: href}}
+		case req.Operation == "download":
+			obj.Error = &batchError{Code: 404, Message: "not found"}
+		}
+		resp.Objects = append(resp.Objects, obj)
+	}
+	w.Header().Set("Content-Type", mediaType)
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (ts *testServer) object(w http.ResponseWriter, r *http.Request) {
+	oid := strings.TrimPrefix(r.URL.Path, "/objects/")
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	switch {
+	case strings.HasSuffix(oid, "/verify"):
+		if _, ok := ts.objects[strings.TrimSuffix(oid, "/verify")]; !ok {
+			http.NotFound(w, r)
+		}
+	case r.Method == http.MethodPut:
+		data, _ := io.ReadAll(r.Body)
+		ts.objects[oid] = data
+	case r.Method == http.MethodGet:
+		data, ok := ts.objects[oid]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(data)
+	}
+}
+
+func pointerFor(content string) Pointer {
+	sum := sha256.Sum256([]byte(content))
+	return Pointer{Oid: hex.EncodeToString(sum[:]), Size: int64(len(content))}
+}
+
+func TestParsePointer(t *testing.T) {
+	p := pointerFor("hello")
+	data := fmt.Sprintf("version https://git-lfs.github.com/spec/v1\noid sha256:%s\nsize %d\n", p.Oid, p.Size)
+
+	got, ok := ParsePointer([]byte(data))
+	if !ok || got.Oid != p.Oid || got.Size != p.Size {
+		t.Fatalf("expected %+v, got %+v (ok=%v)", p, got, ok)
+	}
+	if _, ok := ParsePointer([]byte("plain file\n")); ok {
+		t.Fatal("plain content parsed as pointer")
+	}
+}
+
+func TestEndpointForRemote(t *testing.T) {
+	cases := map[string]string{
+		"https://example.com/group/repo.git": "https://example.com/group/repo.git/info/lfs",
+		"https://example.com/group/repo":     "https://example.com/group/repo.git/info/lfs",
+		"git@example.com:group/repo.git":     "https://example.com/group/repo.git/info/lfs",
+		"ssh://git@example.com/group/repo":   "https://example.com/group/repo.git/info/lfs",
+		"/srv/git/repo.git":                  "",
+	}
+	for in, want := range cases {
+		if got := EndpointForRemote(in); got != want {
+			t.Errorf("EndpointForRemote(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestSync(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "lfs-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	store := NewLocalStore(tmpDir)
+
+	source := newTestServer()
+	defer source.srv.Close()
+	target := newTestServer()
+	defer target.srv.Close()
+
+	local := pointerFor("stored locally")
+	if err := store.Put(local, bytes.NewReader([]byte("stored locally"))); err != nil {
+		t.Fatal(err)
+	}
+	remote := pointerFor("only on source")
+	source.objects[remote.Oid] = []byte("only on source")
+	existing := pointerFor("already on target")
+	target.objects[existing.Oid] = []byte("already on target")
+
+	res, err := Sync([]Pointer{local, remote, existing, local}, store,
+		NewClient(source.endpoint(), "", ""), NewClient(target.endpoint(), "", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Pointers != 3 || res.Uploaded != 2 {
+		t.Errorf("expected 3 pointers / 2 uploaded, got %+v", res)
+	}
+	if string(target.objects[remote.Oid]) != "only on source" || string(target.objects[local.Oid]) != "stored locally" {
+		t.Error("target is missing uploaded objects")
+	}
+
+	// An object that exists nowhere fails the sync and is reported
+	missing := pointerFor("lost")
+	missing.Path = "assets/lost.bin"
+	_, err = Sync([]Pointer{missing}, store,
+		NewClient(source.endpoint(), "", ""), NewClient(target.endpoint(), "", ""))
+	if err == nil || !strings.Contains(err.Error(), "assets/lost.bin") {
+		t.Fatalf("expected missing object error, got %v", err)
+	}
+	if _, ok := target.objects[missing.Oid]; ok {
+		t.Error("missing object should not be uploaded")
+	}
+}
@@ -0,0 +1,189 @@
+package maintenance
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	stdsync "sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+
+	"github.com/yi-nology/git-manage-service/biz/dal/db"
+	"github.com/yi-nology/git-manage-service/biz/model/po"
+	"github.com/yi-nology/git-manage-service/biz/service/git"
+	"github.com/yi-nology/git-manage-service/biz/service/notify"
+	"github.com/yi-nology/git-manage-service/pkg/configs"
+)
+
+var (
+	ErrInvalidTask = errors.New("invalid maintenance task")
+	ErrRunning     = errors.New("maintenance is already running for this repository")
+)
+
+// DefaultTasks are run when neither the request nor maintenance.tasks names any
+var DefaultTasks = []string{git.MaintenanceFsck, git.MaintenanceGC, git.MaintenanceCommitGraph}
+
+// MaintenanceService runs fsck, gc, repack and commit-graph on registered repositories,
+// on demand or on the maintenance.schedule, and records their health.
+type MaintenanceService struct {
+	git       *git.GitService
+	repoDAO   *db.RepoDAO
+	healthDAO *db.RepoHealthDAO
+	running   stdsync.Map // repo id -> struct{}
+	sweep     stdsync.Mutex
+}
+
+var MaintenanceSvc *MaintenanceService
+
+func InitMaintenanceService() {
+	MaintenanceSvc = &MaintenanceService{
+		git:       git.NewGitService(),
+		repoDAO:   db.NewRepoDAO(),
+		healthDAO: db.NewRepoHealthDAO(),
+	}
+
+	spec := configs.GlobalConfig.Maintenance.Schedule
+	if spec == "" {
+		log.Println("Scheduled maintenance disabled (maintenance.schedule is empty)")
+		return
+	}
+	c := cron.New()
+	if _, err := c.AddFunc(spec, MaintenanceSvc.RunAll); err != nil {
+		log.Printf("Invalid maintenance.schedule %q, scheduled maintenance disabled: %v", spec, err)
+		return
+	}
+	c.Start()
+	fmt.Printf("Scheduled maintenance started, schedule %q\n", spec)
+}
+
+// Tasks validates the requested tasks, falling back to the configured ones
+func Tasks(requested []string) ([]string, error) {
+	tasks := requested
+	if len(tasks) == 0 {
+		tasks = configs.GlobalConfig.Maintenance.Tasks
+	}
+	if len(tasks) == 0 {
+		tasks = DefaultTasks
+	}
+	for _, t := range tasks {
+		if !git.IsMaintenanceTask(t) {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidTask, t)
+		}
+	}
+	return tasks, nil
+}
+
+// RunAll maintains every registered repository one after another
+func (s *MaintenanceService) RunAll() {
+	if !s.sweep.TryLock() {
+		log.Println("[Maintenance] Previous run still in progress, skipping")
+		return
+	}
+	defer s.sweep.Unlock()
+
+	tasks, err := Tasks(nil)
+	if err != nil {
+		log.Println("[Maintenance]", err)
+		return
+	}
+	repos, err := s.repoDAO.FindAll()
+	if err != nil {
+		log.Println("[Maintenance] Failed to load repos:", err)
+		return
+	}
+	for _, repo := range repos {
+		logf := func(format string, args ...interface{}) {
+			log.Printf("[Maintenance] %s: "+format, append([]interface{}{repo.Name}, args...)...)
+		}
+		if _, err := s.Run(repo, tasks, logf); err != nil && !errors.Is(err, ErrRunning) {
+			logf("%v", err)
+		}
+	}
+}
+
+// Run executes the tasks on the repository and records the outcome. A failing task
+// does not stop the following ones.
+func (s *MaintenanceService) Run(repo po.Repo, tasks []string, logf func(string, ...interface{})) (*po.RepoHealth, error) {
+	if _, busy := s.running.LoadOrStore(repo.ID, struct{}{}); busy {
+		return nil, ErrRunning
+	}
+	defer s.running.Delete(repo.ID)
+
+	health, err := s.healthDAO.FindByRepoID(repo.ID)
+	if err != nil {
+		return nil, err
+	}
+	previous := health.Status
+
+	start := time.Now()
+	var failures []string
+	for _, task := range tasks {
+		logf("Running %s", task)
+		if task == git.MaintenanceFsck {
+			result, err := s.git.Fsck(repo.Path)
+			if err != nil {
+				failures = append(failures, fmt.Sprintf("%s: %v", task, err))
+				logf("%s failed: %v", task, err)
+				continue
+			}
+			now := time.Now()
+			health.FsckAt = &now
+			health.FsckProblems = strings.Join(result.Problems, "\n")
+			for _, w := range result.Warnings {
+				logf("%s", w)
+			}
+			for _, p := range result.Problems {
+				logf("%s", p)
+			}
+			logf("fsck found %d problems", len(result.Problems))
+			continue
+		}
+		out, err := s.git.Maintain(repo.Path, task)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", task, err))
+			logf("%s failed: %v", task, err)
+			continue
+		}
+		if out != "" {
+			logf("%s", out)
+		}
+	}
+
+	health.LastTasks = strings.Join(tasks, ",")
+	health.LastError = strings.Join(failures, "\n")
+	health.LastRunAt = &start
+	health.Duration = time.Since(start).Milliseconds()
+	switch {
+	case health.FsckProblems != "":
+		health.Status = po.RepoHealthCorrupt
+	case len(failures) > 0:
+		health.Status = po.RepoHealthError
+	default:
+		health.Status = po.RepoHealthOK
+	}
+	if err := s.healthDAO.Save(health); err != nil {
+		return nil, err
+	}
+
+	if health.Status == po.RepoHealthCorrupt && previous != po.RepoHealthCorrupt {
+		notify.NotifySvc.Notify("REPO_CORRUPT", "repo:"+repo.Key,
+			fmt.Sprintf("fsck found %d problems in repository %s", len(health.Problems()), repo.Name), health.Problems())
+	} else if health.Status != po.RepoHealthCorrupt && previous == po.RepoHealthCorrupt {
+		notify.NotifySvc.Notify("REPO_CORRUPT_RECOVERED", "repo:"+repo.Key,
+			fmt.Sprintf("fsck of repository %s passed again", repo.Name), nil)
+	}
+	return health, nil
+}
+
+// Running reports whether maintenance is in progress for the repository
+func (s *MaintenanceService) Running(repoID uint) bool {
+	_, ok := s.running.Load(repoID)
+	return ok
+}
+
+// ObjectStats reports the current object database usage of the repository
+func (s *MaintenanceService) ObjectStats(repo po.Repo) (*git.ObjectStats, error) {
+	return s.git.CountObjects(repo.Path)
+}
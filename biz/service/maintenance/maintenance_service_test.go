@@ -0,0 +1,108 @@
+package maintenance
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"github.com/yi-nology/git-manage-service/biz/dal/db"
+	"github.com/yi-nology/git-manage-service/biz/model/po"
+	"github.com/yi-nology/git-manage-service/biz/service/git"
+	"github.com/yi-nology/git-manage-service/biz/service/git/gittest"
+	"github.com/yi-nology/git-manage-service/biz/service/notify"
+)
+
+func newTestService(t *testing.T) *MaintenanceService {
+	var err error
+	db.DB, err = gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.DB.AutoMigrate(&po.Repo{}, &po.RepoHealth{}); err != nil {
+		t.Fatal(err)
+	}
+	notify.InitNotifyService()
+	return &MaintenanceService{git: git.NewGitService(), repoDAO: db.NewRepoDAO(), healthDAO: db.NewRepoHealthDAO()}
+}
+
+func newRepo(t *testing.T) po.Repo {
+	r := gittest.New(t)
+	r.CommitFile("a", "a\n")
+	r.CommitFile("b", "b\n")
+	dir := r.Dir
+	repo := po.Repo{Key: filepath.Base(dir), Name: filepath.Base(dir), Path: dir}
+	if err := db.NewRepoDAO().Create(&repo); err != nil {
+		t.Fatal(err)
+	}
+	return repo
+}
+
+func TestRunHealthy(t *testing.T) {
+	s := newTestService(t)
+	repo := newRepo(t)
+	logf := func(format string, args ...interface{}) { t.Logf(format, args...) }
+
+	before, err := s.ObjectStats(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if before.LooseObjects == 0 || before.Packs != 0 {
+		t.Fatalf("expected only loose objects: %+v", before)
+	}
+
+	health, err := s.Run(repo, DefaultTasks, logf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if health.Status != po.RepoHealthOK || health.FsckAt == nil || health.LastError != "" {
+		t.Fatalf("unexpected health: %+v", health)
+	}
+
+	after, err := s.ObjectStats(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after.LooseObjects != 0 || after.Packs != 1 || after.PackedObjects != before.LooseObjects {
+		t.Fatalf("gc did not pack objects: before %+v, after %+v", before, after)
+	}
+	if _, err := os.Stat(filepath.Join(repo.Path, ".git", "objects", "info", "commit-graph")); err != nil {
+		t.Fatalf("commit-graph not written: %v", err)
+	}
+}
+
+func TestRunCorrupt(t *testing.T) {
+	s := newTestService(t)
+	repo := newRepo(t)
+	logf := func(string, ...interface{}) {}
+
+	// Drop the blob of file a
+	blob := gittest.Run(t, repo.Path, nil, "rev-parse", "HEAD:a")
+	if err := os.Remove(filepath.Join(repo.Path, ".git", "objects", blob[:2], blob[2:])); err != nil {
+		t.Fatal(err)
+	}
+
+	health, err := s.Run(repo, []string{git.MaintenanceFsck}, logf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if health.Status != po.RepoHealthCorrupt || len(health.Problems()) == 0 {
+		t.Fatalf("corruption not flagged: %+v", health)
+	}
+
+	stored, err := db.NewRepoHealthDAO().FindByRepoID(repo.ID)
+	if err != nil || stored.Status != po.RepoHealthCorrupt {
+		t.Fatalf("health not stored: %+v, %v", stored, err)
+	}
+}
+
+func TestTasks(t *testing.T) {
+	if tasks, err := Tasks(nil); err != nil || len(tasks) != len(DefaultTasks) {
+		t.Fatalf("default tasks: %v, %v", tasks, err)
+	}
+	if _, err := Tasks([]string{"fsck", "prune --now"}); err == nil {
+		t.Fatal("unknown task accepted")
+	}
+}
@@ -0,0 +1,72 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/yi-nology/git-manage-service/biz/service/audit"
+	"github.com/yi-nology/git-manage-service/pkg/configs"
+)
+
+// Event is the payload delivered for every notification
+type Event struct {
+	Type    string      `json:"type"`   // e.g. SYNC_LAG
+	Target  string      `json:"target"` // task:abc, repo:xyz
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+	Time    time.Time   `json:"time"`
+}
+
+type NotifyService struct {
+	client *http.Client
+}
+
+var NotifySvc *NotifyService
+
+func InitNotifyService() {
+	NotifySvc = &NotifyService{
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Notify logs the event, records it in the audit log and, if configured,
+// posts it to the notification webhook. Delivery failures are only logged.
+func (s *NotifyService) Notify(eventType, target, message string, data interface{}) {
+	evt := Event{
+		Type:    eventType,
+		Target:  target,
+		Message: message,
+		Data:    data,
+		Time:    time.Now(),
+	}
+
+	log.Printf("[Notify] %s %s: %s", evt.Type, evt.Target, evt.Message)
+	if audit.AuditSvc != nil {
+		audit.AuditSvc.Log(nil, "NOTIFY_"+evt.Type, evt.Target, evt)
+	}
+
+	url := configs.GlobalConfig.Notify.WebhookURL
+	if url == "" {
+		return
+	}
+
+	go func() {
+		body, err := json.Marshal(evt)
+		if err != nil {
+			log.Printf("[Notify] Failed to encode event: %v", err)
+			return
+		}
+		resp, err := s.client.Post(url, "application/json", bytes.NewReader(body))
+		if err != nil {
+			log.Printf("[Notify] Failed to deliver event: %v", err)
+			return
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			log.Printf("[Notify] Webhook responded with status %d", resp.StatusCode)
+		}
+	}()
+}
@@ -0,0 +1,219 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/yi-nology/git-manage-service/biz/dal/db"
+	"github.com/yi-nology/git-manage-service/biz/model/po"
+	"github.com/yi-nology/git-manage-service/biz/service/git"
+	conf "github.com/yi-nology/git-manage-service/pkg/configs"
+)
+
+const (
+	maxPatternLength = 1000
+	maxContextLines  = 5
+)
+
+var (
+	ErrInvalidQuery = errors.New("invalid search query")
+	ErrRepoNotFound = errors.New("repo not found")
+)
+
+type CodeQuery struct {
+	RepoKeys   []string // all registered repositories when empty
+	Ref        string   // defaults to each repository's HEAD
+	Pattern    string
+	Literal    bool
+	IgnoreCase bool
+	Paths      []string
+	Context    int
+	MaxMatches int // per repository
+}
+
+type CommitQuery struct {
+	RepoKeys   []string
+	Ref        string
+	Message    string
+	Author     string
+	Literal    bool
+	IgnoreCase bool
+	Since      string
+	Until      string
+	Limit      int // per repository
+}
+
+// RepoResult is the outcome of a query in one repository. A repository that fails or
+// times out reports Error without failing the whole search.
+type RepoResult struct {
+	RepoKey   string              `json:"repo_key"`
+	RepoName  string              `json:"repo_name"`
+	Matches   []git.GrepMatch     `json:"matches,omitempty"`
+	Commits   []git.CommitSummary `json:"commits,omitempty"`
+	Truncated bool                `json:"truncated"`
+	Error     string              `json:"error,omitempty"`
+}
+
+type SearchService struct {
+	git        *git.GitService
+	repoDAO    *db.RepoDAO
+	slots      chan struct{} // bounds concurrent git processes across all searches
+	timeout    time.Duration
+	maxMatches int
+}
+
+var SearchSvc *SearchService
+
+func InitSearchService() {
+	cfg := conf.GlobalConfig.Search
+	timeout, err := time.ParseDuration(cfg.Timeout)
+	if err != nil || timeout <= 0 {
+		if cfg.Timeout != "" {
+			log.Printf("[Search] Invalid timeout %q, using 10s", cfg.Timeout)
+		}
+		timeout = 10 * time.Second
+	}
+	concurrency := cfg.MaxConcurrency
+	if concurrency <= 0 {
+		concurrency = 4
+	}
+	maxMatches := cfg.MaxMatches
+	if maxMatches <= 0 {
+		maxMatches = 200
+	}
+	SearchSvc = &SearchService{
+		git:        git.NewGitService(),
+		repoDAO:    db.NewRepoDAO(),
+		slots:      make(chan struct{}, concurrency),
+		timeout:    timeout,
+		maxMatches: maxMatches,
+	}
+}
+
+// Code runs a content search in every selected repository
+func (s *SearchService) Code(ctx context.Context, q CodeQuery) ([]RepoResult, error) {
+	if q.Pattern == "" || len(q.Pattern) > maxPatternLength {
+		return nil, fmt.Errorf("%w: pattern must be 1-%d characters", ErrInvalidQuery, maxPatternLength)
+	}
+	if !q.Literal {
+		if _, err := regexp.CompilePOSIX(q.Pattern); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidQuery, err)
+		}
+	}
+	q.Context = min(max(q.Context, 0), maxContextLines)
+	q.MaxMatches = s.limit(q.MaxMatches)
+
+	return s.each(ctx, q.RepoKeys, func(ctx context.Context, repo po.Repo, res *RepoResult) error {
+		matches, truncated, err := s.git.Grep(ctx, repo.Path, q.Ref, git.GrepOptions{
+			Pattern:    q.Pattern,
+			Literal:    q.Literal,
+			IgnoreCase: q.IgnoreCase,
+			Paths:      q.Paths,
+			Context:    q.Context,
+			MaxMatches: q.MaxMatches,
+		})
+		res.Matches, res.Truncated = matches, truncated
+		return err
+	})
+}
+
+// Commits searches commit messages and authors in every selected repository
+func (s *SearchService) Commits(ctx context.Context, q CommitQuery) ([]RepoResult, error) {
+	if q.Message == "" && q.Author == "" {
+		return nil, fmt.Errorf("%w: message or author is required", ErrInvalidQuery)
+	}
+	if len(q.Message) > maxPatternLength || len(q.Author) > maxPatternLength {
+		return nil, fmt.Errorf("%w: pattern longer than %d characters", ErrInvalidQuery, maxPatternLength)
+	}
+	if !q.Literal {
+		for _, p := range []string{q.Message, q.Author} {
+			if _, err := regexp.CompilePOSIX(p); err != nil {
+				return nil, fmt.Errorf("%w: %v", ErrInvalidQuery, err)
+			}
+		}
+	}
+	limit := s.limit(q.Limit)
+
+	return s.each(ctx, q.RepoKeys, func(ctx context.Context, repo po.Repo, res *RepoResult) error {
+		// Ask for one more to know whether the list was cut
+		commits, err := s.git.SearchCommits(ctx, repo.Path, q.Ref, git.CommitSearchOptions{
+			Message:    q.Message,
+			Author:     q.Author,
+			Literal:    q.Literal,
+			IgnoreCase: q.IgnoreCase,
+			Since:      q.Since,
+			Until:      q.Until,
+			Limit:      limit + 1,
+		})
+		if len(commits) > limit {
+			commits, res.Truncated = commits[:limit], true
+		}
+		res.Commits = commits
+		return err
+	})
+}
+
+func (s *SearchService) limit(requested int) int {
+	if requested <= 0 || requested > s.maxMatches {
+		return s.maxMatches
+	}
+	return requested
+}
+
+// each runs fn for every selected repository, at most len(slots) at a time and each
+// bounded by the search timeout. Results keep the repository order.
+func (s *SearchService) each(ctx context.Context, repoKeys []string, fn func(ctx context.Context, repo po.Repo, res *RepoResult) error) ([]RepoResult, error) {
+	repos, err := s.selectRepos(repoKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]RepoResult, len(repos))
+	var wg sync.WaitGroup
+	for i, repo := range repos {
+		results[i] = RepoResult{RepoKey: repo.Key, RepoName: repo.Name}
+		wg.Add(1)
+		go func(repo po.Repo, res *RepoResult) {
+			defer wg.Done()
+			select {
+			case s.slots <- struct{}{}:
+				defer func() { <-s.slots }()
+			case <-ctx.Done():
+				res.Error = ctx.Err().Error()
+				return
+			}
+
+			repoCtx, cancel := context.WithTimeout(ctx, s.timeout)
+			defer cancel()
+			if err := fn(repoCtx, repo, res); err != nil {
+				if errors.Is(err, context.DeadlineExceeded) {
+					res.Error = fmt.Sprintf("search timed out after %s", s.timeout)
+				} else {
+					res.Error = err.Error()
+				}
+			}
+		}(repo, &results[i])
+	}
+	wg.Wait()
+	return results, nil
+}
+
+func (s *SearchService) selectRepos(keys []string) ([]po.Repo, error) {
+	if len(keys) == 0 {
+		return s.repoDAO.FindAll()
+	}
+	repos := make([]po.Repo, 0, len(keys))
+	for _, key := range keys {
+		repo, err := s.repoDAO.FindByKey(key)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrRepoNotFound, key)
+		}
+		repos = append(repos, *repo)
+	}
+	return repos, nil
+}
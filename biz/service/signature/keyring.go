@@ -0,0 +1,92 @@
+package signature
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"golang.org/x/crypto/ssh"
+
+	"github.com/yi-nology/git-manage-service/biz/model/po"
+)
+
+const (
+	KeyTypeGPG = "gpg"
+	KeyTypeSSH = "ssh"
+)
+
+// ParseKey validates public key material and fills in type, fingerprint and identity
+func ParseKey(keyType, data string) (*po.TrustedKey, error) {
+	data = strings.TrimSpace(data)
+	key := &po.TrustedKey{Type: keyType, KeyData: data}
+
+	switch keyType {
+	case KeyTypeGPG:
+		entities, err := openpgp.ReadArmoredKeyRing(strings.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("invalid gpg public key: %v", err)
+		}
+		if len(entities) != 1 {
+			return nil, fmt.Errorf("expected exactly one gpg key, got %d", len(entities))
+		}
+		e := entities[0]
+		if e.PrivateKey != nil {
+			return nil, errors.New("refusing to store a private key")
+		}
+		key.Fingerprint = fmt.Sprintf("%X", e.PrimaryKey.Fingerprint)
+		var ids []string
+		for name := range e.Identities {
+			ids = append(ids, name)
+		}
+		key.Identity = strings.Join(ids, ", ")
+	case KeyTypeSSH:
+		pub, comment, _, _, err := ssh.ParseAuthorizedKey([]byte(data))
+		if err != nil {
+			return nil, fmt.Errorf("invalid ssh public key: %v", err)
+		}
+		key.Fingerprint = ssh.FingerprintSHA256(pub)
+		key.Identity = comment
+	default:
+		return nil, fmt.Errorf("unsupported key type: %s", keyType)
+	}
+	return key, nil
+}
+
+// Keyring holds the parsed trusted keys used to verify commits
+type Keyring struct {
+	gpg []gpgKey
+	ssh map[string]sshKey // keyed by SHA256 fingerprint
+}
+
+type gpgKey struct {
+	armored string
+	name    string
+}
+
+type sshKey struct {
+	pub  ssh.PublicKey
+	name string
+}
+
+// NewKeyring builds a keyring from stored keys; keys that fail to parse are skipped
+func NewKeyring(keys []po.TrustedKey) *Keyring {
+	kr := &Keyring{ssh: make(map[string]sshKey)}
+	for _, k := range keys {
+		switch k.Type {
+		case KeyTypeGPG:
+			kr.gpg = append(kr.gpg, gpgKey{armored: k.KeyData, name: k.Name})
+		case KeyTypeSSH:
+			pub, _, _, _, err := ssh.ParseAuthorizedKey([]byte(k.KeyData))
+			if err != nil {
+				continue
+			}
+			kr.ssh[ssh.FingerprintSHA256(pub)] = sshKey{pub: pub, name: k.Name}
+		}
+	}
+	return kr
+}
+
+func (kr *Keyring) Empty() bool {
+	return len(kr.gpg) == 0 && len(kr.ssh) == 0
+}
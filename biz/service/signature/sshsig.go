@@ -0,0 +1,112 @@
+package signature
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// SSH signatures use the SSHSIG format of OpenSSH (PROTOCOL.sshsig), git signs with namespace "git".
+const (
+	sshArmorStart = "-----BEGIN SSH SIGNATURE-----"
+	sshArmorEnd   = "-----END SSH SIGNATURE-----"
+	sshSigMagic   = "SSHSIG"
+	sshNamespace  = "git"
+)
+
+type sshSigBlob struct {
+	Version       uint32
+	PublicKey     []byte
+	Namespace     string
+	Reserved      string
+	HashAlgorithm string
+	Signature     []byte
+}
+
+type sshSignedData struct {
+	Namespace     string
+	Reserved      string
+	HashAlgorithm string
+	Hash          []byte
+}
+
+type sshWireSignature struct {
+	Format string
+	Blob   []byte
+	Rest   []byte `ssh:"rest"`
+}
+
+func (kr *Keyring) verifySSH(armored string, payload []byte) (string, error) {
+	blob, err := parseSSHSig(armored)
+	if err != nil {
+		return "", err
+	}
+	if blob.Namespace != sshNamespace {
+		return "", fmt.Errorf("unexpected ssh signature namespace %q", blob.Namespace)
+	}
+
+	signer, err := ssh.ParsePublicKey(blob.PublicKey)
+	if err != nil {
+		return "", fmt.Errorf("invalid ssh signature key: %v", err)
+	}
+	trusted, ok := kr.ssh[ssh.FingerprintSHA256(signer)]
+	if !ok {
+		return "", fmt.Errorf("ssh key %s is not trusted", ssh.FingerprintSHA256(signer))
+	}
+
+	var h hash.Hash
+	switch blob.HashAlgorithm {
+	case "sha256":
+		h = sha256.New()
+	case "sha512":
+		h = sha512.New()
+	default:
+		return "", fmt.Errorf("unsupported ssh signature hash %q", blob.HashAlgorithm)
+	}
+	h.Write(payload)
+
+	signed := append([]byte(sshSigMagic), ssh.Marshal(sshSignedData{
+		Namespace:     blob.Namespace,
+		Reserved:      blob.Reserved,
+		HashAlgorithm: blob.HashAlgorithm,
+		Hash:          h.Sum(nil),
+	})...)
+
+	var sig sshWireSignature
+	if err := ssh.Unmarshal(blob.Signature, &sig); err != nil {
+		return "", fmt.Errorf("invalid ssh signature: %v", err)
+	}
+	if err := trusted.pub.Verify(signed, &ssh.Signature{Format: sig.Format, Blob: sig.Blob, Rest: sig.Rest}); err != nil {
+		return "", fmt.Errorf("bad ssh signature: %v", err)
+	}
+	return trusted.name, nil
+}
+
+func parseSSHSig(armored string) (*sshSigBlob, error) {
+	body := strings.TrimSpace(armored)
+	body = strings.TrimPrefix(body, sshArmorStart)
+	body = strings.TrimSuffix(body, sshArmorEnd)
+	raw, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(body), ""))
+	if err != nil {
+		return nil, fmt.Errorf("invalid ssh signature armor: %v", err)
+	}
+	if !bytes.HasPrefix(raw, []byte(sshSigMagic)) {
+		return nil, errors.New("invalid ssh signature magic")
+	}
+
+	var blob sshSigBlob
+	if err := ssh.Unmarshal(raw[len(sshSigMagic):], &blob); err != nil {
+		return nil, fmt.Errorf("invalid ssh signature: %v", err)
+	}
+	if blob.Version != 1 {
+		return nil, fmt.Errorf("unsupported ssh signature version %d", blob.Version)
+	}
+	return &blob, nil
+}
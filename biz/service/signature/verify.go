@@ -0,0 +1,84 @@
+package signature
+
+import (
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+var ErrUnsigned = errors.New("commit is not signed")
+
+// Signature states reported by Status
+const (
+	StatusUnsigned   = "unsigned"
+	StatusVerified   = "verified"
+	StatusUnverified = "unverified" // signed, but not by a trusted key or not valid
+)
+
+type SignatureStatus struct {
+	Status string `json:"status"`
+	Key    string `json:"key,omitempty"`    // name of the trusted key that made a verified signature
+	Reason string `json:"reason,omitempty"` // why a signature could not be verified
+}
+
+// Status verifies the commit signature for display rather than enforcement
+func (kr *Keyring) Status(c *object.Commit) SignatureStatus {
+	key, err := kr.VerifyCommit(c)
+	switch {
+	case errors.Is(err, ErrUnsigned):
+		return SignatureStatus{Status: StatusUnsigned}
+	case err != nil:
+		return SignatureStatus{Status: StatusUnverified, Reason: err.Error()}
+	}
+	return SignatureStatus{Status: StatusVerified, Key: key}
+}
+
+// VerifyCommit checks the commit signature against the keyring and returns the name of the signing key
+func (kr *Keyring) VerifyCommit(c *object.Commit) (string, error) {
+	sig := strings.TrimSpace(c.PGPSignature)
+	if sig == "" {
+		return "", ErrUnsigned
+	}
+
+	switch {
+	case strings.HasPrefix(sig, sshArmorStart):
+		payload, err := signedPayload(c)
+		if err != nil {
+			return "", err
+		}
+		return kr.verifySSH(sig, payload)
+	case strings.HasPrefix(sig, "-----BEGIN PGP SIGNATURE-----"):
+		return kr.verifyGPG(c)
+	default:
+		return "", errors.New("unsupported signature format")
+	}
+}
+
+func (kr *Keyring) verifyGPG(c *object.Commit) (string, error) {
+	if len(kr.gpg) == 0 {
+		return "", errors.New("gpg signature but no trusted gpg keys")
+	}
+	for _, k := range kr.gpg {
+		if _, err := c.Verify(k.armored); err == nil {
+			return k.name, nil
+		}
+	}
+	return "", errors.New("gpg signature does not match any trusted key")
+}
+
+// signedPayload is the commit object without its gpgsig header, which is what git signs
+func signedPayload(c *object.Commit) ([]byte, error) {
+	obj := &plumbing.MemoryObject{}
+	if err := c.EncodeWithoutSignature(obj); err != nil {
+		return nil, err
+	}
+	r, err := obj.Reader()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
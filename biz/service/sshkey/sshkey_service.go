@@ -0,0 +1,239 @@
+package sshkey
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/yi-nology/git-manage-service/biz/dal/db"
+	"github.com/yi-nology/git-manage-service/biz/model/po"
+)
+
+// RefPrefix marks a managed key in an AuthKey field: "sshkey:<id>". Any other SSH
+// AuthKey is a private key file path.
+const RefPrefix = "sshkey:"
+
+const (
+	TypeED25519 = "ed25519"
+	TypeRSA     = "rsa"
+	TypeECDSA   = "ecdsa"
+)
+
+var (
+	ErrInvalidKey         = errors.New("invalid ssh key")
+	ErrPassphraseRequired = errors.New("ssh key is passphrase protected")
+	ErrKeyNotFound        = errors.New("ssh key not found")
+	ErrKeyInUse           = errors.New("ssh key is in use")
+	ErrKeyExists          = errors.New("ssh key with the same name or fingerprint already exists")
+)
+
+// Ref returns the AuthKey value referencing a managed key
+func Ref(id uint) string {
+	return RefPrefix + strconv.FormatUint(uint64(id), 10)
+}
+
+// ParseRef returns the managed key id of an AuthKey value
+func ParseRef(authKey string) (uint, bool) {
+	if !strings.HasPrefix(authKey, RefPrefix) {
+		return 0, false
+	}
+	id, err := strconv.ParseUint(strings.TrimPrefix(authKey, RefPrefix), 10, 64)
+	if err != nil || id == 0 {
+		return 0, false
+	}
+	return uint(id), true
+}
+
+type SSHKeyService struct {
+	dao     *db.SSHKeyDAO
+	repoDAO *db.RepoDAO
+}
+
+var SSHKeySvc *SSHKeyService
+
+func InitSSHKeyService() {
+	SSHKeySvc = &SSHKeyService{dao: db.NewSSHKeyDAO(), repoDAO: db.NewRepoDAO()}
+}
+
+// Generate creates a new key pair. bits only applies to RSA (default 4096, minimum 2048).
+func (s *SSHKeyService) Generate(name, keyType string, bits int, comment string) (*po.SSHKey, error) {
+	if name == "" {
+		return nil, fmt.Errorf("%w: name is required", ErrInvalidKey)
+	}
+	var priv crypto.Signer
+	switch keyType {
+	case TypeED25519, "":
+		_, k, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		priv, keyType, bits = k, TypeED25519, 256
+	case TypeRSA:
+		if bits == 0 {
+			bits = 4096
+		}
+		if bits < 2048 || bits > 8192 {
+			return nil, fmt.Errorf("%w: rsa bits must be between 2048 and 8192", ErrInvalidKey)
+		}
+		k, err := rsa.GenerateKey(rand.Reader, bits)
+		if err != nil {
+			return nil, err
+		}
+		priv = k
+	default:
+		return nil, fmt.Errorf("%w: unsupported key type %s", ErrInvalidKey, keyType)
+	}
+
+	block, err := ssh.MarshalPrivateKey(priv, comment)
+	if err != nil {
+		return nil, err
+	}
+	return s.store(name, string(pem.EncodeToMemory(block)), "", comment, priv)
+}
+
+// Import stores an existing private key (PEM or OpenSSH format). A protected key is
+// kept as is together with its passphrase.
+func (s *SSHKeyService) Import(name, privateKey, passphrase, comment string) (*po.SSHKey, error) {
+	if name == "" {
+		return nil, fmt.Errorf("%w: name is required", ErrInvalidKey)
+	}
+	privateKey = strings.TrimSpace(privateKey) + "\n"
+	raw, err := ParsePrivateKey([]byte(privateKey), passphrase)
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := raw.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("%w: unsupported private key", ErrInvalidKey)
+	}
+	if _, err := ssh.ParseRawPrivateKey([]byte(privateKey)); err == nil {
+		passphrase = "" // not protected, do not keep an unused secret
+	}
+	return s.store(name, privateKey, passphrase, comment, signer)
+}
+
+func (s *SSHKeyService) store(name, privateKey, passphrase, comment string, priv crypto.Signer) (*po.SSHKey, error) {
+	pub, err := ssh.NewPublicKey(priv.Public())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKey, err)
+	}
+	key := &po.SSHKey{
+		Name:        name,
+		Fingerprint: ssh.FingerprintSHA256(pub),
+		PublicKey:   strings.TrimSpace(string(ssh.MarshalAuthorizedKey(pub))),
+		PrivateKey:  privateKey,
+		Passphrase:  passphrase,
+	}
+	if comment != "" {
+		key.PublicKey += " " + comment
+	}
+	switch k := priv.Public().(type) {
+	case ed25519.PublicKey:
+		key.Type, key.Bits = TypeED25519, 256
+	case *rsa.PublicKey:
+		key.Type, key.Bits = TypeRSA, k.N.BitLen()
+	case *ecdsa.PublicKey:
+		key.Type, key.Bits = TypeECDSA, k.Curve.Params().BitSize
+	}
+	exists, err := s.dao.Exists(key.Name, key.Fingerprint)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, ErrKeyExists
+	}
+	if err := s.dao.Create(key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// Delete removes a key that no repository references any more
+func (s *SSHKeyService) Delete(id uint) (*po.SSHKey, error) {
+	key, err := s.dao.FindByID(id)
+	if err != nil {
+		return nil, ErrKeyNotFound
+	}
+	repos, err := s.repoDAO.FindAll()
+	if err != nil {
+		return nil, err
+	}
+	ref := Ref(id)
+	for _, repo := range repos {
+		if repo.AuthType == "ssh" && repo.AuthKey == ref {
+			return nil, fmt.Errorf("%w by repo %s", ErrKeyInUse, repo.Name)
+		}
+		for remote, auth := range repo.RemoteAuths {
+			if auth.Type == "ssh" && auth.Key == ref {
+				return nil, fmt.Errorf("%w by remote %s of repo %s", ErrKeyInUse, remote, repo.Name)
+			}
+		}
+	}
+	if err := s.dao.Delete(key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// LoadPrivateKey returns the PEM and passphrase for an SSH AuthKey: a managed key
+// reference, or a key file whose passphrase is authSecret
+func (s *SSHKeyService) LoadPrivateKey(authKey, authSecret string) ([]byte, string, error) {
+	if id, ok := ParseRef(authKey); ok {
+		key, err := s.dao.FindByID(id)
+		if err != nil {
+			return nil, "", fmt.Errorf("%w: %s", ErrKeyNotFound, authKey)
+		}
+		return []byte(key.PrivateKey), key.Passphrase, nil
+	}
+	data, err := os.ReadFile(authKey)
+	if err != nil {
+		return nil, "", err
+	}
+	return data, authSecret, nil
+}
+
+// ParsePrivateKey parses a private key, using the passphrase only if the key is protected
+func ParsePrivateKey(data []byte, passphrase string) (interface{}, error) {
+	raw, err := ssh.ParseRawPrivateKey(data)
+	var missing *ssh.PassphraseMissingError
+	switch {
+	case errors.As(err, &missing):
+		if passphrase == "" {
+			return nil, ErrPassphraseRequired
+		}
+		raw, err = ssh.ParseRawPrivateKeyWithPassphrase(data, []byte(passphrase))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidKey, err)
+		}
+	case err != nil:
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKey, err)
+	}
+	return raw, nil
+}
+
+// DecryptedPEM returns the key in unprotected OpenSSH format for tools that cannot be
+// given a passphrase, like the ssh command used by the git CLI
+func DecryptedPEM(data []byte, passphrase string) ([]byte, error) {
+	raw, err := ParsePrivateKey(data, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	if k, ok := raw.(*ed25519.PrivateKey); ok {
+		raw = *k
+	}
+	block, err := ssh.MarshalPrivateKey(raw, "")
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(block), nil
+}
@@ -0,0 +1,141 @@
+package sshkey
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"errors"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"github.com/yi-nology/git-manage-service/biz/dal/db"
+	"github.com/yi-nology/git-manage-service/biz/model/domain"
+	"github.com/yi-nology/git-manage-service/biz/model/po"
+	"github.com/yi-nology/git-manage-service/biz/utils"
+)
+
+func newTestService(t *testing.T) *SSHKeyService {
+	utils.InitEncryption()
+	var err error
+	db.DB, err = gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.DB.AutoMigrate(&po.SSHKey{}, &po.Repo{}); err != nil {
+		t.Fatal(err)
+	}
+	return &SSHKeyService{dao: db.NewSSHKeyDAO(), repoDAO: db.NewRepoDAO()}
+}
+
+func TestGenerate(t *testing.T) {
+	s := newTestService(t)
+
+	key, err := s.Generate("deploy", TypeED25519, 0, "deploy@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key.Type != TypeED25519 || key.Fingerprint == "" {
+		t.Fatalf("unexpected key: %+v", key)
+	}
+	pub, _, _, _, err := ssh.ParseAuthorizedKey([]byte(key.PublicKey))
+	if err != nil || ssh.FingerprintSHA256(pub) != key.Fingerprint {
+		t.Fatalf("public key %q does not match fingerprint: %v", key.PublicKey, err)
+	}
+
+	data, passphrase, err := s.LoadPrivateKey(Ref(key.ID), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.ParsePrivateKey(data)
+	if err != nil || passphrase != "" {
+		t.Fatalf("stored key not usable: %v", err)
+	}
+	if ssh.FingerprintSHA256(signer.PublicKey()) != key.Fingerprint {
+		t.Fatal("stored private key does not match public key")
+	}
+
+	if _, err := s.Generate("deploy", TypeED25519, 0, ""); !errors.Is(err, ErrKeyExists) {
+		t.Fatalf("duplicate name: got %v", err)
+	}
+	if _, err := s.Generate("weak", TypeRSA, 1024, ""); !errors.Is(err, ErrInvalidKey) {
+		t.Fatalf("weak rsa: got %v", err)
+	}
+}
+
+func TestImportProtected(t *testing.T) {
+	s := newTestService(t)
+	_, priv, _ := ed25519.GenerateKey(rand.Reader)
+	block, err := ssh.MarshalPrivateKeyWithPassphrase(priv, "", []byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	protected := string(pem.EncodeToMemory(block))
+
+	if _, err := s.Import("ci", protected, "", ""); !errors.Is(err, ErrPassphraseRequired) {
+		t.Fatalf("missing passphrase: got %v", err)
+	}
+	if _, err := s.Import("ci", protected, "wrong", ""); !errors.Is(err, ErrInvalidKey) {
+		t.Fatalf("wrong passphrase: got %v", err)
+	}
+	key, err := s.Import("ci", protected, "secret", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, passphrase, err := s.LoadPrivateKey(Ref(key.ID), "")
+	if err != nil || passphrase != "secret" {
+		t.Fatalf("load: passphrase %q, err %v", passphrase, err)
+	}
+	plain, err := DecryptedPEM(data, passphrase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.ParsePrivateKey(plain)
+	if err != nil {
+		t.Fatalf("decrypted key: %v", err)
+	}
+	if ssh.FingerprintSHA256(signer.PublicKey()) != key.Fingerprint {
+		t.Fatal("decrypted key does not match")
+	}
+}
+
+func TestDeleteInUse(t *testing.T) {
+	s := newTestService(t)
+	key, err := s.Generate("deploy", TypeED25519, 0, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := &po.Repo{Name: "app", Path: "/tmp/app", RemoteAuths: map[string]domain.AuthInfo{
+		"origin": {Type: "ssh", Key: Ref(key.ID)},
+	}}
+	if err := db.NewRepoDAO().Create(repo); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.Delete(key.ID); !errors.Is(err, ErrKeyInUse) {
+		t.Fatalf("in use: got %v", err)
+	}
+	if err := db.DB.Unscoped().Delete(repo).Error; err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Delete(key.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Delete(key.ID); !errors.Is(err, ErrKeyNotFound) {
+		t.Fatalf("deleted: got %v", err)
+	}
+}
+
+func TestParseRef(t *testing.T) {
+	if id, ok := ParseRef(Ref(42)); !ok || id != 42 {
+		t.Fatalf("ParseRef(Ref(42)) = %d, %v", id, ok)
+	}
+	for _, s := range []string{"/home/git/.ssh/id_ed25519", "sshkey:", "sshkey:0", "sshkey:x"} {
+		if _, ok := ParseRef(s); ok {
+			t.Errorf("ParseRef(%q) should fail", s)
+		}
+	}
+}
@@ -6,15 +6,14 @@ import (
 	"encoding/hex"
 	"io/fs"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"sort"
-	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/yi-nology/git-manage-service/biz/model/api"
+	"github.com/yi-nology/git-manage-service/biz/service/git"
 )
 
 // LineCounter 代码行统计器
@@ -451,86 +450,35 @@ func (lc *LineCounter) isGitRepo(repoPath string) bool {
 
 // getGitBlameInfo 获取文件的 git blame 信息
 func (lc *LineCounter) getGitBlameInfo(repoPath, filePath, branch string) (map[int]*BlameLineInfo, error) {
-	// 构建 git blame 命令
-	args := []string{"blame", "--line-porcelain"}
-	if branch != "" {
-		args = append(args, branch, "--")
-	}
-	
 	// 获取相对路径
 	relPath, err := filepath.Rel(repoPath, filePath)
 	if err != nil {
 		relPath = filePath
 	}
-	args = append(args, relPath)
-	
-	cmd := exec.Command("git", args...)
-	cmd.Dir = repoPath
-	
-	output, err := cmd.Output()
-	if err != nil {
-		return nil, err
-	}
-	
-	return lc.parseBlameOutput(string(output))
-}
 
-// parseBlameOutput 解析 git blame --line-porcelain 输出
-func (lc *LineCounter) parseBlameOutput(output string) (map[int]*BlameLineInfo, error) {
+	svc := git.NewGitService()
 	result := make(map[int]*BlameLineInfo)
-	lines := strings.Split(output, "\n")
-	
-	var currentLine int
-	var currentInfo *BlameLineInfo
-	
-	for i := 0; i < len(lines); i++ {
-		line := lines[i]
-		
-		// 提交哈希行 (40字符的hex + 行号信息)
-		if len(line) >= 40 && isHexString(line[:40]) {
-			parts := strings.Fields(line)
-			if len(parts) >= 3 {
-				lineNum, _ := strconv.Atoi(parts[2])
-				currentLine = lineNum
-				currentInfo = &BlameLineInfo{}
-			}
-			continue
-		}
-		
-		if currentInfo == nil {
-			continue
+	opts := git.BlameOptions{StartLine: 1}
+	// 单次 blame 最多返回 MaxBlameLines 行，分段获取整个文件
+	for {
+		blame, err := svc.Blame(repoPath, branch, filepath.ToSlash(relPath), opts)
+		if err != nil {
+			return nil, err
 		}
-		
-		// 解析作者信息
-		if strings.HasPrefix(line, "author ") {
-			currentInfo.Author = strings.TrimPrefix(line, "author ")
-		} else if strings.HasPrefix(line, "author-mail ") {
-			email := strings.TrimPrefix(line, "author-mail ")
-			email = strings.Trim(email, "<>")
-			currentInfo.Email = email
-		} else if strings.HasPrefix(line, "author-time ") {
-			timestamp, _ := strconv.ParseInt(strings.TrimPrefix(line, "author-time "), 10, 64)
-			currentInfo.Timestamp = timestamp
-		} else if strings.HasPrefix(line, "\t") {
-			// 代码行内容行，表示当前blame块结束
-			if currentLine > 0 && currentInfo != nil {
-				result[currentLine] = currentInfo
+		for _, r := range blame.Ranges {
+			c := blame.Commits[r.Commit]
+			info := &BlameLineInfo{Author: c.AuthorName, Email: c.AuthorEmail, Timestamp: c.AuthorTime}
+			for line := r.StartLine; line <= r.EndLine; line++ {
+				result[line] = info
 			}
-			currentInfo = nil
 		}
-	}
-	
-	return result, nil
-}
-
-// isHexString 检查字符串是否为十六进制
-func isHexString(s string) bool {
-	for _, c := range s {
-		if !((c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')) {
-			return false
+		if !blame.Truncated {
+			return result, nil
 		}
+		// 后续分段固定在同一提交上
+		branch = blame.Ref
+		opts.StartLine += git.MaxBlameLines
 	}
-	return true
 }
 
 // shouldCountLine 判断某一行是否应该被统计（基于作者和时间过滤）
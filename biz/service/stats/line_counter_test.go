@@ -0,0 +1,28 @@
+package stats
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yi-nology/git-manage-service/biz/service/git"
+	"github.com/yi-nology/git-manage-service/biz/service/git/gittest"
+)
+
+func TestGitBlameInfoCoversLongFiles(t *testing.T) {
+	repo := gittest.New(t)
+	lines := git.MaxBlameLines + 10
+	repo.CommitFileAs("main.go", strings.Repeat("x := 1\n", lines), "alice <alice@example.com>")
+
+	file := filepath.Join(repo.Dir, "main.go")
+	info, err := (&LineCounter{}).getGitBlameInfo(repo.Dir, file, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(info) != lines {
+		t.Fatalf("expected blame for %d lines, got %d", lines, len(info))
+	}
+	if last := info[lines]; last == nil || last.Author != "alice" || last.Email != "alice@example.com" || last.Timestamp == 0 {
+		t.Errorf("unexpected blame of the last line %+v", last)
+	}
+}
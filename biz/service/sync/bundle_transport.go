@@ -0,0 +1,114 @@
+package sync
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yi-nology/git-manage-service/biz/model/po"
+	"github.com/yi-nology/git-manage-service/biz/service/git"
+)
+
+// bundleSourceRef keeps the source branch as last imported from the task's bundles
+func bundleSourceRef(task *po.SyncTask) string {
+	return fmt.Sprintf("refs/bundles/%s/%s", task.Key, task.SourceBranch)
+}
+
+// resolveBundleSource imports, in file name order, the bundles of the task's source
+// directory that carry commits of the source branch not imported yet. Bundle files are
+// left in place so several tasks can share a drop directory.
+func (s *SyncService) resolveBundleSource(path string, task *po.SyncTask, logf func(string, ...interface{})) (string, error) {
+	entries, err := os.ReadDir(task.SourceBundleDir)
+	if err != nil {
+		return "", fmt.Errorf("read bundle directory failed: %v", err)
+	}
+	var files []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), ".bundle") {
+			files = append(files, filepath.Join(task.SourceBundleDir, e.Name()))
+		}
+	}
+	sort.Strings(files)
+
+	ref := bundleSourceRef(task)
+	branchRef := "refs/heads/" + task.SourceBranch
+	for _, file := range files {
+		heads, err := s.git.ListBundleHeads(file)
+		if err != nil {
+			logf("Skipping %s: %v", filepath.Base(file), err)
+			continue
+		}
+		var head string
+		for _, h := range heads {
+			if h.Name == branchRef {
+				head = h.Hash
+			}
+		}
+		if head == "" {
+			continue
+		}
+		current, _ := s.git.ResolveRevision(path, ref)
+		if current != "" {
+			if imported, _ := s.git.IsAncestor(path, head, current); imported {
+				continue
+			}
+		}
+		logf("Importing bundle %s (%s -> %s)", filepath.Base(file), task.SourceBranch, head)
+		if err := s.git.FetchBundleRef(path, file, branchRef, ref); err != nil {
+			return "", fmt.Errorf("import bundle %s failed: %v", filepath.Base(file), err)
+		}
+	}
+
+	h, err := s.git.ResolveRevision(path, ref)
+	if err != nil {
+		return "", fmt.Errorf("no bundle in %s contains branch %s", task.SourceBundleDir, task.SourceBranch)
+	}
+	logf("Source hash (bundle/%s): %s", task.SourceBranch, h)
+	return h, nil
+}
+
+// resolveBundleTarget treats the commit pushed by the task's last successful run as the
+// target's position: the receiving side has it once it imported that bundle.
+func (s *SyncService) resolveBundleTarget(path string, task *po.SyncTask, logf func(string, ...interface{})) (string, bool, error) {
+	run, err := s.syncRunDAO.FindLastPushed(task.Key)
+	if err != nil {
+		logf("No bundle exported yet, the bundle will contain the full history")
+		return "", false, nil
+	}
+	if _, err := s.git.ResolveRevision(path, run.PushedHash); err != nil {
+		logf("Last exported commit %s is missing, the bundle will contain the full history", run.PushedHash)
+		return "", false, nil
+	}
+	logf("Target hash (last exported bundle, run %d): %s", run.ID, run.PushedHash)
+	return run.PushedHash, true, nil
+}
+
+// exportBundle writes the commits from targetHash to sourceHash as a bundle for the
+// target branch. The file only appears under its final name once complete.
+func (s *SyncService) exportBundle(path string, task *po.SyncTask, targetHash, sourceHash string, logf func(string, ...interface{})) error {
+	if err := os.MkdirAll(task.TargetBundleDir, 0755); err != nil {
+		return fmt.Errorf("create bundle directory failed: %v", err)
+	}
+	// UTC with fixed-width nanoseconds, so names sort in export order
+	name := fmt.Sprintf("%s-%s.bundle", task.Key, time.Now().UTC().Format("20060102T150405.000000000Z"))
+	final := filepath.Join(task.TargetBundleDir, name)
+	tmp := filepath.Join(task.TargetBundleDir, "."+name+".tmp")
+
+	var since []string
+	if targetHash != "" {
+		since = []string{targetHash}
+	}
+	heads := []git.BundleRef{{Name: "refs/heads/" + task.TargetBranch, Hash: sourceHash}}
+	if err := s.git.CreateBundle(path, tmp, heads, since); err != nil {
+		return fmt.Errorf("create bundle failed: %v", err)
+	}
+	if err := os.Rename(tmp, final); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("publish bundle failed: %v", err)
+	}
+	logf("Wrote bundle %s", final)
+	return nil
+}
@@ -0,0 +1,213 @@
+package sync
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	stdsync "sync"
+	"time"
+
+	"github.com/yi-nology/git-manage-service/biz/dal/db"
+	"github.com/yi-nology/git-manage-service/biz/model/domain"
+	"github.com/yi-nology/git-manage-service/biz/model/po"
+	"github.com/yi-nology/git-manage-service/biz/service/notify"
+	"github.com/yi-nology/git-manage-service/pkg/configs"
+)
+
+// LagMonitor periodically fetches the source and target of every sync task
+// (without pushing) and reports how far each target is behind.
+type LagMonitor struct {
+	syncSvc  *SyncService
+	taskDAO  *db.SyncTaskDAO
+	statuses stdsync.Map // task key -> *domain.SyncLag
+	locks    stdsync.Map // task key -> *stdsync.Mutex, serializes checks of a task
+	sweep    stdsync.Mutex
+}
+
+var LagMonitorSvc *LagMonitor
+
+var errSyncInFlight = errors.New("a sync of the repository is running, check skipped")
+
+func InitLagMonitor() {
+	LagMonitorSvc = &LagMonitor{
+		syncSvc: NewSyncService(),
+		taskDAO: db.NewSyncTaskDAO(),
+	}
+
+	raw := configs.GlobalConfig.Monitor.LagCheckInterval
+	if raw == "" {
+		log.Println("Lag monitor disabled (monitor.lag_check_interval is empty)")
+		return
+	}
+	interval, err := time.ParseDuration(raw)
+	if err != nil || interval <= 0 {
+		log.Printf("Invalid monitor.lag_check_interval %q, lag monitor disabled", raw)
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			LagMonitorSvc.CheckAll()
+		}
+	}()
+	fmt.Printf("Lag monitor started, interval %s\n", interval)
+}
+
+// CheckAll checks every task, including disabled ones, since a disabled
+// task is one of the ways a mirror silently falls behind.
+func (m *LagMonitor) CheckAll() {
+	if !m.sweep.TryLock() {
+		log.Println("[LagMonitor] Previous sweep still running, skipping")
+		return
+	}
+	defer m.sweep.Unlock()
+
+	tasks, err := m.taskDAO.FindAllWithRepos()
+	if err != nil {
+		log.Println("[LagMonitor] Failed to load tasks:", err)
+		return
+	}
+
+	known := make(map[string]bool)
+	for i := range tasks {
+		known[tasks[i].Key] = true
+		m.CheckTask(&tasks[i])
+	}
+
+	// Drop statuses of deleted tasks
+	m.statuses.Range(func(k, _ interface{}) bool {
+		if !known[k.(string)] {
+			m.statuses.Delete(k)
+			m.locks.Delete(k)
+		}
+		return true
+	})
+}
+
+// CheckTask resolves the task's source and target refs, computes the lag and
+// raises a notification when the task crosses its threshold. Checks of the same
+// task from the ticker and the API run one at a time, so each crossing notifies once.
+func (m *LagMonitor) CheckTask(task *po.SyncTask) *domain.SyncLag {
+	v, _ := m.locks.LoadOrStore(task.Key, &stdsync.Mutex{})
+	lock := v.(*stdsync.Mutex)
+	lock.Lock()
+	defer lock.Unlock()
+
+	maxCommits, maxMinutes := lagThresholds(task)
+	status := &domain.SyncLag{
+		TaskKey:          task.Key,
+		Enabled:          task.Enabled,
+		ThresholdCommits: maxCommits,
+		ThresholdMinutes: maxMinutes,
+		CheckedAt:        time.Now(),
+	}
+
+	if err := m.measure(task, status); err != nil {
+		status.Error = err.Error()
+		log.Printf("[LagMonitor] Task %s check failed: %v", task.Key, err)
+	} else {
+		status.Exceeded, status.Reason = lagExceeded(status)
+	}
+
+	var previous *domain.SyncLag
+	if v, ok := m.statuses.Load(task.Key); ok {
+		previous = v.(*domain.SyncLag)
+	}
+	// A failed check tells nothing about the lag; keep the last known state so the
+	// next successful check does not alert again
+	if status.Error != "" && previous != nil {
+		status.Exceeded, status.Reason = previous.Exceeded, previous.Reason
+	}
+	m.statuses.Store(task.Key, status)
+
+	wasExceeded := previous != nil && previous.Exceeded
+	if status.Exceeded && !wasExceeded {
+		notify.NotifySvc.Notify("SYNC_LAG", "task:"+task.Key,
+			fmt.Sprintf("target %s/%s lags behind source: %s", task.TargetRemote, task.TargetBranch, status.Reason), status)
+	} else if !status.Exceeded && wasExceeded && status.Error == "" {
+		notify.NotifySvc.Notify("SYNC_LAG_RECOVERED", "task:"+task.Key,
+			fmt.Sprintf("target %s/%s caught up with source", task.TargetRemote, task.TargetBranch), status)
+	}
+	return status
+}
+
+func (m *LagMonitor) measure(task *po.SyncTask, status *domain.SyncLag) error {
+	path := task.SourceRepo.Path
+	quiet := func(string, ...interface{}) {}
+
+	// The check fetches like a sync does; waiting for a long sync would stall the sweep
+	// and the next check measures the result anyway
+	lock := repoLock(path)
+	if !lock.TryLock() {
+		return errSyncInFlight
+	}
+	defer lock.Unlock()
+
+	sourceHash, err := m.syncSvc.resolveSource(path, task, quiet)
+	if err != nil {
+		return err
+	}
+	targetHash, targetExists, err := m.syncSvc.resolveTarget(path, task, quiet)
+	if err != nil {
+		return err
+	}
+	status.SourceHash = sourceHash
+	status.TargetHash = targetHash
+	status.TargetExists = targetExists
+
+	count, oldest, err := m.syncSvc.git.GetCommitLag(path, targetHash, sourceHash)
+	if err != nil {
+		return fmt.Errorf("count lag failed: %v", err)
+	}
+	status.LagCommits = count
+	if count > 0 && !oldest.IsZero() {
+		status.OldestPendingAt = oldest
+		status.LagSeconds = int64(time.Since(oldest).Seconds())
+	}
+	return nil
+}
+
+// GetStatus returns the latest lag status of a task
+func (m *LagMonitor) GetStatus(taskKey string) (*domain.SyncLag, bool) {
+	v, ok := m.statuses.Load(taskKey)
+	if !ok {
+		return nil, false
+	}
+	return v.(*domain.SyncLag), true
+}
+
+// ListStatuses returns the latest lag status of every checked task
+func (m *LagMonitor) ListStatuses() []*domain.SyncLag {
+	list := []*domain.SyncLag{}
+	m.statuses.Range(func(_, v interface{}) bool {
+		list = append(list, v.(*domain.SyncLag))
+		return true
+	})
+	return list
+}
+
+func lagThresholds(task *po.SyncTask) (int, int) {
+	maxCommits := configs.GlobalConfig.Monitor.MaxLagCommits
+	maxMinutes := configs.GlobalConfig.Monitor.MaxLagMinutes
+	if task.LagThresholdCommits > 0 {
+		maxCommits = task.LagThresholdCommits
+	}
+	if task.LagThresholdMinutes > 0 {
+		maxMinutes = task.LagThresholdMinutes
+	}
+	return maxCommits, maxMinutes
+}
+
+func lagExceeded(status *domain.SyncLag) (bool, string) {
+	if status.ThresholdCommits > 0 && status.LagCommits > status.ThresholdCommits {
+		return true, fmt.Sprintf("%d commits behind (threshold %d)", status.LagCommits, status.ThresholdCommits)
+	}
+	if status.ThresholdMinutes > 0 && status.LagCommits > 0 &&
+		status.LagSeconds > int64(status.ThresholdMinutes)*60 {
+		return true, fmt.Sprintf("oldest pending commit is %s old (threshold %dm)",
+			(time.Duration(status.LagSeconds) * time.Second).String(), status.ThresholdMinutes)
+	}
+	return false, ""
+}
@@ -0,0 +1,84 @@
+package sync
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	stdsync "sync"
+	"testing"
+	"time"
+
+	"github.com/yi-nology/git-manage-service/biz/model/po"
+	"github.com/yi-nology/git-manage-service/biz/service/git/gittest"
+	"github.com/yi-nology/git-manage-service/biz/service/notify"
+	"github.com/yi-nology/git-manage-service/pkg/configs"
+)
+
+func TestLagAlertSurvivesFailedCheck(t *testing.T) {
+	var mu stdsync.Mutex
+	events := map[string]int{}
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var evt notify.Event
+		json.NewDecoder(r.Body).Decode(&evt)
+		mu.Lock()
+		events[evt.Type]++
+		mu.Unlock()
+	}))
+	defer hook.Close()
+	saved := configs.GlobalConfig.Notify
+	defer func() { configs.GlobalConfig.Notify = saved }()
+	configs.GlobalConfig.Notify.WebhookURL = hook.URL
+	notify.InitNotifyService()
+
+	tmpDir := t.TempDir()
+	target := filepath.Join(tmpDir, "target.git")
+	gittest.Run(t, tmpDir, nil, "init", "-q", "--bare", target)
+	work := gittest.Init(t, filepath.Join(tmpDir, "work")).Dir
+	gittest.Run(t, work, nil, "commit", "-q", "--allow-empty", "-m", "a")
+	gittest.Run(t, work, nil, "remote", "add", "mirror", target)
+	gittest.Run(t, work, nil, "push", "-q", "mirror", "main")
+	for _, msg := range []string{"b", "c", "d"} {
+		gittest.Run(t, work, nil, "commit", "-q", "--allow-empty", "-m", msg)
+	}
+
+	m := &LagMonitor{syncSvc: NewSyncService()}
+	task := &po.SyncTask{
+		Key:                 "lag",
+		SourceRepo:          po.Repo{Path: work},
+		SourceRemote:        "local",
+		SourceBranch:        "main",
+		TargetRemote:        "mirror",
+		TargetBranch:        "main",
+		LagThresholdCommits: 1,
+	}
+
+	if st := m.CheckTask(task); !st.Exceeded || st.LagCommits != 3 {
+		t.Fatalf("first check: %+v", st)
+	}
+	// The target becomes unreachable for one check
+	gittest.Run(t, work, nil, "remote", "set-url", "mirror", filepath.Join(tmpDir, "missing.git"))
+	if st := m.CheckTask(task); st.Error == "" || !st.Exceeded {
+		t.Fatalf("failed check should keep the exceeded state: %+v", st)
+	}
+	gittest.Run(t, work, nil, "remote", "set-url", "mirror", target)
+	if st := m.CheckTask(task); !st.Exceeded {
+		t.Fatalf("third check: %+v", st)
+	}
+	// Checks leave the repository to a running sync
+	lock := repoLock(work)
+	lock.Lock()
+	st := m.CheckTask(task)
+	lock.Unlock()
+	if st.Error != errSyncInFlight.Error() || !st.Exceeded {
+		t.Fatalf("check during a sync: %+v", st)
+	}
+
+	// Webhooks are delivered in the background
+	time.Sleep(200 * time.Millisecond)
+	mu.Lock()
+	defer mu.Unlock()
+	if events["SYNC_LAG"] != 1 || events["SYNC_LAG_RECOVERED"] != 0 {
+		t.Errorf("expected a single SYNC_LAG alert, got %v", events)
+	}
+}
@@ -0,0 +1,99 @@
+package sync
+
+import (
+	"fmt"
+
+	"github.com/yi-nology/git-manage-service/biz/model/po"
+	"github.com/yi-nology/git-manage-service/biz/service/lfs"
+)
+
+// lfsHook transfers the LFS objects referenced by the pushed range to the
+// target's LFS server, so the mirror never ends up with dangling pointers.
+func (s *SyncService) lfsHook() prePushHook {
+	return prePushHook{
+		name:    "lfs",
+		applies: func(task *po.SyncTask) bool { return task.SyncLFS },
+		run:     s.syncLFSObjects,
+	}
+}
+
+func (s *SyncService) syncLFSObjects(in *hookInput) error {
+	blobs, err := s.git.ListNewBlobs(in.path, in.targetHash, in.sourceHash, lfs.MaxPointerSize)
+	if err != nil {
+		return fmt.Errorf("scan for lfs pointers failed: %v", err)
+	}
+
+	var pointers []lfs.Pointer
+	for _, b := range blobs {
+		if p, ok := lfs.ParsePointer(b.Content); ok {
+			p.Path = b.Path
+			pointers = append(pointers, p)
+		}
+	}
+	if len(pointers) == 0 {
+		in.logf("LFS: no pointers in pushed range")
+		return nil
+	}
+
+	target, err := s.lfsTargetClient(in.path, in.task)
+	if err != nil {
+		return err
+	}
+	source := s.lfsSourceClient(in.path, in.task)
+
+	gitDir, err := s.git.GetGitDir(in.path)
+	if err != nil {
+		return err
+	}
+
+	in.logf("LFS: %d pointer(s) in pushed range, target endpoint %s", len(pointers), target.Endpoint)
+	res, err := lfs.Sync(pointers, lfs.NewLocalStore(gitDir), source, target)
+	if err != nil {
+		return err
+	}
+	in.logf("LFS: %d object(s) referenced, %d uploaded", res.Pointers, res.Uploaded)
+	return nil
+}
+
+func (s *SyncService) lfsTargetClient(path string, task *po.SyncTask) (*lfs.Client, error) {
+	url, authType, authKey, authSecret := s.targetEndpoint(path, task)
+	endpoint := task.LFSTargetURL
+	if endpoint == "" {
+		endpoint = lfs.EndpointForRemote(url)
+	}
+	if endpoint == "" {
+		return nil, fmt.Errorf("cannot derive lfs endpoint from target url %q, set lfs_target_url", url)
+	}
+
+	user, pass := "", ""
+	if authType == "http" {
+		user, pass = authKey, authSecret
+	}
+	return lfs.NewClient(endpoint, user, pass), nil
+}
+
+// lfsSourceClient returns nil when the source has no LFS endpoint; objects must then be in the local store
+func (s *SyncService) lfsSourceClient(path string, task *po.SyncTask) *lfs.Client {
+	sourceRemote := task.SourceRemote
+	if sourceRemote == "" {
+		sourceRemote = "origin"
+	}
+	if sourceRemote == "local" {
+		return nil
+	}
+
+	url, _ := s.git.GetRemoteURL(path, sourceRemote)
+	if url == "" && sourceRemote == "origin" {
+		url = task.SourceRepo.RemoteURL
+	}
+	endpoint := lfs.EndpointForRemote(url)
+	if endpoint == "" {
+		return nil
+	}
+
+	user, pass := "", ""
+	if authType, authKey, authSecret := getAuthForRemote(task.SourceRepo, sourceRemote, url); authType == "http" {
+		user, pass = authKey, authSecret
+	}
+	return lfs.NewClient(endpoint, user, pass)
+}
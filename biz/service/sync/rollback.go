@@ -0,0 +1,121 @@
+package sync
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/yi-nology/git-manage-service/biz/model/po"
+)
+
+var ErrBundleRollback = errors.New("bundle targets cannot be rolled back, the bundle may already have been imported")
+
+// CheckRollback reports why a run cannot be rolled back, or nil if it can
+func CheckRollback(run *po.SyncRun) error {
+	if run.Kind == RunKindRollback {
+		return errors.New("rollback runs cannot be rolled back, roll back the original run's successor instead")
+	}
+	if run.Status != "success" || run.PushedHash == "" {
+		return errors.New("run did not push anything")
+	}
+	if run.PreviousTargetHash == "" {
+		return errors.New("target branch did not exist before this run, nothing to roll back to")
+	}
+	return nil
+}
+
+// Rollback moves the task's target branch back to the hash it had before orig pushed.
+// The push carries a lease on orig's pushed hash, so it fails instead of discarding
+// commits pushed to the target afterwards. It waits for syncs of the repository in
+// flight, and the attempt is recorded as its own run.
+func (s *SyncService) Rollback(orig *po.SyncRun, task *po.SyncTask) (*po.SyncRun, error) {
+	if err := CheckRollback(orig); err != nil {
+		return nil, err
+	}
+	if task.TargetBundleDir != "" {
+		return nil, ErrBundleRollback
+	}
+	lock := repoLock(task.SourceRepo.Path)
+	lock.Lock()
+	defer lock.Unlock()
+
+	run := po.SyncRun{
+		TaskKey:            task.Key,
+		Kind:               RunKindRollback,
+		RollbackOf:         orig.ID,
+		CommitRange:        fmt.Sprintf("%s..%s", orig.PreviousTargetHash, orig.PushedHash),
+		PreviousTargetHash: orig.PushedHash,
+		StartTime:          time.Now(),
+		Status:             "running",
+	}
+	s.syncRunDAO.Create(&run)
+
+	var logs strings.Builder
+	logf := func(format string, args ...interface{}) {
+		msg := fmt.Sprintf(format, args...)
+		logs.WriteString(fmt.Sprintf("[%s] %s\n", time.Now().Format("15:04:05"), msg))
+	}
+
+	rec := &runRecorder{}
+	err := s.doRollback(task.SourceRepo.Path, task, orig, logf, rec)
+
+	run.EndTime = time.Now()
+	if err != nil {
+		run.Status = "failed"
+		run.ErrorMessage = err.Error()
+		logf("Rollback failed: %v", err)
+	} else {
+		run.Status = "success"
+		run.PushedHash = orig.PreviousTargetHash
+		logf("Rollback completed successfully")
+		if task.Enabled && task.Cron != "" {
+			logf("Note: task is still scheduled, its next run will push the source branch again")
+		}
+	}
+	run.Details = logs.String()
+	s.syncRunDAO.Save(&run)
+	if dErr := s.syncRunDAO.SaveDetails(run.ID, rec.steps, nil); dErr != nil {
+		log.Printf("Failed to save details of sync run %d: %v", run.ID, dErr)
+	}
+	return &run, err
+}
+
+func (s *SyncService) doRollback(path string, task *po.SyncTask, orig *po.SyncRun, logf func(string, ...interface{}), rec *runRecorder) error {
+	logf("Rolling back run %d of task %s: %s/%s %s -> %s",
+		orig.ID, task.Key, task.TargetRemote, task.TargetBranch, orig.PushedHash, orig.PreviousTargetHash)
+
+	err := rec.step(StepCheckRollback, func() (string, error) {
+		if _, err := s.git.GetCommit(path, orig.PreviousTargetHash); err != nil {
+			return "", fmt.Errorf("commit %s is not available locally: %v", orig.PreviousTargetHash, err)
+		}
+		return orig.PreviousTargetHash, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return rec.step(StepPush, func() (string, error) {
+		remote := task.TargetRemote
+		if remote == "" {
+			remote = "origin"
+		}
+		url, tType, tKey, tSecret := s.targetEndpoint(path, task)
+		if url != "" && tType != "" && tType != "none" {
+			logf("Pushing target (Auth: %s)...", tType)
+			remote = url
+		}
+
+		logf("Command: git push --force-with-lease=refs/heads/%s:%s %s %s:refs/heads/%s",
+			task.TargetBranch, orig.PushedHash, task.TargetRemote, orig.PreviousTargetHash, task.TargetBranch)
+		out, err := s.git.PushWithLease(path, remote, orig.PreviousTargetHash, task.TargetBranch, orig.PushedHash, tType, tKey, tSecret)
+		if out != "" {
+			logf("%s", out)
+		}
+		if err != nil {
+			return "", err
+		}
+		return orig.PreviousTargetHash, nil
+	})
+}
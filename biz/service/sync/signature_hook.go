@@ -0,0 +1,67 @@
+package sync
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/object"
+
+	"github.com/yi-nology/git-manage-service/biz/dal/db"
+	"github.com/yi-nology/git-manage-service/biz/model/po"
+	"github.com/yi-nology/git-manage-service/biz/service/signature"
+)
+
+// maxReportedUnverified caps how many offending commits are listed in the error
+const maxReportedUnverified = 50
+
+// signatureHook refuses the push unless every commit in the range carries a
+// valid GPG or SSH signature made by a key of the managed keyring.
+func (s *SyncService) signatureHook() prePushHook {
+	return prePushHook{
+		name:    "signature",
+		applies: func(task *po.SyncTask) bool { return task.VerifySignatures },
+		run:     s.verifySignatures,
+	}
+}
+
+func (s *SyncService) verifySignatures(in *hookInput) error {
+	keys, err := db.NewTrustedKeyDAO().FindAll()
+	if err != nil {
+		return fmt.Errorf("load trusted keys failed: %v", err)
+	}
+	keyring := signature.NewKeyring(keys)
+	if keyring.Empty() {
+		return errors.New("signature verification enabled but no trusted keys are configured")
+	}
+
+	total := 0
+	var failed []string
+	err = s.git.ForEachCommitInRange(in.path, in.targetHash, in.sourceHash, func(c *object.Commit) error {
+		total++
+		if _, err := keyring.VerifyCommit(c); err != nil {
+			failed = append(failed, fmt.Sprintf("%s (%s): %v", c.Hash.String()[:8], firstLine(c.Message), err))
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("walk commits failed: %v", err)
+	}
+
+	if n := len(failed); n > 0 {
+		for _, f := range failed {
+			in.logf("Unverified commit %s", f)
+		}
+		if n > maxReportedUnverified {
+			failed = append(failed[:maxReportedUnverified], fmt.Sprintf("... and %d more", n-maxReportedUnverified))
+		}
+		return fmt.Errorf("%d of %d commit(s) failed signature verification: %s", n, total, strings.Join(failed, "; "))
+	}
+	in.logf("Signatures: all %d commit(s) verified", total)
+	return nil
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(s), "\n")
+	return line
+}
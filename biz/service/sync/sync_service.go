@@ -2,11 +2,15 @@ package sync
 
 import (
 	"fmt"
+	"log"
 	"strings"
+	stdsync "sync"
 	"time"
 
 	"github.com/yi-nology/git-manage-service/biz/dal/db"
+	"github.com/yi-nology/git-manage-service/biz/model/domain"
 	"github.com/yi-nology/git-manage-service/biz/model/po"
+	"github.com/yi-nology/git-manage-service/biz/service/credential"
 	"github.com/yi-nology/git-manage-service/biz/service/git"
 )
 
@@ -14,14 +18,44 @@ type SyncService struct {
 	git         *git.GitService
 	syncTaskDAO *db.SyncTaskDAO
 	syncRunDAO  *db.SyncRunDAO
+	hooks       []prePushHook
+}
+
+// hookInput describes what a sync run is about to push
+type hookInput struct {
+	path       string
+	task       *po.SyncTask
+	sourceHash string
+	targetHash string // empty when the target branch does not exist yet
+	commits    []domain.Commit
+	logf       func(string, ...interface{})
+}
+
+// prePushHook runs before the push of every task it applies to; an error aborts the sync
+type prePushHook struct {
+	name    string
+	applies func(task *po.SyncTask) bool
+	run     func(in *hookInput) error
+}
+
+// repoLocks serializes the syncs and rollbacks of a repository, whose fetches and
+// pushes would otherwise race on its refs
+var repoLocks stdsync.Map // repo path -> *stdsync.Mutex
+
+func repoLock(path string) *stdsync.Mutex {
+	l, _ := repoLocks.LoadOrStore(path, &stdsync.Mutex{})
+	return l.(*stdsync.Mutex)
 }
 
 func NewSyncService() *SyncService {
-	return &SyncService{
+	s := &SyncService{
 		git:         git.NewGitService(),
 		syncTaskDAO: db.NewSyncTaskDAO(),
 		syncRunDAO:  db.NewSyncRunDAO(),
 	}
+	// Verification runs first so nothing is uploaded for a range that will be refused
+	s.hooks = append(s.hooks, s.signatureHook(), s.lfsHook())
+	return s
 }
 
 func (s *SyncService) RunTask(taskKey string) error {
@@ -33,15 +67,19 @@ func (s *SyncService) RunTask(taskKey string) error {
 }
 
 func (s *SyncService) ExecuteSync(task *po.SyncTask) error {
+	repoPath := task.SourceRepo.Path
+	lock := repoLock(repoPath)
+	lock.Lock()
+	defer lock.Unlock()
+
 	run := po.SyncRun{
 		TaskKey:   task.Key,
+		Kind:      RunKindSync,
 		StartTime: time.Now(),
 		Status:    "running",
 	}
 	s.syncRunDAO.Create(&run)
 
-	repoPath := task.SourceRepo.Path
-
 	// Capture logs
 	var logs strings.Builder
 	logf := func(format string, args ...interface{}) {
@@ -49,9 +87,12 @@ func (s *SyncService) ExecuteSync(task *po.SyncTask) error {
 		logs.WriteString(fmt.Sprintf("[%s] %s\n", time.Now().Format("15:04:05"), msg))
 	}
 
-	commitRange, err := s.doSync(repoPath, task, logf)
+	rec := &runRecorder{}
+	commitRange, err := s.doSync(repoPath, task, logf, rec)
 
 	run.CommitRange = commitRange
+	run.PreviousTargetHash = rec.previousTarget
+	run.PushedHash = rec.pushed
 	run.Details = logs.String()
 	run.EndTime = time.Now()
 
@@ -63,6 +104,8 @@ func (s *SyncService) ExecuteSync(task *po.SyncTask) error {
 		}
 		run.ErrorMessage = err.Error()
 		logf("Sync failed: %v", err)
+		// Nothing was pushed
+		rec.commits = nil
 	} else {
 		run.Status = "success"
 		logf("Sync completed successfully")
@@ -70,182 +113,303 @@ func (s *SyncService) ExecuteSync(task *po.SyncTask) error {
 	// Save final details
 	run.Details = logs.String()
 	s.syncRunDAO.Save(&run)
+	if dErr := s.syncRunDAO.SaveDetails(run.ID, rec.steps, rec.commits); dErr != nil {
+		log.Printf("Failed to save details of sync run %d: %v", run.ID, dErr)
+	}
 	return err
 }
 
-func getAuthForRemote(repo po.Repo, remoteName string) (string, string, string) {
-	if repo.RemoteAuths != nil {
-		if auth, ok := repo.RemoteAuths[remoteName]; ok {
-			return auth.Type, auth.Key, auth.Secret
+// getAuthForRemote returns the auth configured on the repo for the remote, or the
+// stored credential bound to its URL when the repo configures none
+func getAuthForRemote(repo po.Repo, remoteName, url string) (string, string, string) {
+	auth := repo.AuthForRemote(remoteName)
+	if auth.Key == "" && url != "" && credential.CredentialSvc != nil {
+		if info, ok := credential.CredentialSvc.Resolve(url); ok {
+			auth = info
 		}
 	}
-	return repo.AuthType, repo.AuthKey, repo.AuthSecret
+	return auth.Type, auth.Key, auth.Secret
 }
 
-func (s *SyncService) doSync(path string, task *po.SyncTask, logf func(string, ...interface{})) (string, error) {
+func (s *SyncService) doSync(path string, task *po.SyncTask, logf func(string, ...interface{}), rec *runRecorder) (string, error) {
 	logf("Starting sync for task %s (Repo: %s)", task.Key, path)
 
 	// 1. Fetch Source
-	sourceRemote := task.SourceRemote
-	if sourceRemote == "" {
-		sourceRemote = "origin"
+	var sourceHash string
+	err := rec.step(StepFetchSource, func() (string, error) {
+		h, err := s.resolveSource(path, task, logf)
+		sourceHash = h
+		return h, err
+	})
+	if err != nil {
+		return "", err
 	}
 
-	// Check if source is local
-	isLocalSource := (sourceRemote == "local")
+	// 2. Fetch Target
+	// 3. Get Hashes
+	var targetHash string
+	var targetExists bool
+	err = rec.step(StepFetchTarget, func() (string, error) {
+		h, exists, err := s.resolveTarget(path, task, logf)
+		targetHash, targetExists = h, exists
+		if err == nil && !exists {
+			return "target branch does not exist yet", nil
+		}
+		return h, err
+	})
+	if err != nil {
+		return "", err
+	}
 
-	var sourceHash string
+	if targetExists {
+		rec.previousTarget = targetHash
+	}
 
-	// Helper for progress logging
-	progressWriter := &logWriter{logf: logf}
+	var commitRange string
+	if targetExists {
+		commitRange = fmt.Sprintf("%s..%s", targetHash, sourceHash)
+	} else {
+		commitRange = sourceHash // New branch
+	}
+
+	if targetExists && sourceHash == targetHash {
+		logf("Source and Target are at the same commit. No sync needed.")
+		rec.skip(StepAncestryCheck, "source and target are at the same commit")
+		rec.skip(StepHooks, "nothing to push")
+		rec.skip(StepPush, "nothing to push")
+		return "", nil // Already synced
+	}
+
+	// 4. Check Fast-Forward
+	if targetExists {
+		err = rec.step(StepAncestryCheck, func() (string, error) {
+			// Is Target an ancestor of Source?
+			isAncestor, err := s.git.IsAncestor(path, targetHash, sourceHash)
+			if err != nil {
+				return "", fmt.Errorf("check ancestor failed: %v", err)
+			}
+
+			if !isAncestor {
+				logf("Not a fast-forward update. Checking divergence...")
+				// Check if diverged or Source is behind
+				// If Source is ancestor of Target, Source is behind.
+				isSourceBehind, _ := s.git.IsAncestor(path, sourceHash, targetHash)
+				if isSourceBehind {
+					return "", fmt.Errorf("source is behind target")
+				}
+				return "", fmt.Errorf("conflict")
+			}
+			logf("Fast-forward check passed.")
+			return "fast-forward", nil
+		})
+		if err != nil {
+			return "", err
+		}
+	} else {
+		rec.skip(StepAncestryCheck, "target branch does not exist yet")
+	}
 
-	if !isLocalSource {
-		sourceURL, _ := s.git.GetRemoteURL(path, sourceRemote)
-		if sourceURL == "" && sourceRemote == "origin" {
-			sourceURL = task.SourceRepo.RemoteURL
+	commits, err := s.git.ListCommitRange(path, targetHash, sourceHash, maxRecordedCommits)
+	if err != nil {
+		logf("Failed to list commits to push: %v", err)
+	}
+	rec.setCommits(commits)
+
+	// Pre-push hooks
+	if err := s.runHooks(rec, &hookInput{
+		path:       path,
+		task:       task,
+		sourceHash: sourceHash,
+		targetHash: targetHash,
+		commits:    commits,
+		logf:       logf,
+	}); err != nil {
+		return "", err
+	}
+
+	// 5. Push
+	err = rec.step(StepPush, func() (string, error) {
+		if task.TargetBundleDir != "" {
+			return sourceHash, s.exportBundle(path, task, targetHash, sourceHash, logf)
 		}
+		return sourceHash, s.pushTarget(path, task, sourceHash, logf)
+	})
+	if err != nil {
+		return "", err
+	}
+	rec.pushed = sourceHash
 
-		sType, sKey, sSecret := getAuthForRemote(task.SourceRepo, sourceRemote)
-		sRefSpec := fmt.Sprintf("+refs/heads/%s:refs/remotes/%s/%s", task.SourceBranch, sourceRemote, task.SourceBranch)
+	return commitRange, nil
+}
 
-		// Log Fetch Command (Approximate)
-		fetchCmd := fmt.Sprintf("git fetch %s %s", sourceRemote, sRefSpec)
-		logf("Command: %s", fetchCmd)
+func (s *SyncService) runHooks(rec *runRecorder, in *hookInput) error {
+	var applicable []prePushHook
+	for _, h := range s.hooks {
+		if h.applies(in.task) {
+			applicable = append(applicable, h)
+		}
+	}
+	if len(applicable) == 0 {
+		rec.skip(StepHooks, "no hooks enabled for this task")
+		return nil
+	}
 
-		if sourceURL != "" && sType != "" && sType != "none" {
-			logf("Fetching source %s (Auth: %s)...", sourceRemote, sType)
-			if err := s.git.FetchWithAuth(path, sourceURL, sType, sKey, sSecret, progressWriter, sRefSpec); err != nil {
-				return "", fmt.Errorf("fetch source failed: %v", err)
-			}
-		} else {
-			logf("Fetching source %s...", sourceRemote)
-			if err := s.git.Fetch(path, sourceRemote, progressWriter); err != nil {
-				return "", fmt.Errorf("fetch source failed: %v", err)
+	return rec.step(StepHooks, func() (string, error) {
+		var names []string
+		for _, h := range applicable {
+			in.logf("Running pre-push hook: %s", h.name)
+			if err := h.run(in); err != nil {
+				return "", fmt.Errorf("%s: %v", h.name, err)
 			}
+			names = append(names, h.name)
 		}
+		return strings.Join(names, ", "), nil
+	})
+}
 
-		// Get Hash from Remote Ref
-		h, err := s.git.GetCommitHash(path, task.SourceRemote, task.SourceBranch)
+func (s *SyncService) pushTarget(path string, task *po.SyncTask, sourceHash string, logf func(string, ...interface{})) error {
+	var pushOpts []string
+	if task.PushOptions != "" {
+		pushOpts = strings.Fields(task.PushOptions)
+	}
+
+	// Construct command for logging
+	cmdStr := fmt.Sprintf("git push %s %s:refs/heads/%s", task.TargetRemote, sourceHash, task.TargetBranch)
+	if len(pushOpts) > 0 {
+		cmdStr += " " + strings.Join(pushOpts, " ")
+	}
+	logf("Command: %s", cmdStr)
+	logf("Pushing to %s/%s with options: %v", task.TargetRemote, task.TargetBranch, pushOpts)
+
+	progressWriter := &logWriter{logf: logf}
+	targetURL, tType, tKey, tSecret := s.targetEndpoint(path, task)
+	if targetURL != "" && tType != "" && tType != "none" {
+		logf("Pushing target (Auth: %s)...", tType)
+		err := s.git.PushWithAuth(path, targetURL, sourceHash, task.TargetBranch, tType, tKey, tSecret, pushOpts, progressWriter)
 		if err != nil {
-			return "", fmt.Errorf("get source hash failed: %v", err)
+			return fmt.Errorf("push failed: %v", err)
 		}
-		sourceHash = h
 	} else {
-		// Local Source
+		if err := s.git.Push(path, task.TargetRemote, sourceHash, task.TargetBranch, pushOpts, progressWriter); err != nil {
+			return fmt.Errorf("push failed: %v", err)
+		}
+	}
+	return nil
+}
+
+// resolveSource fetches the task's source branch (unless the source is the local
+// repository) and returns the commit hash it points to.
+func (s *SyncService) resolveSource(path string, task *po.SyncTask, logf func(string, ...interface{})) (string, error) {
+	if task.SourceBundleDir != "" {
+		return s.resolveBundleSource(path, task, logf)
+	}
+
+	sourceRemote := task.SourceRemote
+	if sourceRemote == "" {
+		sourceRemote = "origin"
+	}
+
+	// Check if source is local
+	if sourceRemote == "local" {
 		// Get Hash from Local Head
 		logf("Using local branch: %s", task.SourceBranch)
 		h, err := s.git.ResolveRevision(path, task.SourceBranch)
 		if err != nil {
 			return "", fmt.Errorf("get local source hash failed: %v", err)
 		}
-		sourceHash = h
+		logf("Source hash (%s/%s): %s", task.SourceRemote, task.SourceBranch, h)
+		return h, nil
 	}
 
-	logf("Source hash (%s/%s): %s", task.SourceRemote, task.SourceBranch, sourceHash)
+	// Helper for progress logging
+	progressWriter := &logWriter{logf: logf}
+
+	sourceURL, _ := s.git.GetRemoteURL(path, sourceRemote)
+	if sourceURL == "" && sourceRemote == "origin" {
+		sourceURL = task.SourceRepo.RemoteURL
+	}
+
+	sType, sKey, sSecret := getAuthForRemote(task.SourceRepo, sourceRemote, sourceURL)
+	sRefSpec := fmt.Sprintf("+refs/heads/%s:refs/remotes/%s/%s", task.SourceBranch, sourceRemote, task.SourceBranch)
+
+	// Log Fetch Command (Approximate)
+	logf("Command: git fetch %s %s", sourceRemote, sRefSpec)
+
+	if sourceURL != "" && sType != "" && sType != "none" {
+		logf("Fetching source %s (Auth: %s)...", sourceRemote, sType)
+		if err := s.git.FetchWithAuth(path, sourceURL, sType, sKey, sSecret, progressWriter, sRefSpec); err != nil {
+			return "", fmt.Errorf("fetch source failed: %v", err)
+		}
+	} else {
+		logf("Fetching source %s...", sourceRemote)
+		if err := s.git.Fetch(path, sourceRemote, progressWriter); err != nil {
+			return "", fmt.Errorf("fetch source failed: %v", err)
+		}
+	}
+
+	// Get Hash from Remote Ref
+	h, err := s.git.GetCommitHash(path, task.SourceRemote, task.SourceBranch)
+	if err != nil {
+		return "", fmt.Errorf("get source hash failed: %v", err)
+	}
+	logf("Source hash (%s/%s): %s", task.SourceRemote, task.SourceBranch, h)
+	return h, nil
+}
+
+// resolveTarget fetches the task's target branch and returns the commit hash it
+// points to. exists is false when the target branch has not been created yet.
+func (s *SyncService) resolveTarget(path string, task *po.SyncTask, logf func(string, ...interface{})) (hash string, exists bool, err error) {
+	if task.TargetBundleDir != "" {
+		return s.resolveBundleTarget(path, task, logf)
+	}
 
-	// 2. Fetch Target
 	targetRemote := task.TargetRemote
 	if targetRemote == "" {
 		targetRemote = "origin"
 	}
 
-	targetURL, _ := s.git.GetRemoteURL(path, targetRemote)
-	if targetURL == "" && targetRemote == "origin" {
-		targetURL = task.TargetRepo.RemoteURL
-	}
-
-	tType, tKey, tSecret := getAuthForRemote(task.TargetRepo, targetRemote)
+	progressWriter := &logWriter{logf: logf}
+	targetURL, tType, tKey, tSecret := s.targetEndpoint(path, task)
 	tRefSpec := fmt.Sprintf("+refs/heads/%s:refs/remotes/%s/%s", task.TargetBranch, targetRemote, task.TargetBranch)
 
 	// Log Fetch Target Command
-	fetchTgtCmd := fmt.Sprintf("git fetch %s %s", targetRemote, tRefSpec)
-	logf("Command: %s", fetchTgtCmd)
+	logf("Command: git fetch %s %s", targetRemote, tRefSpec)
 
 	if targetURL != "" && tType != "" && tType != "none" {
 		logf("Fetching target %s (Auth: %s)...", targetRemote, tType)
 		if err := s.git.FetchWithAuth(path, targetURL, tType, tKey, tSecret, progressWriter, tRefSpec); err != nil {
-			return "", fmt.Errorf("fetch target failed: %v", err)
+			return "", false, fmt.Errorf("fetch target failed: %v", err)
 		}
 	} else {
 		logf("Fetching target %s...", targetRemote)
 		if err := s.git.Fetch(path, targetRemote, progressWriter); err != nil {
-			return "", fmt.Errorf("fetch target failed: %v", err)
+			return "", false, fmt.Errorf("fetch target failed: %v", err)
 		}
 	}
 
-	// 3. Get Hashes
-	// sourceHash already got
-
-	targetHash, err := s.git.GetCommitHash(path, task.TargetRemote, task.TargetBranch)
 	// Target branch might not exist yet (first sync).
-	targetExists := err == nil
-
-	if targetExists {
-		logf("Target hash (%s/%s): %s", task.TargetRemote, task.TargetBranch, targetHash)
-	} else {
+	targetHash, err := s.git.GetCommitHash(path, task.TargetRemote, task.TargetBranch)
+	if err != nil {
 		logf("Target branch does not exist yet")
+		return "", false, nil
 	}
+	logf("Target hash (%s/%s): %s", task.TargetRemote, task.TargetBranch, targetHash)
+	return targetHash, true, nil
+}
 
-	var commitRange string
-	if targetExists {
-		commitRange = fmt.Sprintf("%s..%s", targetHash, sourceHash)
-	} else {
-		commitRange = sourceHash // New branch
-	}
-
-	if targetExists {
-		if sourceHash == targetHash {
-			logf("Source and Target are at the same commit. No sync needed.")
-			return "", nil // Already synced
-		}
-
-		// 4. Check Fast-Forward
-		// Is Target an ancestor of Source?
-		isAncestor, err := s.git.IsAncestor(path, targetHash, sourceHash)
-		if err != nil {
-			return "", fmt.Errorf("check ancestor failed: %v", err)
-		}
-
-		if !isAncestor {
-			logf("Not a fast-forward update. Checking divergence...")
-			// Check if diverged or Source is behind
-			// If Source is ancestor of Target, Source is behind.
-			isSourceBehind, _ := s.git.IsAncestor(path, sourceHash, targetHash)
-			if isSourceBehind {
-				return "", fmt.Errorf("source is behind target")
-			}
-			return "", fmt.Errorf("conflict")
-		}
-		logf("Fast-forward check passed.")
-	}
-
-	// 5. Push
-	var pushOpts []string
-	if task.PushOptions != "" {
-		pushOpts = strings.Fields(task.PushOptions)
-	}
-
-	// Construct command for logging
-	cmdStr := fmt.Sprintf("git push %s %s:refs/heads/%s", task.TargetRemote, sourceHash, task.TargetBranch)
-	if len(pushOpts) > 0 {
-		cmdStr += " " + strings.Join(pushOpts, " ")
+// targetEndpoint returns the URL and credentials used to talk to the task's target remote.
+func (s *SyncService) targetEndpoint(path string, task *po.SyncTask) (url, authType, authKey, authSecret string) {
+	targetRemote := task.TargetRemote
+	if targetRemote == "" {
+		targetRemote = "origin"
 	}
-	logf("Command: %s", cmdStr)
-	logf("Pushing to %s/%s with options: %v", task.TargetRemote, task.TargetBranch, pushOpts)
 
-	if targetURL != "" && tType != "" && tType != "none" {
-		logf("Pushing target (Auth: %s)...", tType)
-		err := s.git.PushWithAuth(path, targetURL, sourceHash, task.TargetBranch, tType, tKey, tSecret, pushOpts, progressWriter)
-		if err != nil {
-			return "", fmt.Errorf("push failed: %v", err)
-		}
-	} else {
-		if err := s.git.Push(path, task.TargetRemote, sourceHash, task.TargetBranch, pushOpts, progressWriter); err != nil {
-			return "", fmt.Errorf("push failed: %v", err)
-		}
+	url, _ = s.git.GetRemoteURL(path, targetRemote)
+	if url == "" && targetRemote == "origin" {
+		url = task.TargetRepo.RemoteURL
 	}
-
-	return commitRange, nil
+	authType, authKey, authSecret = getAuthForRemote(task.TargetRepo, targetRemote, url)
+	return url, authType, authKey, authSecret
 }
 
 // LogWriter implements io.Writer
@@ -0,0 +1,85 @@
+package sync
+
+import (
+	"time"
+
+	"github.com/yi-nology/git-manage-service/biz/model/domain"
+	"github.com/yi-nology/git-manage-service/biz/model/po"
+)
+
+// Sync run kinds
+const (
+	RunKindSync     = "sync"
+	RunKindRollback = "rollback"
+)
+
+// Sync run phases, recorded as SyncRunStep rows
+const (
+	StepFetchSource   = "fetch_source"
+	StepFetchTarget   = "fetch_target"
+	StepAncestryCheck = "ancestry_check"
+	StepHooks         = "hooks"
+	StepPush          = "push"
+	StepCheckRollback = "check_rollback"
+)
+
+// maxRecordedCommits caps the commit listing stored per run (e.g. the first sync of a long history)
+const maxRecordedCommits = 1000
+
+// runRecorder collects the structured steps and the pushed commits of a sync run
+type runRecorder struct {
+	steps   []po.SyncRunStep
+	commits []po.SyncRunCommit
+
+	previousTarget string // target hash before the push, kept for rollbacks
+	pushed         string
+}
+
+// step runs fn as the named phase and records its timing, status and summary message
+func (r *runRecorder) step(name string, fn func() (string, error)) error {
+	start := time.Now()
+	msg, err := fn()
+	end := time.Now()
+
+	status := "success"
+	if err != nil {
+		status = "failed"
+		msg = err.Error()
+	}
+	r.steps = append(r.steps, po.SyncRunStep{
+		Seq:        len(r.steps) + 1,
+		Name:       name,
+		Status:     status,
+		Message:    msg,
+		StartTime:  start,
+		EndTime:    end,
+		DurationMs: end.Sub(start).Milliseconds(),
+	})
+	return err
+}
+
+// skip records a phase that did not need to run
+func (r *runRecorder) skip(name, reason string) {
+	now := time.Now()
+	r.steps = append(r.steps, po.SyncRunStep{
+		Seq:       len(r.steps) + 1,
+		Name:      name,
+		Status:    "skipped",
+		Message:   reason,
+		StartTime: now,
+		EndTime:   now,
+	})
+}
+
+func (r *runRecorder) setCommits(commits []domain.Commit) {
+	r.commits = r.commits[:0]
+	for _, c := range commits {
+		r.commits = append(r.commits, po.SyncRunCommit{
+			Hash:        c.Hash,
+			AuthorName:  c.Author,
+			AuthorEmail: c.Email,
+			Subject:     c.Message,
+			CommitTime:  c.Date,
+		})
+	}
+}
@@ -15,9 +15,18 @@ import (
 	kserver "github.com/cloudwego/kitex/server"
 	"github.com/yi-nology/git-manage-service/biz/dal/db"
 	"github.com/yi-nology/git-manage-service/biz/kitex_gen/git/gitservice"
+	"github.com/yi-nology/git-manage-service/biz/middleware"
 	"github.com/yi-nology/git-manage-service/biz/router"
 	"github.com/yi-nology/git-manage-service/biz/rpc_handler"
 	"github.com/yi-nology/git-manage-service/biz/service/audit"
+	"github.com/yi-nology/git-manage-service/biz/service/batch"
+	"github.com/yi-nology/git-manage-service/biz/service/credential"
+	"github.com/yi-nology/git-manage-service/biz/service/git"
+	"github.com/yi-nology/git-manage-service/biz/service/hostkey"
+	"github.com/yi-nology/git-manage-service/biz/service/maintenance"
+	"github.com/yi-nology/git-manage-service/biz/service/notify"
+	"github.com/yi-nology/git-manage-service/biz/service/search"
+	"github.com/yi-nology/git-manage-service/biz/service/sshkey"
 	"github.com/yi-nology/git-manage-service/biz/service/stats"
 	"github.com/yi-nology/git-manage-service/biz/service/sync"
 	"github.com/yi-nology/git-manage-service/biz/utils"
@@ -97,6 +106,9 @@ func main() {
 	<-quit
 	log.Println("Shutdown signal received, shutting down servers...")
 
+	// 终止进行中的 git 操作，避免挂起的远程请求阻塞退出
+	git.Shutdown()
+
 	// 优雅关闭
 	shutdownCtx, shutdownCancel := context.WithTimeout(ctx, 5*time.Second)
 	defer shutdownCancel()
@@ -133,10 +145,23 @@ func initResources() {
 	// 初始化加密工具
 	utils.InitEncryption()
 
+	// 清理上次异常退出遗留的临时工作区
+	git.InitWorkspaces()
+
+	// 加载 SSH 主机密钥库并生成 known_hosts，初始化托管 SSH 密钥与共享凭据
+	hostkey.InitHostKeyService()
+	sshkey.InitSSHKeyService()
+	credential.InitCredentialService()
+
 	// 初始化业务服务
 	sync.InitCronService()
 	stats.InitStatsService()
 	audit.InitAuditService()
+	notify.InitNotifyService()
+	search.InitSearchService()
+	batch.InitBatchService()
+	maintenance.InitMaintenanceService()
+	sync.InitLagMonitor()
 
 	log.Println("Resources initialized successfully")
 }
@@ -146,6 +171,9 @@ func startHTTPServer() *hserver.Hertz {
 	addr := fmt.Sprintf(":%d", configs.GlobalConfig.Server.Port)
 	h := hserver.Default(hserver.WithHostPorts(addr))
 
+	// 客户端断开时取消请求上下文，停止对应的 git 操作
+	h.Use(middleware.CancelOnDisconnect(time.Second))
+
 	// 注册路由
 	router.GeneratedRegister(h)
 
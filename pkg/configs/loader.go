@@ -24,6 +24,23 @@ func LoadConfig(configPaths []string, configName string, configType string) (Con
 	v.SetDefault("webhook.secret", "my-secret-key")
 	v.SetDefault("webhook.rate_limit", 100)
 	v.SetDefault("webhook.ip_whitelist", []string{})
+	v.SetDefault("monitor.lag_check_interval", "10m")
+	v.SetDefault("monitor.max_lag_commits", 0)
+	v.SetDefault("monitor.max_lag_minutes", 60)
+	v.SetDefault("notify.webhook_url", "")
+	v.SetDefault("workspace.root", "data/workspaces")
+	v.SetDefault("search.timeout", "10s")
+	v.SetDefault("search.max_concurrency", 4)
+	v.SetDefault("search.max_matches", 200)
+	v.SetDefault("ssh.host_key_mode", "tofu")
+	v.SetDefault("ssh.known_hosts_file", "data/known_hosts")
+	v.SetDefault("git.timeouts.fetch", "10m")
+	v.SetDefault("git.timeouts.push", "10m")
+	v.SetDefault("git.timeouts.clone", "30m")
+	v.SetDefault("git.timeouts.local", "5m")
+	v.SetDefault("git.timeouts.maintenance", "1h")
+	v.SetDefault("maintenance.schedule", "0 3 * * 0")
+	v.SetDefault("maintenance.tasks", []string{"fsck", "gc", "commit-graph"})
 
 	// Environment variables override
 	v.AutomaticEnv()
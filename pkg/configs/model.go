@@ -1,10 +1,17 @@
 package configs
 
 type Config struct {
-	Server   ServerConfig   `mapstructure:"server"`
-	Database DatabaseConfig `mapstructure:"database"`
-	Webhook  WebhookConfig  `mapstructure:"webhook"`
-	Rpc      RpcConfig      `mapstructure:"rpc"`
+	Server      ServerConfig      `mapstructure:"server"`
+	Database    DatabaseConfig    `mapstructure:"database"`
+	Webhook     WebhookConfig     `mapstructure:"webhook"`
+	Rpc         RpcConfig         `mapstructure:"rpc"`
+	Monitor     MonitorConfig     `mapstructure:"monitor"`
+	Notify      NotifyConfig      `mapstructure:"notify"`
+	Workspace   WorkspaceConfig   `mapstructure:"workspace"`
+	Search      SearchConfig      `mapstructure:"search"`
+	SSH         SSHConfig         `mapstructure:"ssh"`
+	Git         GitConfig         `mapstructure:"git"`
+	Maintenance MaintenanceConfig `mapstructure:"maintenance"`
 }
 
 type ServerConfig struct {
@@ -31,3 +38,46 @@ type WebhookConfig struct {
 	RateLimit   int      `mapstructure:"rate_limit"`
 	IPWhitelist []string `mapstructure:"ip_whitelist"`
 }
+
+type MonitorConfig struct {
+	LagCheckInterval string `mapstructure:"lag_check_interval"` // e.g. "10m", empty disables the checker
+	MaxLagCommits    int    `mapstructure:"max_lag_commits"`    // 0 disables the commit threshold
+	MaxLagMinutes    int    `mapstructure:"max_lag_minutes"`    // 0 disables the age threshold
+}
+
+type NotifyConfig struct {
+	WebhookURL string `mapstructure:"webhook_url"` // Optional: receives alerts as JSON POST
+}
+
+type WorkspaceConfig struct {
+	Root string `mapstructure:"root"` // Directory for ephemeral worktrees used by merge/cherry-pick etc.
+}
+
+type SearchConfig struct {
+	Timeout        string `mapstructure:"timeout"`         // per repository, e.g. "10s"
+	MaxConcurrency int    `mapstructure:"max_concurrency"` // git processes running searches at once
+	MaxMatches     int    `mapstructure:"max_matches"`     // upper bound of results per repository
+}
+
+type SSHConfig struct {
+	HostKeyMode    string `mapstructure:"host_key_mode"`    // tofu, approve
+	KnownHostsFile string `mapstructure:"known_hosts_file"` // generated for the git CLI from the trusted keys
+}
+
+type GitConfig struct {
+	Timeouts GitTimeouts `mapstructure:"timeouts"`
+}
+
+// GitTimeouts bound git operations by class, e.g. "10m"; "0" disables a timeout
+type GitTimeouts struct {
+	Fetch       string `mapstructure:"fetch"` // fetch, pull, ls-remote
+	Push        string `mapstructure:"push"`
+	Clone       string `mapstructure:"clone"`
+	Local       string `mapstructure:"local"`       // everything that does not talk to a remote
+	Maintenance string `mapstructure:"maintenance"` // fsck, gc, repack, commit-graph
+}
+
+type MaintenanceConfig struct {
+	Schedule string   `mapstructure:"schedule"` // cron spec, e.g. "0 3 * * 0"; empty disables scheduled runs
+	Tasks    []string `mapstructure:"tasks"`    // fsck, gc, repack, commit-graph
+}
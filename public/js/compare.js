@@ -243,6 +243,7 @@ async function submitMerge() {
     const source = document.getElementById('sourceBranch').value;
     const target = document.getElementById('targetBranch').value;
     const message = document.getElementById('mergeForm').message.value;
+    const strategy = document.getElementById('mergeForm').strategy.value;
     
     const btn = document.getElementById('confirmMergeBtn');
     btn.disabled = true;
@@ -251,7 +252,7 @@ async function submitMerge() {
     try {
         const res = await request('/branch/merge', {
             method: 'POST',
-            body: { repo_key: repoKey, source, target, message }
+            body: { repo_key: repoKey, source, target, message, strategy }
         });
         
         // Handled by request logic? Wait, request.js throws on error?
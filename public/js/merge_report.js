@@ -44,7 +44,33 @@ async function loadConflicts() {
             return;
         }
 
-        if (res.conflicts && res.conflicts.length > 0) {
+        if (res.conflict_details && res.conflict_details.length > 0) {
+            res.conflict_details.forEach(c => {
+                const hunks = (c.hunks || []).map(h =>
+                    `<div class="small text-muted">行 ${h.start_line}-${h.end_line}（当前 ${h.ours_start}-${h.ours_end}，合入 ${h.theirs_start}-${h.theirs_end}）</div>`
+                ).join('');
+                const li = document.createElement('li');
+                li.className = 'list-group-item';
+                li.innerHTML = `
+                    <div class="d-flex justify-content-between align-items-center">
+                        <span class="font-monospace"><i class="bi bi-file-earmark-code"></i> ${c.path}</span>
+                        <span class="badge bg-danger rounded-pill">${c.type}</span>
+                    </div>
+                    <div class="small">${c.message}</div>
+                    ${hunks}
+                `;
+                list.appendChild(li);
+            });
+            (res.auto_merged || []).forEach(file => {
+                const li = document.createElement('li');
+                li.className = 'list-group-item d-flex justify-content-between align-items-center';
+                li.innerHTML = `
+                    <span class="font-monospace"><i class="bi bi-file-earmark-check"></i> ${file}</span>
+                    <span class="badge bg-success rounded-pill">自动合并</span>
+                `;
+                list.appendChild(li);
+            });
+        } else if (res.conflicts && res.conflicts.length > 0) {
             res.conflicts.forEach(file => {
                 const li = document.createElement('li');
                 li.className = 'list-group-item d-flex justify-content-between align-items-center';